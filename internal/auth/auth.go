@@ -2,78 +2,252 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
 	"github.com/dgrijalva/jwt-go"
 	uuid "github.com/satori/go.uuid"
 )
 
+// AccessTokenTTL -> lifetime of access tokens, refresh tokens are used to get new ones
+const AccessTokenTTL = 15 * time.Minute
+
+var (
+	// ErrNoKeys -> UseKeys wasn't called
+	ErrNoKeys = errors.New("No signing keys")
+	// ErrNoSecret -> UseSecret wasn't called
+	ErrNoSecret = errors.New("No server secret")
+	// ErrSecretTooShort is returned by UseSecret for secrets shorter than MinSecretLength
+	ErrSecretTooShort = errors.New("Server secret too short")
+	// ErrTokenMissing is returned for requests without a token in any of the accepted sources
+	ErrTokenMissing = errors.New("Missing token")
+	// ErrTokenMalformed is returned for tokens that can't be decoded or lack required claims
+	ErrTokenMalformed = errors.New("Malformed token")
+	// ErrTokenExpired ...
+	ErrTokenExpired = errors.New("Token expired")
+	// ErrTokenRevoked is returned by middleware.SetAuth for tokens logged out or issued before the user's sessions were revoked
+	ErrTokenRevoked = errors.New("Token revoked")
+)
+
+// TokenSource -> where access tokens are looked for in requests
+type TokenSource string
+
+// Token sources
+const (
+	SourceHeader TokenSource = "header" // Authorization: Bearer <token>
+	SourceCookie TokenSource = "cookie" // access_token cookie
+	SourceQuery  TokenSource = "query"  // ?token=, ends up in access logs
+)
+
+// AccessTokenCookie -> name of the cookie read by SourceCookie
+const AccessTokenCookie = "access_token"
+
+// Config -> how access tokens are issued and checked
+type Config struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration // Clock skew allowed when checking exp, nbf and iat
+	Sources  []TokenSource // Looked at in order, the first one with a token wins
+}
+
+// DefaultConfig -> only the Authorization header is accepted
+var DefaultConfig = Config{
+	Issuer:   "trackr",
+	Audience: "trackr-api",
+	Leeway:   30 * time.Second,
+	Sources:  []TokenSource{SourceHeader},
+}
+
+var config = DefaultConfig
+
+// Configure -> sets the token configuration, zero fields keep their defaults. Called once on start.
+func Configure(custom Config) {
+	config = DefaultConfig
+	if custom.Issuer != "" {
+		config.Issuer = custom.Issuer
+	}
+	if custom.Audience != "" {
+		config.Audience = custom.Audience
+	}
+	if custom.Leeway > 0 {
+		config.Leeway = custom.Leeway
+	}
+	if len(custom.Sources) > 0 {
+		config.Sources = custom.Sources
+	}
+}
+
+// KeySet -> keys access tokens are signed with and verified against, see keys.Manager
+type KeySet interface {
+	SigningKey() (*keys.Key, error)
+	Key(string) (*keys.Key, bool)
+}
+
+var keySet KeySet
+
+// UseKeys -> sets the key set of access tokens, called once on start before any token is issued or verified
+func UseKeys(set KeySet) {
+	keySet = set
+}
+
+// MinSecretLength -> shortest server secret accepted, in bytes
+const MinSecretLength = 32
+
+// serverSecret -> keys the signatures of emailed tokens and the encryption of sealed secrets
+var serverSecret []byte
+
+// UseSecret -> sets the server secret, called once on start before any token is signed or secret sealed
+func UseSecret(secret string) error {
+	if len(secret) < MinSecretLength {
+		return ErrSecretTooShort
+	}
+
+	serverSecret = []byte(secret)
+	return nil
+}
+
 // CreateToken ...
-func CreateToken(userID uuid.UUID) (string, error) {
-	claims := jwt.MapClaims{}
-	claims["authorized"] = true
-	claims["user_id"] = userID.String()
-	claims["is_admin"] = false
-	claims["exp"] = time.Now().Add(time.Hour * 1).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("API_SECRET")))
+func CreateToken(userID uuid.UUID, role string) (string, error) {
+	token, _, err := signToken(newClaims(userID, role))
+	return token, err
 }
 
-// TokenValid ...
-func TokenValid(request *http.Request) error {
-	tokenString := ExtractToken(request)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(os.Getenv("API_SECRET")), nil
-	})
+// CreateImpersonationToken -> token for the user issued to actorID, who is named in its act claim.
+// The jti is returned along with the token so its issuance can be audited.
+func CreateImpersonationToken(userID uuid.UUID, role string, actorID uuid.UUID) (string, string, error) {
+	claims := newClaims(userID, role)
+	claims.Actor = &Actor{Subject: actorID.String()}
+	return signToken(claims)
+}
+
+func newClaims(userID uuid.UUID, role string) *Claims {
+	now := time.Now()
+	return &Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewV4().String(),
+			Subject:   userID.String(),
+			Issuer:    config.Issuer,
+			Audience:  config.Audience,
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+		},
+		Authorized: true,
+		UserID:     userID.String(),
+		Role:       role,
+		IsAdmin:    role == "admin",
+	}
+}
+
+// signToken -> signs with the current signing key, named in the kid header. Returns the jti along with the token.
+func signToken(claims *Claims) (string, string, error) {
+	if keySet == nil {
+		return "", "", ErrNoKeys
+	}
+
+	key, err := keySet.SigningKey()
 	if err != nil {
-		return err
+		return "", "", err
 	}
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		return "", "", err
 	}
-	return nil
+
+	return signed, claims.Id, nil
 }
 
-// ExtractToken ...
+// TokenValid ...
+func TokenValid(request *http.Request) error {
+	_, err := ParseRequest(request)
+	return err
+}
+
+// ExtractToken -> token of the request from the first configured source that has one
 func ExtractToken(request *http.Request) string {
-	keys := request.URL.Query()
-	token := keys.Get("token")
-	if token != "" {
-		return token
-	}
-	bearerToken := request.Header.Get("Authorization")
-	if len(strings.Split(bearerToken, " ")) == 2 {
-		return strings.Split(bearerToken, " ")[1]
+	for _, source := range config.Sources {
+		switch source {
+		case SourceHeader:
+			parts := strings.Fields(request.Header.Get("Authorization"))
+			if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+				return parts[1]
+			}
+		case SourceCookie:
+			cookie, err := request.Cookie(AccessTokenCookie)
+			if err == nil && cookie.Value != "" {
+				return cookie.Value
+			}
+		case SourceQuery:
+			token := request.URL.Query().Get("token")
+			if token != "" {
+				return token
+			}
+		}
 	}
 	return ""
 }
 
 // ExtractUserID ...
 func ExtractUserID(request *http.Request) (string, error) {
+	claims, err := ParseRequest(request)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// ParseRequest -> verified claims of the request's token.
+// Errors are ErrTokenMissing, ErrTokenMalformed, ErrTokenExpired or ErrInvalidToken.
+func ParseRequest(request *http.Request) (*Claims, error) {
 	tokenString := ExtractToken(request)
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	if tokenString == "" {
+		return nil, ErrTokenMissing
+	}
+	return ParseToken(tokenString)
+}
+
+// ParseToken -> verified claims of an access token, see ParseRequest
+func ParseToken(tokenString string) (*Claims, error) {
+	if keySet == nil {
+		return nil, ErrNoKeys
+	}
+
+	claims := &Claims{}
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := keySet.Key(keyID)
+		if !ok {
+			return nil, fmt.Errorf("Unknown signing key: %v", token.Header["kid"])
+		}
+		// The algorithm is the key's, never the one the token claims
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(os.Getenv("API_SECRET")), nil
+		return key.Public(), nil
 	})
+	if validationError, ok := err.(*jwt.ValidationError); ok && validationError.Errors&jwt.ValidationErrorMalformed != 0 {
+		return nil, ErrTokenMalformed
+	}
+
 	if err != nil {
-		return "", err
+		return nil, ErrInvalidToken
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		userID := claims["user_id"].(string)
-		return userID, nil
+
+	err = claims.validate(config, time.Now())
+	if err != nil {
+		return nil, err
 	}
-	return "", nil
+
+	return claims, nil
 }
 
 // Pretty display the claims nicely in the terminal
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims -> claims of access tokens, the jti is StandardClaims.Id
+type Claims struct {
+	jwt.StandardClaims
+	Authorized bool   `json:"authorized"`
+	UserID     string `json:"user_id"`
+	Role       string `json:"role"`
+	IsAdmin    bool   `json:"is_admin"`
+	Actor      *Actor `json:"act,omitempty"`
+}
+
+// Actor -> user impersonating the subject of a token, see RFC 8693
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// Valid -> claims are checked by validate once the signature is verified, jwt-go has no leeway
+func (claims *Claims) Valid() error {
+	return nil
+}
+
+// validate -> checks the claims at now, allowing leeway for clock skew between servers
+func (claims *Claims) validate(config Config, now time.Time) error {
+	if claims.UserID == "" || claims.Id == "" || claims.ExpiresAt == 0 {
+		return ErrTokenMalformed
+	}
+
+	if now.Add(-config.Leeway).Unix() > claims.ExpiresAt {
+		return ErrTokenExpired
+	}
+
+	if now.Add(config.Leeway).Unix() < claims.NotBefore || now.Add(config.Leeway).Unix() < claims.IssuedAt {
+		return ErrInvalidToken
+	}
+
+	if claims.Issuer != config.Issuer || claims.Audience != config.Audience {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// IssuedAtTime ...
+func (claims *Claims) IssuedAtTime() time.Time {
+	return time.Unix(claims.IssuedAt, 0)
+}
+
+// ExpiresAtTime -> after it the token no longer needs to be denied
+func (claims *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(claims.ExpiresAt, 0)
+}
+
+// ActorID -> ID of the user impersonating the token's user, empty for regular tokens
+func (claims *Claims) ActorID() string {
+	if claims.Actor == nil {
+		return ""
+	}
+	return claims.Actor.Subject
+}
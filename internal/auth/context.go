@@ -0,0 +1,56 @@
+package auth
+
+import "context"
+
+type contextKey string
+
+const (
+	userIDKey   contextKey = "user_id"
+	roleKey     contextKey = "role"
+	actorIDKey  contextKey = "actor_id"
+	apiKeyIDKey contextKey = "api_key_id"
+)
+
+// WithUserID -> returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext -> returns the authenticated user ID set by WithUserID
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok && userID != ""
+}
+
+// WithRole -> returns a copy of ctx carrying the role of the authenticated user
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext -> returns the role set by WithRole
+func RoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(roleKey).(string)
+	return role, ok && role != ""
+}
+
+// WithActorID -> returns a copy of ctx carrying the ID of the user impersonating the authenticated user
+func WithActorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorIDKey, actorID)
+}
+
+// ActorIDFromContext -> returns the impersonating user ID set by WithActorID
+func ActorIDFromContext(ctx context.Context) (string, bool) {
+	actorID, ok := ctx.Value(actorIDKey).(string)
+	return actorID, ok && actorID != ""
+}
+
+// WithAPIKeyID -> returns a copy of ctx carrying the ID of the API key the request was authenticated with
+func WithAPIKeyID(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, apiKeyIDKey, keyID)
+}
+
+// APIKeyIDFromContext -> returns the API key ID set by WithAPIKeyID, absent for requests authenticated with a token
+func APIKeyIDFromContext(ctx context.Context) (string, bool) {
+	keyID, ok := ctx.Value(apiKeyIDKey).(string)
+	return keyID, ok && keyID != ""
+}
@@ -0,0 +1,188 @@
+package keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Signing algorithms
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+const rsaKeySize = 2048
+
+// ErrUnsupportedAlgorithm ...
+var ErrUnsupportedAlgorithm = errors.New("Unsupported signing algorithm")
+
+// Key -> private signing key, identified in token headers by its kid
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	Private   crypto.Signer
+}
+
+// Generate -> new key for algorithm, created at now
+func Generate(algorithm string, now time.Time) (*Key, error) {
+	var private crypto.Signer
+	var err error
+
+	switch algorithm {
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:        uuid.NewV4().String(),
+		Algorithm: algorithm,
+		CreatedAt: now,
+		Private:   private,
+	}, nil
+}
+
+// newKey -> key for a stored private key, the algorithm follows from its type
+func newKey(id string, private interface{}, createdAt time.Time) (*Key, error) {
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Algorithm: RS256, CreatedAt: createdAt, Private: private}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: EdDSA, CreatedAt: createdAt, Private: private}, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// Public -> key tokens signed with the key are verified with
+func (key *Key) Public() crypto.PublicKey {
+	return key.Private.Public()
+}
+
+// SigningMethod ...
+func (key *Key) SigningMethod() jwt.SigningMethod {
+	if key.Algorithm == EdDSA {
+		return SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK -> public part of a key as described in RFC 7517
+func (key *Key) JWK() JWK {
+	jwk := JWK{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Algorithm,
+	}
+
+	switch public := key.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
+
+// JWK ...
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// PublicKey -> key described by the JWK, for verifying tokens of other issuers.
+// RSA, EC and Ed25519 keys are supported.
+func (jwk JWK) PublicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedAlgorithm
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		public := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(public.X, public.Y) {
+			return nil, errors.New("Invalid EC key")
+		}
+		return public, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if jwk.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// JWKSet -> served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,278 @@
+// +build !integration
+
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/dgrijalva/jwt-go"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func newTestLogger() logger.Logger {
+	logger, err := logger.NewZapLogger(logger.Config{
+		EnableConsole: false,
+		EnableFile:    false,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return logger
+}
+
+func TestManagerRotate(t *testing.T) {
+
+	store := NewMemoryStore()
+	manager, err := NewManager(store, EdDSA, 10*time.Hour, time.Hour, newTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := manager.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := first.CreatedAt
+
+	err = manager.Rotate(now.Add(5 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(manager.JWKS().Keys), 1)
+
+	// The new key is published right away, the first one stays until the new one signed for an overlap
+	err = manager.Rotate(now.Add(10 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(manager.JWKS().Keys), 2)
+
+	err = manager.Rotate(now.Add(12*time.Hour - time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(manager.JWKS().Keys), 2)
+
+	err = manager.Rotate(now.Add(12 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(manager.JWKS().Keys), 1)
+
+	_, ok := manager.Key(first.ID)
+	assert.Equal(t, ok, false)
+
+	stored, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(stored), 1)
+}
+
+func TestManagerSigningKey(t *testing.T) {
+
+	now := time.Now()
+
+	old, err := Generate(EdDSA, now.Add(-5*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		createdAt time.Time
+		signing   bool
+	}{
+		{createdAt: now.Add(-10 * time.Minute), signing: false},
+		{createdAt: now.Add(-2 * time.Hour), signing: true},
+	}
+
+	for _, c := range cases {
+		store := NewMemoryStore()
+
+		recent, err := Generate(EdDSA, c.createdAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, key := range []*Key{old, recent} {
+			err = store.Put(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		manager, err := NewManager(store, EdDSA, 10*time.Hour, time.Hour, newTestLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := manager.SigningKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, key.ID == recent.ID, c.signing)
+	}
+}
+
+func TestNewManager_Invalid(t *testing.T) {
+
+	_, err := NewManager(NewMemoryStore(), "HS256", 0, 0, newTestLogger())
+	assert.Equal(t, err, ErrUnsupportedAlgorithm)
+
+	_, err = NewManager(NewMemoryStore(), EdDSA, 2*time.Hour, time.Hour, newTestLogger())
+	assert.NotEqual(t, err, nil)
+}
+
+func TestDirStore(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := NewDirStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	for i, algorithm := range []string{RS256, EdDSA} {
+		key, err := Generate(algorithm, now.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = store.Put(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stored, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(stored), 2)
+	assert.Equal(t, stored[0].Algorithm, RS256)
+	assert.Equal(t, stored[0].CreatedAt.Equal(now), true)
+	assert.Equal(t, stored[1].Algorithm, EdDSA)
+
+	err = store.Delete(stored[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting twice is fine
+	err = store.Delete(stored[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err = store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(stored), 1)
+}
+
+func TestSigningMethods(t *testing.T) {
+
+	for _, algorithm := range []string{RS256, EdDSA} {
+		key, err := Generate(algorithm, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := jwt.NewWithClaims(key.SigningMethod(), jwt.MapClaims{"sub": "random"}).SignedString(key.Private)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+			return key.Public(), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, token.Valid, true)
+		assert.Equal(t, token.Method.Alg(), algorithm)
+
+		// Keys of the same algorithm don't verify each other's tokens
+		other, err := Generate(algorithm, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+			return other.Public(), nil
+		})
+		assert.NotEqual(t, err, nil)
+	}
+}
+
+func TestJWK(t *testing.T) {
+
+	rsaKey, err := Generate(RS256, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk := rsaKey.JWK()
+	assert.Equal(t, jwk.KeyType, "RSA")
+	assert.Equal(t, jwk.KeyID, rsaKey.ID)
+	assert.Equal(t, jwk.E, "AQAB")
+	assert.Equal(t, jwk.Use, "sig")
+
+	edKey, err := Generate(EdDSA, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk = edKey.JWK()
+	assert.Equal(t, jwk.KeyType, "OKP")
+	assert.Equal(t, jwk.Curve, "Ed25519")
+	assert.Equal(t, jwk.Algorithm, "EdDSA")
+	assert.Equal(t, len(jwk.X), 43)
+}
+
+func TestJWK_PublicKey(t *testing.T) {
+
+	for _, algorithm := range []string{RS256, EdDSA} {
+		key, err := Generate(algorithm, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		public, err := key.JWK().PublicKey()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, public, key.Public())
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := JWK{
+		KeyType: "EC",
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+		Y:       base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+	}.PublicKey()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, public.(*ecdsa.PublicKey).X, ecKey.X)
+
+	_, err = JWK{KeyType: "oct"}.PublicKey()
+	assert.Equal(t, err, ErrUnsupportedAlgorithm)
+}
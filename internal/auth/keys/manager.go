@@ -0,0 +1,212 @@
+package keys
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+// Defaults used when the manager is given none
+const (
+	DefaultRotation = 30 * 24 * time.Hour
+	DefaultOverlap  = time.Hour
+	DefaultInterval = time.Minute
+)
+
+// ErrNoKey ...
+var ErrNoKey = errors.New("No signing key")
+
+// Manager -> rotates the keys of a store and picks the one tokens are signed with.
+//
+// A new key is published as soon as it's created but only signs tokens once overlap has passed,
+// so verifiers caching the key set learn about it first. Once a newer key signs tokens, older keys
+// are kept for another overlap so the tokens they signed can still be verified. Overlap must
+// therefore exceed both the lifetime of tokens and the time verifiers cache the key set.
+type Manager struct {
+	store     Store
+	algorithm string
+	rotation  time.Duration
+	overlap   time.Duration
+	logger    logger.Logger
+
+	mu   sync.RWMutex
+	keys []*Key // Oldest first
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager -> loads the keys of store, creating one if needed
+func NewManager(store Store, algorithm string, rotation, overlap time.Duration, logger logger.Logger) (*Manager, error) {
+	if algorithm != RS256 && algorithm != EdDSA {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	if rotation <= 0 {
+		rotation = DefaultRotation
+	}
+
+	if overlap <= 0 {
+		overlap = DefaultOverlap
+	}
+
+	if rotation <= 2*overlap {
+		return nil, errors.New("Key rotation must be longer than twice the overlap")
+	}
+
+	manager := &Manager{
+		store:     store,
+		algorithm: algorithm,
+		rotation:  rotation,
+		overlap:   overlap,
+		logger:    logger,
+	}
+
+	err := manager.Rotate(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return manager, nil
+}
+
+// Rotate -> reloads the store, creates a key when the newest one is due for rotation at now and removes retired keys
+func (manager *Manager) Rotate(now time.Time) error {
+	keys, err := manager.store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 || !keys[len(keys)-1].CreatedAt.After(now.Add(-manager.rotation)) {
+		key, err := Generate(manager.algorithm, now)
+		if err != nil {
+			return err
+		}
+
+		err = manager.store.Put(key)
+		if err != nil {
+			return err
+		}
+
+		manager.logger.Infof("Created signing key %s", key.ID)
+		keys = append(keys, key)
+	}
+
+	// A key is retired once a newer one signed tokens for longer than overlap
+	retained := []*Key{}
+	for i, key := range keys {
+		retired := false
+		for _, newer := range keys[i+1:] {
+			if !now.Before(newer.CreatedAt.Add(2 * manager.overlap)) {
+				retired = true
+				break
+			}
+		}
+
+		if !retired {
+			retained = append(retained, key)
+			continue
+		}
+
+		err = manager.store.Delete(key.ID)
+		if err != nil {
+			return err
+		}
+		manager.logger.Infof("Removed signing key %s", key.ID)
+	}
+
+	manager.mu.Lock()
+	manager.keys = retained
+	manager.mu.Unlock()
+
+	return nil
+}
+
+// SigningKey -> newest key published for longer than overlap, or the oldest key when none is
+func (manager *Manager) SigningKey() (*Key, error) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	if len(manager.keys) == 0 {
+		return nil, ErrNoKey
+	}
+
+	published := time.Now().Add(-manager.overlap)
+	for i := len(manager.keys) - 1; i >= 0; i-- {
+		if !manager.keys[i].CreatedAt.After(published) {
+			return manager.keys[i], nil
+		}
+	}
+
+	return manager.keys[0], nil
+}
+
+// Key -> key with the given kid, tokens signed by unknown keys are rejected
+func (manager *Manager) Key(id string) (*Key, bool) {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	for _, key := range manager.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// JWKS -> public keys of every key, including the ones not signing yet
+func (manager *Manager) JWKS() JWKSet {
+	manager.mu.RLock()
+	defer manager.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range manager.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+
+	return set
+}
+
+// Start -> rotates keys in the background until Stop is called, which also picks up keys created by other replicas
+func (manager *Manager) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.cancel = cancel
+
+	manager.wg.Add(1)
+	go func() {
+		defer manager.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				err := manager.Rotate(now)
+				if err != nil {
+					manager.logger.Errorf("Failed to rotate signing keys: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Stop -> waits for the current rotation to finish
+func (manager *Manager) Stop() {
+	if manager.cancel == nil {
+		return
+	}
+
+	manager.cancel()
+	manager.wg.Wait()
+}
@@ -0,0 +1,189 @@
+package keys
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var idPattern = regexp.MustCompile(`^[0-9a-f-]{36}$`)
+
+// Store -> where keys are kept, replicas share keys by sharing a store
+type Store interface {
+	List() ([]*Key, error)
+	Put(*Key) error
+	Delete(id string) error
+}
+
+// DirStore -> Store keeping every key in a PEM file named after its ID
+type DirStore struct {
+	root string
+}
+
+// NewDirStore -> creates root if it doesn't exist, only the owner can read it
+func NewDirStore(root string) (*DirStore, error) {
+	err := os.MkdirAll(root, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirStore{
+		root: root,
+	}, nil
+}
+
+// List -> keys sorted from the oldest to the newest
+func (store *DirStore) List() ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(store.root, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []*Key{}
+	for _, path := range paths {
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if !idPattern.MatchString(id) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("Invalid key file " + path)
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, block.Headers["Created-At"])
+		if err != nil {
+			return nil, err
+		}
+
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := newKey(id, private, createdAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+// Put -> writes to a temporary file first so other replicas never read partial keys
+func (store *DirStore) Put(key *Key) error {
+	if !idPattern.MatchString(key.ID) {
+		return errors.New("Invalid key ID")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(store.root, key.ID+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = pem.Encode(file, &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{"Created-At": key.CreatedAt.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	})
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filepath.Join(store.root, key.ID+".pem"))
+}
+
+// Delete -> deleting a missing key is not an error
+func (store *DirStore) Delete(id string) error {
+	if !idPattern.MatchString(id) {
+		return errors.New("Invalid key ID")
+	}
+
+	err := os.Remove(filepath.Join(store.root, id+".pem"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// MemoryStore -> Store for a single process, keys are lost on restart
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]*Key
+}
+
+// NewMemoryStore ...
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys: map[string]*Key{},
+	}
+}
+
+// List -> keys sorted from the oldest to the newest
+func (store *MemoryStore) List() ([]*Key, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	keys := []*Key{}
+	for _, key := range store.keys {
+		keys = append(keys, key)
+	}
+
+	sortKeys(keys)
+	return keys, nil
+}
+
+// Put ...
+func (store *MemoryStore) Put(key *Key) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.keys[key.ID] = key
+	return nil
+}
+
+// Delete ...
+func (store *MemoryStore) Delete(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	delete(store.keys, id)
+	return nil
+}
+
+func sortKeys(keys []*Key) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
@@ -0,0 +1,130 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// leeway -> clock skew tolerated between the provider and this server
+const leeway = time.Minute
+
+// signingMethods -> ID tokens signed otherwise are rejected, none and HMAC in particular
+var signingMethods = map[string]bool{
+	"RS256": true, "RS384": true, "RS512": true,
+	"PS256": true, "PS384": true, "PS512": true,
+	"ES256": true, "ES384": true, "ES512": true,
+	keys.EdDSA: true,
+}
+
+// audience -> the aud claim is either a string or an array of strings
+type audience []string
+
+// UnmarshalJSON ...
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	single := ""
+	if json.Unmarshal(data, &single) == nil {
+		*aud = audience{single}
+		return nil
+	}
+
+	multiple := []string{}
+	err := json.Unmarshal(data, &multiple)
+	if err != nil {
+		return err
+	}
+
+	*aud = multiple
+	return nil
+}
+
+func (aud audience) contains(value string) bool {
+	for _, item := range aud {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// idTokenClaims -> claims of ID tokens, see OpenID Connect Core 2 and 5.1
+type idTokenClaims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        audience `json:"aud"`
+	AuthorizedParty string   `json:"azp"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	Nonce           string   `json:"nonce"`
+	Email           string   `json:"email"`
+	EmailVerified   bool     `json:"email_verified"`
+	Name            string   `json:"name"`
+	GivenName       string   `json:"given_name"`
+	FamilyName      string   `json:"family_name"`
+}
+
+// Valid -> claims are checked by validate once the signature is verified
+func (claims *idTokenClaims) Valid() error {
+	return nil
+}
+
+// validate -> checks the claims against the client and the nonce of the login, see OpenID Connect Core 3.1.3.7
+func (claims *idTokenClaims) validate(issuer, clientID, nonce string, now time.Time) error {
+	if claims.Issuer != issuer || claims.Subject == "" {
+		return ErrInvalidIDToken
+	}
+
+	if !claims.Audience.contains(clientID) {
+		return ErrInvalidIDToken
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != clientID {
+		return ErrInvalidIDToken
+	}
+
+	if claims.ExpiresAt == 0 || now.Add(-leeway).Unix() > claims.ExpiresAt || now.Add(leeway).Unix() < claims.IssuedAt {
+		return ErrInvalidIDToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return ErrInvalidIDToken
+	}
+
+	return nil
+}
+
+// verify -> identity in the ID token once its signature and claims are checked
+func (provider *Provider) verify(ctx context.Context, metadata *metadata, idToken, nonce string) (*Identity, error) {
+
+	claims := idTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if !signingMethods[token.Method.Alg()] {
+			return nil, ErrInvalidIDToken
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		return provider.publicKey(ctx, metadata, keyID)
+	})
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+
+	err = claims.validate(metadata.Issuer, provider.config.ClientID, nonce, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Provider:      provider.config.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
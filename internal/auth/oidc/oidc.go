@@ -0,0 +1,302 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// keysRefreshInterval -> unknown key IDs trigger a JWKS refresh at most this often
+	keysRefreshInterval = time.Minute
+
+	requestTimeout = 10 * time.Second
+)
+
+var (
+	// ErrExchangeFailed is returned when the provider rejects the authorization code
+	ErrExchangeFailed = errors.New("Authorization code exchange failed")
+	// ErrInvalidIDToken is returned for ID tokens with a bad signature or claims
+	ErrInvalidIDToken = errors.New("Invalid ID token")
+)
+
+// DefaultScopes -> requested when a provider is configured without scopes
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// Config -> client registered with an OpenID provider
+type Config struct {
+	Name         string // Used in routes, like google in /auth/oidc/google
+	DiscoveryURL string // Issuer URL, /.well-known/openid-configuration is appended when missing
+	ClientID     string
+	ClientSecret string // Empty for public clients, which rely on PKCE alone
+	Scopes       []string
+	RedirectURL  string
+}
+
+// Identity -> user as described by the ID token of a provider
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+}
+
+// metadata -> fields of the discovery document used by the client
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider -> OpenID Connect client of the authorization code flow with PKCE.
+// The discovery document and keys are fetched on first use and cached.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mutex         sync.Mutex
+	metadata      *metadata
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider -> doesn't contact the provider, so it can start while the provider is unreachable
+func NewProvider(config Config) (*Provider, error) {
+
+	if config.Name == "" || config.DiscoveryURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("OIDC providers need a name, discovery URL, client ID and redirect URL")
+	}
+
+	if !strings.HasSuffix(config.DiscoveryURL, discoveryPath) {
+		config.DiscoveryURL = strings.TrimSuffix(config.DiscoveryURL, "/") + discoveryPath
+	}
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Name ...
+func (provider *Provider) Name() string {
+	return provider.config.Name
+}
+
+// AuthCodeURL -> URL of the provider's login page, which redirects back with a code and the state.
+// nonce ends up in the ID token and verifier has to be given to Exchange.
+func (provider *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+
+	metadata, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.config.ClientID)
+	query.Set("redirect_uri", provider.config.RedirectURL)
+	query.Set("scope", strings.Join(provider.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange -> identity of the user who signed in, given the code the provider redirected with
+func (provider *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+
+	metadata, err := provider.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", provider.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	form.Set("client_id", provider.config.ClientID)
+
+	request, err := http.NewRequestWithContext(ctx, "POST", metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if provider.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(provider.config.ClientID), url.QueryEscape(provider.config.ClientSecret))
+	}
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// Error responses of RFC 6749 are 400 or 401, anything else is the provider failing
+	if response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized {
+		return nil, ErrExchangeFailed
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Token endpoint responded with %d", response.StatusCode)
+	}
+
+	tokens := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	err = json.NewDecoder(response.Body).Decode(&tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens.IDToken == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return provider.verify(ctx, metadata, tokens.IDToken, nonce)
+}
+
+// discover -> cached discovery document, fetched again after a failure
+func (provider *Provider) discover(ctx context.Context) (*metadata, error) {
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.metadata != nil {
+		return provider.metadata, nil
+	}
+
+	document := metadata{}
+	err := provider.getJSON(ctx, provider.config.DiscoveryURL, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	// The issuer has to be the one the document was discovered from, see OpenID Connect Discovery 4.3
+	if document.Issuer == "" || strings.TrimSuffix(document.Issuer, "/")+discoveryPath != provider.config.DiscoveryURL {
+		return nil, fmt.Errorf("Discovery document of %s has issuer %q", provider.config.Name, document.Issuer)
+	}
+
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, fmt.Errorf("Discovery document of %s is missing endpoints", provider.config.Name)
+	}
+
+	provider.metadata = &document
+	return provider.metadata, nil
+}
+
+// publicKey -> key with the given ID, the key set is fetched again when a provider rotated its keys
+func (provider *Provider) publicKey(ctx context.Context, metadata *metadata, keyID string) (crypto.PublicKey, error) {
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if key, ok := provider.keys[keyID]; ok {
+		return key, nil
+	}
+
+	if time.Since(provider.keysFetchedAt) < keysRefreshInterval {
+		return nil, ErrInvalidIDToken
+	}
+
+	set := keys.JWKSet{}
+	err := provider.getJSON(ctx, metadata.JWKSURI, &set)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.keys = map[string]crypto.PublicKey{}
+	provider.keysFetchedAt = time.Now()
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		// Keys of unsupported types are skipped rather than failing the whole set
+		key, err := jwk.PublicKey()
+		if err == nil {
+			provider.keys[jwk.KeyID] = key
+		}
+	}
+
+	key, ok := provider.keys[keyID]
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+	return key, nil
+}
+
+func (provider *Provider) getJSON(ctx context.Context, url string, value interface{}) error {
+
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with %d", url, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// NewVerifier -> PKCE code verifier, see RFC 7636
+func NewVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewNonce -> value tying an ID token to the login that requested it
+func NewNonce() (string, error) {
+	return randomString(16)
+}
+
+// CodeChallenge -> S256 challenge of the verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	raw := make([]byte, size)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,184 @@
+// +build !integration
+
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth/oidc/oidctest"
+	"github.com/dgrijalva/jwt-go"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func newTestProvider(t *testing.T, server *oidctest.Server, clientSecret string) *Provider {
+	provider, err := NewProvider(Config{
+		Name:         "test",
+		DiscoveryURL: server.URL,
+		ClientID:     server.ClientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  "http://localhost:3000/auth/oidc/test/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestProvider_Exchange(t *testing.T) {
+
+	server, err := oidctest.NewServer("trackr", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	server.User = oidctest.User{Subject: "1234", Email: "johndoe@gmail.com", EmailVerified: true, GivenName: "John"}
+	provider := newTestProvider(t, server, "secret")
+	ctx := context.Background()
+
+	verifier, _ := NewVerifier()
+	nonce, _ := NewNonce()
+	authorizationURL, err := provider.AuthCodeURL(ctx, "state", nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _ := url.Parse(authorizationURL)
+	assert.Equal(t, parsed.Query().Get("code_challenge"), CodeChallenge(verifier))
+	assert.Equal(t, parsed.Query().Get("scope"), "openid email profile")
+
+	code, state, err := server.Authorize(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, state, "state")
+
+	identity, err := provider.Exchange(ctx, code, verifier, nonce)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, identity.Provider, "test")
+	assert.Equal(t, identity.Subject, "1234")
+	assert.Equal(t, identity.Email, "johndoe@gmail.com")
+	assert.Equal(t, identity.EmailVerified, true)
+	assert.Equal(t, identity.GivenName, "John")
+
+	// Codes are single-use
+	_, err = provider.Exchange(ctx, code, verifier, nonce)
+	assert.Equal(t, err, ErrExchangeFailed)
+}
+
+func TestProvider_Exchange_Rejected(t *testing.T) {
+
+	server, err := oidctest.NewServer("trackr", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	server.User = oidctest.User{Subject: "1234"}
+	ctx := context.Background()
+
+	cases := []struct {
+		clientSecret string
+		verifier     string
+		nonce        string
+		err          error
+	}{
+		// Wrong client secret
+		{clientSecret: "wrong", err: ErrExchangeFailed},
+		// Verifier of another login
+		{clientSecret: "secret", verifier: "another", err: ErrExchangeFailed},
+		// ID token of another login
+		{clientSecret: "secret", nonce: "another", err: ErrInvalidIDToken},
+	}
+
+	for _, c := range cases {
+		provider := newTestProvider(t, server, c.clientSecret)
+		verifier, _ := NewVerifier()
+		nonce, _ := NewNonce()
+
+		authorizationURL, err := provider.AuthCodeURL(ctx, "state", nonce, verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, _, err := server.Authorize(authorizationURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.verifier != "" {
+			verifier = c.verifier
+		}
+		if c.nonce != "" {
+			nonce = c.nonce
+		}
+
+		_, err = provider.Exchange(ctx, code, verifier, nonce)
+		assert.Equal(t, err, c.err)
+	}
+}
+
+func TestProvider_Verify(t *testing.T) {
+
+	server, err := oidctest.NewServer("trackr", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	provider := newTestProvider(t, server, "secret")
+	ctx := context.Background()
+	metadata, err := provider.discover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{"iss": server.URL, "sub": "1234", "aud": "trackr", "exp": now.Add(time.Hour).Unix(), "iat": now.Unix(), "nonce": "nonce"}
+	}
+
+	cases := []struct {
+		edit func(jwt.MapClaims)
+		err  error
+	}{
+		{edit: func(claims jwt.MapClaims) {}, err: nil},
+		{edit: func(claims jwt.MapClaims) { claims["aud"] = []string{"other", "trackr"}; claims["azp"] = "trackr" }, err: nil},
+		{edit: func(claims jwt.MapClaims) { claims["aud"] = []string{"other", "trackr"} }, err: ErrInvalidIDToken},
+		{edit: func(claims jwt.MapClaims) { claims["aud"] = "other" }, err: ErrInvalidIDToken},
+		{edit: func(claims jwt.MapClaims) { claims["iss"] = "https://accounts.example.com" }, err: ErrInvalidIDToken},
+		{edit: func(claims jwt.MapClaims) { claims["exp"] = now.Add(-time.Hour).Unix() }, err: ErrInvalidIDToken},
+		{edit: func(claims jwt.MapClaims) { delete(claims, "sub") }, err: ErrInvalidIDToken},
+	}
+
+	for _, c := range cases {
+		claims := valid()
+		c.edit(claims)
+
+		idToken, err := server.SignIDToken(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = provider.verify(ctx, metadata, idToken, "nonce")
+		assert.Equal(t, err, c.err)
+	}
+
+	// Tokens signed with the client secret instead of the provider's key
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("secret"))
+	_, err = provider.verify(ctx, metadata, forged, "nonce")
+	assert.Equal(t, err, ErrInvalidIDToken)
+}
+
+func TestNewProvider_Invalid(t *testing.T) {
+
+	_, err := NewProvider(Config{Name: "test", DiscoveryURL: "https://accounts.example.com"})
+	assert.NotEqual(t, err, nil)
+
+	provider, err := NewProvider(Config{Name: "test", DiscoveryURL: "https://accounts.example.com/", ClientID: "trackr", RedirectURL: "http://localhost"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, provider.config.DiscoveryURL, "https://accounts.example.com/.well-known/openid-configuration")
+}
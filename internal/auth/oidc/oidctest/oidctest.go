@@ -0,0 +1,207 @@
+// Package oidctest provides a local OpenID provider for tests, like net/http/httptest does for servers
+package oidctest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
+)
+
+// User -> who signs in at the provider, the claims of the ID tokens it issues
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// authorization -> login waiting for its code to be exchanged
+type authorization struct {
+	user        User
+	challenge   string
+	nonce       string
+	redirectURI string
+}
+
+// Server -> OpenID provider supporting discovery, the authorization code flow with PKCE and client_secret_basic.
+// Its issuer is Server.URL.
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	User         User // Signs in on the next authorization
+
+	key            *keys.Key
+	mutex          sync.Mutex
+	authorizations map[string]authorization
+}
+
+// NewServer -> started provider for the given client, Close it when done
+func NewServer(clientID, clientSecret string) (*Server, error) {
+
+	key, err := keys.Generate(keys.RS256, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		ClientID:       clientID,
+		ClientSecret:   clientSecret,
+		key:            key,
+		authorizations: map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", server.discovery)
+	mux.HandleFunc("/authorize", server.authorize)
+	mux.HandleFunc("/token", server.token)
+	mux.HandleFunc("/jwks", server.jwks)
+	server.Server = httptest.NewServer(mux)
+
+	return server, nil
+}
+
+// Authorize -> follows an authorization URL as a browser would, returning the code and state of the redirect
+func (server *Server) Authorize(authorizationURL string) (string, string, error) {
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return "", "", errors.New("Authorization failed: " + response.Status)
+	}
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+// SignIDToken -> ID token for the claims signed with the provider's key, for testing verification directly
+func (server *Server) SignIDToken(claims jwt.MapClaims) (string, error) {
+
+	token := jwt.NewWithClaims(server.key.SigningMethod(), claims)
+	token.Header["kid"] = server.key.ID
+	return token.SignedString(server.key.Private)
+}
+
+func (server *Server) discovery(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, map[string]interface{}{
+		"issuer":                                server.URL,
+		"authorization_endpoint":                server.URL + "/authorize",
+		"token_endpoint":                        server.URL + "/token",
+		"jwks_uri":                              server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{keys.RS256},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (server *Server) authorize(writer http.ResponseWriter, request *http.Request) {
+
+	query := request.URL.Query()
+	if query.Get("client_id") != server.ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := uuid.NewV4().String()
+
+	server.mutex.Lock()
+	server.authorizations[code] = authorization{
+		user:        server.User,
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURI: query.Get("redirect_uri"),
+	}
+	server.mutex.Unlock()
+
+	redirect := url.Values{}
+	redirect.Set("code", code)
+	redirect.Set("state", query.Get("state"))
+	http.Redirect(writer, request, query.Get("redirect_uri")+"?"+redirect.Encode(), http.StatusFound)
+}
+
+func (server *Server) token(writer http.ResponseWriter, request *http.Request) {
+
+	clientID, clientSecret, _ := request.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientID != server.ClientID || clientSecret != server.ClientSecret {
+		writeJSON(writer, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	// Codes are single-use
+	server.mutex.Lock()
+	login, ok := server.authorizations[request.PostFormValue("code")]
+	delete(server.authorizations, request.PostFormValue("code"))
+	server.mutex.Unlock()
+
+	sum := sha256.Sum256([]byte(request.PostFormValue("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if !ok || request.PostFormValue("grant_type") != "authorization_code" ||
+		request.PostFormValue("redirect_uri") != login.redirectURI || challenge != login.challenge {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := server.SignIDToken(jwt.MapClaims{
+		"iss":            server.URL,
+		"sub":            login.user.Subject,
+		"aud":            server.ClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          login.nonce,
+		"email":          login.user.Email,
+		"email_verified": login.user.EmailVerified,
+		"given_name":     login.user.GivenName,
+		"family_name":    login.user.FamilyName,
+	})
+	if err != nil {
+		writeJSON(writer, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{
+		"access_token": uuid.NewV4().String(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (server *Server) jwks(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, keys.JWKSet{Keys: []keys.JWK{server.key.JWK()}})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
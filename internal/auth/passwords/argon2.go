@@ -0,0 +1,101 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idID = "argon2id"
+
+// DefaultArgon2id -> parameters recommended by RFC 9106 for memory constrained servers
+var DefaultArgon2id = Argon2id{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2id -> hashes like $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>, see the PHC string format
+type Argon2id struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2Hash -> parts of an encoded argon2id hash
+type argon2Hash struct {
+	params Argon2id
+	salt   []byte
+	key    []byte
+}
+
+// Hash ...
+func (hasher Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2idID, argon2.Version,
+		hasher.Memory, hasher.Iterations, hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify -> the parameters are read from the hash, so any argon2id hash is verified whatever the hasher's are
+func (hasher Argon2id) Verify(encoded, password string) error {
+	hash, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	params := hash.params
+	key := argon2.IDKey([]byte(password), hash.salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(hash.key)))
+	if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// Current ...
+func (hasher Argon2id) Current(encoded string) bool {
+	hash, err := decodeArgon2id(encoded)
+	return err == nil && hash.params == hasher && len(hash.salt) == argon2SaltLength && len(hash.key) == argon2KeyLength
+}
+
+func decodeArgon2id(encoded string) (*argon2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != argon2idID {
+		return nil, ErrUnknownHash
+	}
+
+	version := 0
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return nil, ErrUnknownHash
+	}
+
+	hash := argon2Hash{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.params.Memory, &hash.params.Iterations, &hash.params.Parallelism)
+	if err != nil || hash.params.Iterations == 0 || hash.params.Parallelism == 0 {
+		return nil, ErrUnknownHash
+	}
+
+	hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrUnknownHash
+	}
+
+	hash.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash.key) == 0 {
+		return nil, ErrUnknownHash
+	}
+
+	return &hash, nil
+}
@@ -0,0 +1,90 @@
+package passwords
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	bcryptID = "bcrypt"
+	// bcryptVersion -> the 2b revision, as 'b' in the PHC string format
+	bcryptVersion = 98
+)
+
+// DefaultBcrypt -> hasher used until Use is called, compatible with hashes stored before
+var DefaultBcrypt = Bcrypt{Cost: bcrypt.DefaultCost}
+
+// Bcrypt -> hashes like $bcrypt$v=98$r=10$<salt and hash>, see the PHC string format. Hashes like $2a$10$...
+// stored before are verified but never current, so they're rehashed on login. Only the first 72 bytes of a password count.
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash ...
+func (hasher Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	// $2a$10$<salt and hash>
+	parts := strings.Split(string(hash), "$")
+	return fmt.Sprintf("$%s$v=%d$r=%d$%s", bcryptID, bcryptVersion, hasher.Cost, parts[3]), nil
+}
+
+// Verify -> the cost is read from the hash, so any bcrypt hash is verified whatever Cost is
+func (hasher Bcrypt) Verify(encoded, password string) error {
+	hash, _, err := decodeBcrypt(encoded)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword(hash, []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrMismatch
+	}
+	return err
+}
+
+// Current -> legacy $2a$ hashes never are
+func (hasher Bcrypt) Current(encoded string) bool {
+	_, cost, err := decodeBcrypt(encoded)
+	return err == nil && strings.HasPrefix(encoded, "$"+bcryptID+"$") && cost == hasher.Cost
+}
+
+// decodeBcrypt -> the hash in the $2b$ form the bcrypt package reads, along with its cost
+func decodeBcrypt(encoded string) ([]byte, int, error) {
+	if isLegacyBcrypt(encoded) {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return nil, 0, ErrUnknownHash
+		}
+		return []byte(encoded), cost, nil
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != bcryptID {
+		return nil, 0, ErrUnknownHash
+	}
+
+	version := 0
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != bcryptVersion {
+		return nil, 0, ErrUnknownHash
+	}
+
+	cost := 0
+	_, err = fmt.Sscanf(parts[3], "r=%d", &cost)
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, 0, ErrUnknownHash
+	}
+
+	return []byte(fmt.Sprintf("$2b$%02d$%s", cost, parts[4])), cost, nil
+}
+
+// isLegacyBcrypt -> hashes stored before the PHC string format
+func isLegacyBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,51 @@
+// Package passwords hashes passwords into PHC strings and checks new ones against a policy
+package passwords
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrMismatch is returned when a password doesn't match its hash
+	ErrMismatch = errors.New("Password doesn't match")
+	// ErrUnknownHash is returned for hashes of no supported algorithm
+	ErrUnknownHash = errors.New("Unknown password hash")
+)
+
+// Hasher -> algorithm and parameters new passwords are hashed with
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) error
+	// Current -> whether encoded was hashed by this algorithm with the same parameters
+	Current(encoded string) bool
+}
+
+var hasher Hasher = DefaultBcrypt
+
+// Use -> sets the hasher of new passwords, called once on start. Hashes of other algorithms are still verified.
+func Use(custom Hasher) {
+	hasher = custom
+}
+
+// Hash -> PHC string of the password with the hasher in use
+func Hash(password string) (string, error) {
+	return hasher.Hash(password)
+}
+
+// Verify -> checks the password against a hash of any supported algorithm
+func Verify(encoded, password string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$"+bcryptID+"$"), isLegacyBcrypt(encoded):
+		return Bcrypt{}.Verify(encoded, password)
+	case strings.HasPrefix(encoded, "$"+argon2idID+"$"):
+		return Argon2id{}.Verify(encoded, password)
+	default:
+		return ErrUnknownHash
+	}
+}
+
+// NeedsRehash -> whether the hash should be replaced by one of the hasher in use, once the password is known
+func NeedsRehash(encoded string) bool {
+	return !hasher.Current(encoded)
+}
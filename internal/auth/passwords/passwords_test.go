@@ -0,0 +1,167 @@
+// +build !integration
+
+package passwords
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// fastArgon2id keeps the tests quick, far too weak for real use
+var fastArgon2id = Argon2id{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHashers(t *testing.T) {
+
+	hashers := []Hasher{Bcrypt{Cost: 4}, fastArgon2id}
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash("correct horse")
+		assert.Equal(t, err, nil)
+		assert.Equal(t, hasher.Current(hash), true)
+
+		// Hashes are salted
+		other, err := hasher.Hash("correct horse")
+		assert.Equal(t, err, nil)
+		assert.NotEqual(t, hash, other)
+
+		assert.Equal(t, hasher.Verify(hash, "correct horse"), nil)
+		assert.Equal(t, hasher.Verify(hash, "wrong horse"), ErrMismatch)
+		assert.Equal(t, Verify(hash, "correct horse"), nil)
+		assert.Equal(t, Verify(hash, "wrong horse"), ErrMismatch)
+	}
+}
+
+func TestArgon2id_PHCFormat(t *testing.T) {
+
+	hash, err := fastArgon2id.Hash("correct horse")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), true)
+	assert.Equal(t, len(strings.Split(hash, "$")), 6)
+
+	// Tampered parameters change the key
+	tampered := strings.Replace(hash, "t=1", "t=2", 1)
+	assert.Equal(t, Verify(tampered, "correct horse"), ErrMismatch)
+
+	assert.Equal(t, Verify("$argon2id$v=19$m=64,t=1,p=1$c2FsdA", "correct horse"), ErrUnknownHash)
+	assert.Equal(t, Verify("plain", "plain"), ErrUnknownHash)
+}
+
+func TestBcrypt_PHCFormat(t *testing.T) {
+
+	hash, err := Bcrypt{Cost: 4}.Hash("correct horse")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.HasPrefix(hash, "$bcrypt$v=98$r=4$"), true)
+	assert.Equal(t, len(strings.Split(hash, "$")), 5)
+
+	// Tampered parameters change the hash
+	tampered := strings.Replace(hash, "r=4", "r=5", 1)
+	assert.Equal(t, Verify(tampered, "correct horse"), ErrMismatch)
+
+	assert.Equal(t, Verify("$bcrypt$v=98$r=4", "correct horse"), ErrUnknownHash)
+	assert.Equal(t, Verify("$bcrypt$v=97$r=4$c2FsdA", "correct horse"), ErrUnknownHash)
+}
+
+func TestBcrypt_Legacy(t *testing.T) {
+
+	defer Use(DefaultBcrypt)
+	Use(Bcrypt{Cost: 4})
+
+	// Hashes stored before the PHC string format verify, and are rehashed on login
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), 4)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, Verify(string(legacy), "correct horse"), nil)
+	assert.Equal(t, Verify(string(legacy), "wrong horse"), ErrMismatch)
+	assert.Equal(t, NeedsRehash(string(legacy)), true)
+}
+
+func TestNeedsRehash(t *testing.T) {
+
+	defer Use(DefaultBcrypt)
+
+	Use(Bcrypt{Cost: 4})
+	bcryptHash, err := Hash("correct horse")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, NeedsRehash(bcryptHash), false)
+
+	Use(Bcrypt{Cost: 5})
+	assert.Equal(t, NeedsRehash(bcryptHash), true)
+
+	// Hashes of the previous algorithm still verify after switching
+	Use(fastArgon2id)
+	assert.Equal(t, NeedsRehash(bcryptHash), true)
+	assert.Equal(t, Verify(bcryptHash, "correct horse"), nil)
+
+	argon2Hash, err := Hash("correct horse")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, NeedsRehash(argon2Hash), false)
+
+	Use(Argon2id{Memory: 128, Iterations: 1, Parallelism: 1})
+	assert.Equal(t, NeedsRehash(argon2Hash), true)
+}
+
+func TestCheck(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "passwords")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	// SHA-1 of "password1234", in the format of Have I Been Pwned downloads
+	path := filepath.Join(directory, "breached.txt")
+	err = ioutil.WriteFile(path, []byte("# Breached passwords\nE6B6AFBD6D76BB5D2041542D7D2E3FAC5BB05593:102\n\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := LoadBreachList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer UsePolicy(DefaultPolicy)
+	UsePolicy(Policy{MinLength: 10, Breached: list})
+
+	cases := []struct {
+		password string
+		err      string
+	}{
+		{password: "short", err: "Password must be at least 10 characters"},
+		{password: "password1234", err: ErrBreached.Error()},
+		{password: "johndoe@gmail.com!", err: ErrContainsEmail.Error()},
+		{password: "my JohnDoe secret", err: ErrContainsEmail.Error()},
+		{password: "correct horse", err: ""},
+	}
+
+	for _, c := range cases {
+		err := Check(c.password, "johndoe@gmail.com")
+		if c.err == "" {
+			assert.Equal(t, err, nil)
+			continue
+		}
+		assert.Equal(t, err.Error(), c.err)
+	}
+}
+
+func TestLoadBreachList_Invalid(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "passwords")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "breached.txt")
+	err = ioutil.WriteFile(path, []byte("password1234\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadBreachList(path)
+	assert.NotEqual(t, err, nil)
+}
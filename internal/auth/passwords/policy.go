@@ -0,0 +1,144 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrBreached is returned for passwords found in the breach list
+	ErrBreached = errors.New("Password appeared in a data breach, choose another one")
+	// ErrContainsEmail is returned for passwords containing the user's email or its local part
+	ErrContainsEmail = errors.New("Password can't contain the email")
+)
+
+// Policy -> requirements of new passwords, existing ones keep working when it changes
+type Policy struct {
+	MinLength int        // In characters
+	Breached  BreachList // Checked when set
+}
+
+// DefaultPolicy -> the minimum of NIST SP 800-63B, no breach list
+var DefaultPolicy = Policy{MinLength: 8}
+
+var policy = DefaultPolicy
+
+// UsePolicy -> sets the policy of new passwords, called once on start
+func UsePolicy(custom Policy) {
+	policy = custom
+}
+
+// Check -> whether the password of the user with the email meets the policy in use
+func Check(password, email string) error {
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		return fmt.Errorf("Password must be at least %d characters", policy.MinLength)
+	}
+
+	lowered := strings.ToLower(password)
+	email = strings.ToLower(email)
+	localPart := email
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		localPart = email[:at]
+	}
+
+	// Short local parts would match too many passwords by chance
+	if email != "" && (strings.Contains(lowered, email) || len(localPart) >= 3 && strings.Contains(lowered, localPart)) {
+		return ErrContainsEmail
+	}
+
+	if policy.Breached != nil {
+		breached, err := IsBreached(policy.Breached, password)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			return ErrBreached
+		}
+	}
+
+	return nil
+}
+
+// BreachList -> SHA-1 hashes of breached passwords, looked up by their first 5 hex characters
+// like the k-anonymity range API of Have I Been Pwned, so lists can be remote without seeing passwords
+type BreachList interface {
+	// Range -> uppercase suffixes of the hashes starting with prefix
+	Range(prefix string) ([]string, error)
+}
+
+// IsBreached ...
+func IsBreached(list BreachList, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := list.Range(hash[:5])
+	if err != nil {
+		return false, err
+	}
+
+	for _, suffix := range suffixes {
+		if suffix == hash[5:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FileBreachList -> breach list loaded in memory from a file
+type FileBreachList struct {
+	ranges map[string][]string
+}
+
+// LoadBreachList -> reads a file of SHA-1 hashes, one per line and optionally followed by :count
+// like the downloads of Have I Been Pwned. Blank lines and lines starting with # are skipped.
+func LoadBreachList(path string) (*FileBreachList, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := &FileBreachList{ranges: map[string][]string{}}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash := strings.TrimSpace(scanner.Text())
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+
+		if colon := strings.Index(hash, ":"); colon >= 0 {
+			hash = hash[:colon]
+		}
+
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d isn't a SHA-1 hash", path, line)
+		}
+
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("%s:%d isn't a SHA-1 hash", path, line)
+		}
+
+		list.ranges[hash[:5]] = append(list.ranges[hash[:5]], hash[5:])
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Range ...
+func (list *FileBreachList) Range(prefix string) ([]string, error) {
+	return list.ranges[strings.ToUpper(prefix)], nil
+}
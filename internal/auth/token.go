@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrInvalidToken -> the token is malformed or wasn't signed by this server
+var ErrInvalidToken = errors.New("Invalid token")
+
+// NewSignedToken -> random token signed for the given purpose, along with the hash to store.
+// Only the hash is persisted, the token itself is sent to the user.
+func NewSignedToken(purpose string) (string, string, error) {
+	if len(serverSecret) == 0 {
+		return "", "", ErrNoSecret
+	}
+
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	token := encoded + "." + base64.RawURLEncoding.EncodeToString(tokenSignature(purpose, encoded))
+	return token, HashToken(encoded), nil
+}
+
+// VerifySignedToken -> checks the token was signed for purpose and returns the hash to look up
+func VerifySignedToken(purpose, token string) (string, error) {
+	if len(serverSecret) == 0 {
+		return "", ErrNoSecret
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, tokenSignature(purpose, parts[0])) {
+		return "", ErrInvalidToken
+	}
+
+	return HashToken(parts[0]), nil
+}
+
+// HashToken -> hex SHA-256 of the token, tokens are random so no salt is needed
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func tokenSignature(purpose, token string) []byte {
+	mac := hmac.New(sha256.New, serverSecret)
+	mac.Write([]byte(purpose + ":" + token))
+	return mac.Sum(nil)
+}
+
+// APIKeyPrefix -> start of every API key, helps secret scanners spot leaked keys
+const APIKeyPrefix = "trk_"
+
+// NewAPIKey -> API key of the form trk_<id>_<secret>, along with the trk_<id> prefix and the hash of the secret to store
+func NewAPIKey() (string, string, string, error) {
+	id := make([]byte, 6)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix := APIKeyPrefix + hex.EncodeToString(id)
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return prefix + "_" + encoded, prefix, HashToken(encoded), nil
+}
+
+// ParseAPIKey -> prefix to look the key up with and the hash to compare
+func ParseAPIKey(key string) (string, string, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", "", ErrTokenMalformed
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(key, APIKeyPrefix), "_", 2)
+	if len(parts) != 2 || len(parts[0]) != 12 || parts[1] == "" {
+		return "", "", ErrTokenMalformed
+	}
+
+	return APIKeyPrefix + parts[0], HashToken(parts[1]), nil
+}
+
+// ExtractAPIKey -> key of an Authorization: ApiKey <key> header
+func ExtractAPIKey(request *http.Request) string {
+	parts := strings.Fields(request.Header.Get("Authorization"))
+	if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+		return parts[1]
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters, the defaults of RFC 6238 since most authenticator apps ignore anything else
+const (
+	TOTPIssuer = "Trackr"
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	totpSkew   = 1 // Codes of the periods right before and after are accepted too
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret -> base32 secret to show the user along with its sealed form to store, see ValidateTOTP
+func NewTOTPSecret() (string, string, error) {
+	key := make([]byte, 20)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret := totpEncoding.EncodeToString(key)
+	sealed, err := sealSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return secret, sealed, nil
+}
+
+// TOTPURI -> otpauth:// URI of the secret for account, usually shown as a QR code
+func TOTPURI(account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod.Seconds())))
+
+	label := url.PathEscape(TOTPIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode -> code of the secret for the period at t
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	return totpCode(key, t.Unix()/int64(TOTPPeriod.Seconds())), nil
+}
+
+// ValidateTOTP -> checks code against the sealed secret at now and returns the period it matched.
+// Callers have to reject periods at or before the last one used so a code can't be replayed.
+func ValidateTOTP(sealed, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	secret, err := openSecret(sealed)
+	if err != nil {
+		return 0, false
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := now.Unix() / int64(TOTPPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// totpCode -> HOTP of RFC 4226 for the given counter
+func totpCode(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%modulo)
+}
+
+// NewRecoveryCode -> single-use code like abcde-fghij accepted instead of a TOTP code, store HashRecoveryCode of it
+func NewRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(totpEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// HashRecoveryCode -> hash of the code ignoring case, spaces and dashes users may type differently
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	return HashToken(normalized)
+}
+
+// sealSecret -> encrypts secrets that have to be read back, unlike tokens which are only hashed.
+// The key is derived from the server secret, see UseSecret.
+func sealSecret(secret string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func openSecret(sealed string) (string, error) {
+	aead, err := secretCipher()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", ErrInvalidToken
+	}
+
+	secret, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	return string(secret), nil
+}
+
+func secretCipher() (cipher.AEAD, error) {
+	if len(serverSecret) == 0 {
+		return nil, ErrNoSecret
+	}
+
+	mac := hmac.New(sha256.New, serverSecret)
+	mac.Write([]byte("sealed-secret"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
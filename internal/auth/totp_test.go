@@ -0,0 +1,79 @@
+// +build !integration
+
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestTOTPCode(t *testing.T) {
+
+	// Test vectors of RFC 6238 for SHA1, truncated to 6 digits
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, c := range cases {
+		code, err := TOTPCode(secret, time.Unix(c.unix, 0))
+		assert.Equal(t, err, nil)
+		assert.Equal(t, code, c.code)
+	}
+}
+
+func TestUseSecret(t *testing.T) {
+
+	assert.Equal(t, UseSecret(""), ErrSecretTooShort)
+	assert.Equal(t, UseSecret("test_secret"), ErrSecretTooShort)
+	assert.Equal(t, UseSecret("test_secret_at_least_32_characters"), nil)
+}
+
+func TestValidateTOTP(t *testing.T) {
+
+	UseSecret("test_secret_at_least_32_characters")
+
+	secret, sealed, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := TOTPCode(secret, now)
+	step, ok := ValidateTOTP(sealed, code, now)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, step, now.Unix()/30)
+
+	// Clocks a period apart are tolerated
+	_, ok = ValidateTOTP(sealed, code, now.Add(TOTPPeriod))
+	assert.Equal(t, ok, true)
+
+	_, ok = ValidateTOTP(sealed, code, now.Add(3*TOTPPeriod))
+	assert.Equal(t, ok, false)
+
+	// The secret can only be opened with the same server secret
+	UseSecret("other_secret_at_least_32_characters")
+	_, ok = ValidateTOTP(sealed, code, now)
+	assert.Equal(t, ok, false)
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+
+	code, err := NewRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(code), 11)
+	assert.Equal(t, HashRecoveryCode(strings.ToUpper(code)), HashRecoveryCode(code))
+	assert.Equal(t, HashRecoveryCode(strings.Replace(code, "-", " ", 1)), HashRecoveryCode(code))
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DisableUser -> handles POST /api/v1/admin/users/{id}/disable, the user is signed out everywhere
+func (handler *Handler) DisableUser(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	actorID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Admins can't lock themselves out
+	if userID == actorID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	user, err := pgRepo.DisableUser(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditUserDisabled, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully disabled the user.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user)})
+}
+
+// EnableUser -> handles POST /api/v1/admin/users/{id}/enable
+func (handler *Handler) EnableUser(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	user, err := pgRepo.EnableUser(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditUserEnabled, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully enabled the user.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user)})
+}
+
+// GetDeletedUsers -> handles GET /api/v1/admin/trash, users whose account was deleted and can still be restored
+func (handler *Handler) GetDeletedUsers(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	items, err := pgRepo.DeletedUsers()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses := make([]trashItemResponse, 0, len(*items))
+	for _, item := range *items {
+		responses = append(responses, trashItemResponse{TrashItem: item, PurgeAt: item.DeletedAt.Add(handler.trashRetention)})
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the deleted users")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"trash": responses})
+}
+
+// RestoreUser -> handles POST /api/v1/admin/users/{id}/restore, for users in the trash
+func (handler *Handler) RestoreUser(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	user, err := pgRepo.RestoreUser(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditUserRestored, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully restored the user.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user)})
+}
+
+// ImpersonateUser -> handles POST /api/v1/admin/users/{id}/impersonate.
+// The access token names the impersonating user in its act claim, can't be refreshed and its issuance is audited.
+func (handler *Handler) ImpersonateUser(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	actorID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	impersonation := struct {
+		Reason string `json:"reason"`
+	}{}
+	err = json.Unmarshal(body, &impersonation)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if strings.TrimSpace(impersonation.Reason) == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Reason"))
+		return
+	}
+
+	user, err := pgRepo.FindUser(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	// Staff accounts can't be impersonated, which would let support act as an admin
+	if user.Role != model.RoleUser || user.Disabled() {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	accessToken, tokenID, err := auth.CreateImpersonationToken(user.ID, string(user.Role), uuid.FromStringOrNil(actorID))
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The token is only handed out once its issuance is recorded
+	err = handler.audit(request, model.AuditUserImpersonated, user.ID, tokenID, impersonation.Reason)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully issued an impersonation token.")
+	response.JSON(writer, http.StatusOK, &tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+// audit -> records the action of the authenticated user on subjectID. Audited routes are staff or
+// session only, so impersonation tokens never get here.
+func (handler *Handler) audit(request *http.Request, action model.AuditAction, subjectID uuid.UUID, tokenID, reason string) error {
+
+	event := model.AuditEvent{
+		Action:  action,
+		TokenID: tokenID,
+		Reason:  reason,
+		IP:      requestIP(request),
+	}
+
+	// Failed logins may be about no user at all
+	if subjectID != uuid.Nil {
+		event.SubjectID = &subjectID
+	}
+
+	userID, ok := auth.UserIDFromContext(request.Context())
+	if ok {
+		actorID := uuid.FromStringOrNil(userID)
+		event.ActorID = &actorID
+	}
+
+	_, err := handler.pgRepo.CreateAuditEvent(event)
+	return err
+}
+
+// requestIP -> address of the client, without the port
+func requestIP(request *http.Request) string {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,347 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/middleware"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestDisableUser_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Role: model.RoleUser},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/disable", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/disable' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	disableUserHandler := http.HandlerFunc(handler.DisableUser)
+	disableUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.NotEqual(t, responseMap["user"].(map[string]interface{})["disabled_at"], nil)
+}
+
+func TestDisableUser_403_Self(t *testing.T) {
+
+	adminID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: adminID}, Role: model.RoleAdmin},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+adminID.String()+"/disable", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/disable' request")
+	}
+	req = withURLParam(authorize(req, adminID), "id", adminID.String())
+
+	rr := httptest.NewRecorder()
+	disableUserHandler := http.HandlerFunc(handler.DisableUser)
+	disableUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 403)
+}
+
+func TestEnableUser_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Role: model.RoleUser},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/enable", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/enable' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	enableUserHandler := http.HandlerFunc(handler.EnableUser)
+	enableUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["user"].(map[string]interface{})["disabled_at"], nil)
+}
+
+func TestEnableUser_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      true,
+		ErrorMessage: "Internal server error",
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/random/enable", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/enable' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", "random")
+
+	rr := httptest.NewRecorder()
+	enableUserHandler := http.HandlerFunc(handler.EnableUser)
+	enableUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 500)
+}
+
+func TestRestoreUser_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	repo := &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID, DeletedAt: &deletedAt}, Email: "john@gmail.com", Role: model.RoleUser, DeletionDueAt: &deletedAt},
+		IsError:      false,
+	}
+	handler.pgRepo = repo
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/restore' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	restoreUserHandler := http.HandlerFunc(handler.RestoreUser)
+	restoreUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["user"].(map[string]interface{})["email"], "john@gmail.com")
+	assert.Equal(t, len(repo.AuditEvents), 1)
+	assert.Equal(t, repo.AuditEvents[0].Action, model.AuditUserRestored)
+	assert.Equal(t, *repo.AuditEvents[0].SubjectID, userID)
+}
+
+func TestGetDeletedUsers_200(t *testing.T) {
+
+	deletedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.TrashItem{
+			{Kind: model.TrashUser, ID: uuid.NewV4(), Title: "john@gmail.com", DeletedAt: deletedAt},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/admin/trash", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/admin/trash' request")
+	}
+	req = authorize(req, uuid.NewV4())
+
+	rr := httptest.NewRecorder()
+	getDeletedUsersHandler := http.HandlerFunc(handler.GetDeletedUsers)
+	getDeletedUsersHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string][]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["trash"]), 1)
+	assert.Equal(t, responseMap["trash"][0]["kind"], "user")
+	assert.Equal(t, responseMap["trash"][0]["title"], "john@gmail.com")
+	assert.Equal(t, responseMap["trash"][0]["purge_at"], deletedAt.Add(model.DefaultTrashRetention).Format(time.RFC3339))
+}
+
+func TestImpersonateUser_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	actorID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Role: model.RoleUser},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/impersonate", bytes.NewBufferString(`{"reason": "Ticket #42"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/impersonate' request")
+	}
+	req = withURLParam(authorize(req, actorID), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	impersonateUserHandler := http.HandlerFunc(handler.ImpersonateUser)
+	impersonateUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["refresh_token"], nil)
+
+	claims, err := auth.ParseToken(responseMap["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, claims.UserID, userID.String())
+	assert.Equal(t, claims.ActorID(), actorID.String())
+}
+
+func TestImpersonateUser_403_Staff(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Role: model.RoleAdmin},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/impersonate", bytes.NewBufferString(`{"reason": "Ticket #42"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/impersonate' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", userID.String())
+
+	rr := httptest.NewRecorder()
+	impersonateUserHandler := http.HandlerFunc(handler.ImpersonateUser)
+	impersonateUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 403)
+}
+
+func TestImpersonateUser_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Role: model.RoleUser},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/random/impersonate", bytes.NewBufferString(`{"reason": " "}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/impersonate' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", "random")
+
+	rr := httptest.NewRecorder()
+	impersonateUserHandler := http.HandlerFunc(handler.ImpersonateUser)
+	impersonateUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Required Reason")
+}
+
+func TestImpersonateUser_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Role: model.RoleUser},
+		IsError:      true,
+		ErrorMessage: "Internal server error",
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/admin/users/random/impersonate", bytes.NewBufferString(`{"reason": "Ticket #42"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/impersonate' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", "random")
+
+	rr := httptest.NewRecorder()
+	impersonateUserHandler := http.HandlerFunc(handler.ImpersonateUser)
+	impersonateUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 500)
+}
+
+func TestAudit_DisableUser(t *testing.T) {
+
+	userID := uuid.NewV4()
+	adminID := uuid.NewV4()
+	router := chi.NewRouter()
+	router.With(middleware.RequireRole(model.RoleAdmin)).Post("/api/v1/admin/users/{id}/disable", handler.DisableUser)
+
+	for _, test := range []struct {
+		name   string
+		ctx    func(context.Context) context.Context
+		code   int
+		events int
+	}{
+		{
+			name: "admin",
+			ctx: func(ctx context.Context) context.Context {
+				return auth.WithRole(auth.WithUserID(ctx, adminID.String()), string(model.RoleAdmin))
+			},
+			code:   200,
+			events: 1,
+		},
+		{
+			// Impersonation tokens carry the impersonated user's role
+			name: "impersonating",
+			ctx: func(ctx context.Context) context.Context {
+				return auth.WithActorID(auth.WithRole(auth.WithUserID(ctx, uuid.NewV4().String()), string(model.RoleUser)), adminID.String())
+			},
+			code:   403,
+			events: 0,
+		},
+	} {
+		repo := &mock.Repository{
+			ReturnObject: &model.User{Base: model.Base{ID: userID}, Role: model.RoleUser},
+			IsError:      false,
+		}
+		handler.pgRepo = repo
+
+		req, err := http.NewRequest("POST", "/api/v1/admin/users/"+userID.String()+"/disable", nil)
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/admin/users/{id}/disable' request")
+		}
+		req = req.WithContext(test.ctx(req.Context()))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, test.code)
+		assert.Equal(t, len(repo.AuditEvents), test.events)
+		if test.events > 0 {
+			// The subject is the disabled user, not whoever disabled them
+			assert.Equal(t, *repo.AuditEvents[0].ActorID, adminID)
+			assert.Equal(t, *repo.AuditEvents[0].SubjectID, userID)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateAPIKey -> handles POST /api/v1/api-keys, the key is only ever returned in this response
+func (handler *Handler) CreateAPIKey(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	apiKey := model.APIKey{}
+	err = json.Unmarshal(body, &apiKey)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = apiKey.Validate("create")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	apiKey.ID = uuid.Nil
+	apiKey.Prefix = prefix
+	apiKey.Hash = hash
+	apiKey.UserID = uuid.FromStringOrNil(userID)
+
+	apiKeyCreated, err := pgRepo.CreateAPIKey(apiKey)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully created API key.")
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"api_key": apiKeyCreated, "key": key})
+}
+
+// GetAllAPIKeys -> handles GET /api/v1/api-keys
+func (handler *Handler) GetAllAPIKeys(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	apiKeys, err := pgRepo.AllAPIKeys(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all API keys")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"api_keys": apiKeys})
+}
+
+// RevokeAPIKey -> handles DELETE /api/v1/api-keys/{id}
+func (handler *Handler) RevokeAPIKey(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	apiKeyID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = pgRepo.RevokeAPIKey(apiKeyID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully revoked the API key")
+	writer.Header().Set("Entity", apiKeyID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
@@ -0,0 +1,175 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateAPIKey_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		IsError: false,
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Import script", "scopes": "read write"})
+	req, err := http.NewRequest("POST", "/api/v1/api-keys", bytes.NewBuffer(body))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/api-keys' request")
+	}
+	req = authorize(req, userID)
+
+	rr := httptest.NewRecorder()
+	createAPIKeyHandler := http.HandlerFunc(handler.CreateAPIKey)
+	createAPIKeyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	apiKey := responseMap["api_key"].(map[string]interface{})
+	key := responseMap["key"].(string)
+	prefix, _, err := auth.ParseAPIKey(key)
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, apiKey["prefix"], prefix)
+	assert.Equal(t, apiKey["user_id"], userID.String())
+	assert.Equal(t, apiKey["hash"], nil)
+	assert.Equal(t, strings.HasPrefix(key, auth.APIKeyPrefix), true)
+}
+
+func TestCreateAPIKey_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		IsError: false,
+	}
+
+	cases := []struct {
+		body    map[string]interface{}
+		message string
+	}{
+		{body: map[string]interface{}{"scopes": "read"}, message: "Required Name"},
+		{body: map[string]interface{}{"name": "Import script"}, message: "Required Scopes"},
+		{body: map[string]interface{}{"name": "Import script", "scopes": "admin"}, message: "Invalid Scopes"},
+		{body: map[string]interface{}{"name": "Import script", "scopes": "read", "expires_at": "2000-01-01T00:00:00Z"}, message: "Invalid Expires At"},
+	}
+
+	for _, c := range cases {
+		body, _ := json.Marshal(c.body)
+		req, err := http.NewRequest("POST", "/api/v1/api-keys", bytes.NewBuffer(body))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/api-keys' request")
+		}
+		req = authorize(req, uuid.NewV4())
+
+		rr := httptest.NewRecorder()
+		createAPIKeyHandler := http.HandlerFunc(handler.CreateAPIKey)
+		createAPIKeyHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.message)
+	}
+}
+
+func TestGetAllAPIKeys_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObjects: map[string]interface{}{
+			"AllAPIKeys": &model.APIKey{Name: "Import script", Prefix: "trk_0123456789ab", Hash: "hash", Scopes: "read", UserID: userID},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/api-keys", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/api-keys' request")
+	}
+	req = authorize(req, userID)
+
+	rr := httptest.NewRecorder()
+	getAllAPIKeysHandler := http.HandlerFunc(handler.GetAllAPIKeys)
+	getAllAPIKeysHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	apiKeys := responseMap["api_keys"].([]interface{})
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(apiKeys), 1)
+	assert.Equal(t, apiKeys[0].(map[string]interface{})["hash"], nil)
+}
+
+func TestRevokeAPIKey_204(t *testing.T) {
+
+	userID := uuid.NewV4()
+	apiKeyID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObjects: map[string]interface{}{
+			"RevokeAPIKey": &model.APIKey{Base: model.Base{ID: apiKeyID}, UserID: userID},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/api-keys/"+apiKeyID.String(), nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/api-keys/{id}' request")
+	}
+	req = withURLParam(authorize(req, userID), "id", apiKeyID.String())
+
+	rr := httptest.NewRecorder()
+	revokeAPIKeyHandler := http.HandlerFunc(handler.RevokeAPIKey)
+	revokeAPIKeyHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, rr.Header().Get("Entity"), apiKeyID.String())
+}
+
+func TestRevokeAPIKey_404(t *testing.T) {
+
+	apiKeyID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObjects: map[string]interface{}{
+			"RevokeAPIKey": &model.APIKey{Base: model.Base{ID: apiKeyID}, UserID: uuid.NewV4()},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/api-keys/"+apiKeyID.String(), nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/api-keys/{id}' request")
+	}
+	req = withURLParam(authorize(req, uuid.NewV4()), "id", apiKeyID.String())
+
+	rr := httptest.NewRecorder()
+	revokeAPIKeyHandler := http.HandlerFunc(handler.RevokeAPIKey)
+	revokeAPIKeyHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
@@ -2,15 +2,93 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/amaraliou/trackr-core/internal/response"
 	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
 )
 
+// applicationRequest -> fields users give about their applications, ownership and status history are the server's
+type applicationRequest struct {
+	JobTitle    string        `json:"job_title"`
+	Company     string        `json:"company"`
+	CompanyID   *uuid.UUID    `json:"company_id"`
+	Description string        `json:"description"`
+	JobPosting  string        `json:"job_url"`
+	Location    string        `json:"location"`
+	Status      *model.Status `json:"status"` // nil when not given, wishlist can be given explicitly
+	Type        string        `json:"type"`
+}
+
+// application -> model of the request, owned by userID. The status is left to the handler,
+// it's recorded along with who changed it.
+func (body *applicationRequest) application(userID string) model.Application {
+	return model.Application{
+		JobTitle:    body.JobTitle,
+		Company:     body.Company,
+		CompanyID:   body.CompanyID,
+		Description: body.Description,
+		JobPosting:  body.JobPosting,
+		Location:    body.Location,
+		Type:        body.Type,
+		UserID:      uuid.FromStringOrNil(userID),
+	}
+}
+
+// applicationResponse -> application as the API returns it, without its owner's record or deletion
+type applicationResponse struct {
+	ID              uuid.UUID    `json:"ID"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"update_at"`
+	JobTitle        string       `json:"job_title"`
+	Company         string       `json:"company"`
+	CompanyID       *uuid.UUID   `json:"company_id"`
+	Description     string       `json:"description"`
+	JobPosting      string       `json:"job_url"`
+	Location        string       `json:"location"`
+	Status          model.Status `json:"status"`
+	StatusChangedAt *time.Time   `json:"status_changed_at"`
+	StatusChangedBy *uuid.UUID   `json:"status_changed_by"`
+	Type            string       `json:"type"`
+	UserID          uuid.UUID    `json:"user_id"`
+}
+
+// newApplicationResponse ...
+func newApplicationResponse(application *model.Application) *applicationResponse {
+	return &applicationResponse{
+		ID:              application.ID,
+		CreatedAt:       application.CreatedAt,
+		UpdatedAt:       application.UpdatedAt,
+		JobTitle:        application.JobTitle,
+		Company:         application.Company,
+		CompanyID:       application.CompanyID,
+		Description:     application.Description,
+		JobPosting:      application.JobPosting,
+		Location:        application.Location,
+		Status:          application.Status,
+		StatusChangedAt: application.StatusChangedAt,
+		StatusChangedBy: application.StatusChangedBy,
+		Type:            application.Type,
+		UserID:          application.UserID,
+	}
+}
+
+// newApplicationResponses ...
+func newApplicationResponses(applications []model.Application) []*applicationResponse {
+	responses := make([]*applicationResponse, 0, len(applications))
+	for i := range applications {
+		responses = append(responses, newApplicationResponse(&applications[i]))
+	}
+	return responses
+}
+
 // CreateApplication ...
 func (handler *Handler) CreateApplication(writer http.ResponseWriter, request *http.Request) {
 
@@ -22,6 +100,13 @@ func (handler *Handler) CreateApplication(writer http.ResponseWriter, request *h
 		"path":   request.URL.Path,
 	})
 
+	userID, err := requestUserID(request)
+	if err != nil {
+		log.Warnf("Couldn't find the authenticated user")
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
 	body, err := ioutil.ReadAll(request.Body)
 	if err != nil {
 		log.Warnf("Couldn't read request body")
@@ -29,14 +114,23 @@ func (handler *Handler) CreateApplication(writer http.ResponseWriter, request *h
 		return
 	}
 
-	application := model.Application{}
-	err = json.Unmarshal(body, &application)
+	applicationBody := applicationRequest{}
+	err = json.Unmarshal(body, &applicationBody)
 	if err != nil {
 		log.Warnf("Couldn't marshal JSON body")
 		response.ERROR(writer, http.StatusUnprocessableEntity, err)
 		return
 	}
 
+	// The owner always comes from the token, never from the body
+	application := applicationBody.application(userID)
+
+	status := model.StatusWishlist
+	if applicationBody.Status != nil {
+		status = *applicationBody.Status
+	}
+	application.ChangeStatus(status, application.UserID)
+
 	err = application.Validate("create")
 	if err != nil {
 		log.Warnf(err.Error())
@@ -46,11 +140,304 @@ func (handler *Handler) CreateApplication(writer http.ResponseWriter, request *h
 
 	applicationCreated, err := pgRepo.CreateApplication(application)
 	if err != nil {
-		response.ERROR(writer, http.StatusInternalServerError, err)
+		response.ERROR(writer, errorStatus(err), err)
 		return
 	}
 
 	log.Infof("Successfully created application.")
 	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, applicationCreated.ID.String()))
-	response.JSON(writer, http.StatusCreated, map[string]interface{}{"application": applicationCreated})
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"application": newApplicationResponse(applicationCreated)})
+}
+
+// GetAllApplications ...
+func (handler *Handler) GetAllApplications(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	applications, err := pgRepo.AllApplications(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all applications")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"applications": newApplicationResponses(*applications)})
+}
+
+// GetApplication ...
+func (handler *Handler) GetApplication(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	application, err := pgRepo.GetApplication(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the application")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"application": newApplicationResponse(application)})
+}
+
+// UpdateApplication -> handles PUT, the whole application must be given
+func (handler *Handler) UpdateApplication(writer http.ResponseWriter, request *http.Request) {
+	handler.updateApplication(writer, request, true)
+}
+
+// PatchApplication -> handles PATCH, only the given fields are updated
+func (handler *Handler) PatchApplication(writer http.ResponseWriter, request *http.Request) {
+	handler.updateApplication(writer, request, false)
+}
+
+func (handler *Handler) updateApplication(writer http.ResponseWriter, request *http.Request, full bool) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	applicationBody := applicationRequest{}
+	err = json.Unmarshal(body, &applicationBody)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	current, err := pgRepo.GetApplication(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	// Ownership and identity can't be changed through the body
+	application := applicationBody.application(userID)
+
+	application.Status = current.Status
+	application.PreviousStatus = current.Status
+	if applicationBody.Status != nil && *applicationBody.Status != current.Status {
+		application.ChangeStatus(*applicationBody.Status, application.UserID)
+	}
+
+	action := "update"
+	if full {
+		action = "replace"
+	}
+
+	err = application.Validate(action)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// PUT replaces the application, fields it leaves out are cleared
+	update := pgRepo.UpdateApplication
+	if full {
+		update = pgRepo.ReplaceApplication
+	}
+
+	updatedApplication, err := update(application, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully updated the application")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"application": newApplicationResponse(updatedApplication)})
+}
+
+// ChangeApplicationStatus -> handles POST /api/v1/applications/{id}/status
+func (handler *Handler) ChangeApplicationStatus(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	statusChange := struct {
+		Status *model.Status `json:"status"`
+	}{}
+	err = json.Unmarshal(body, &statusChange)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if statusChange.Status == nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Status"))
+		return
+	}
+
+	current, err := pgRepo.GetApplication(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	application := model.Application{PreviousStatus: current.Status}
+	application.ChangeStatus(*statusChange.Status, uuid.FromStringOrNil(userID))
+
+	err = application.Validate("update")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	updatedApplication, err := pgRepo.UpdateApplication(application, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully changed the application status")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"application": newApplicationResponse(updatedApplication)})
+}
+
+// DeleteApplication -> moves the application to the trash, see RestoreApplication
+func (handler *Handler) DeleteApplication(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	_, err = pgRepo.DeleteApplication(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully deleted the application")
+	writer.Header().Set("Entity", applicationID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RestoreApplication -> handles POST /api/v1/applications/{id}/restore, for applications in the trash
+func (handler *Handler) RestoreApplication(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	restoredApplication, err := pgRepo.RestoreApplication(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully restored the application")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"application": newApplicationResponse(restoredApplication)})
+}
+
+// GetApplicationTimeline -> handles GET /api/v1/applications/{id}/timeline
+func (handler *Handler) GetApplicationTimeline(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	events, err := pgRepo.GetApplicationTimeline(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	model.FillStageDurations(*events, time.Now())
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the application timeline")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"timeline": events})
 }
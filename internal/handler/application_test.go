@@ -1,3 +1,5 @@
+// +build !integration
+
 package handler
 
 import (
@@ -6,7 +8,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/amaraliou/trackr-core/internal/storage/mock"
@@ -37,6 +41,7 @@ func TestCreateApplication_201(t *testing.T) {
 		t.Error("Failed to create 'POST: /api/v1/applications' request")
 	}
 
+	req = authorize(req, uuid.NewV4())
 	rr := httptest.NewRecorder()
 	createApplicationHandler := http.HandlerFunc(handler.CreateApplication)
 	createApplicationHandler.ServeHTTP(rr, req)
@@ -70,6 +75,7 @@ func TestCreateApplication_422_JSON(t *testing.T) {
 		t.Error("Failed to create 'POST: /api/v1/applications' request")
 	}
 
+	req = authorize(req, uuid.NewV4())
 	rr := httptest.NewRecorder()
 	createApplicationHandler := http.HandlerFunc(handler.CreateApplication)
 	createApplicationHandler.ServeHTTP(rr, req)
@@ -81,7 +87,7 @@ func TestCreateApplication_422_JSON(t *testing.T) {
 	}
 
 	assert.Equal(t, rr.Code, 422)
-	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type model.Application")
+	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type handler.applicationRequest")
 }
 
 func TestCreateApplication_422_Validation(t *testing.T) {
@@ -104,8 +110,8 @@ func TestCreateApplication_422_Validation(t *testing.T) {
 			errorMessage: "Required Company",
 		},
 		{
-			inputJSON:    `{"job_title": "Software Engineer Intern", "company": "GoCardless"}`,
-			errorMessage: "Required User ID",
+			inputJSON:    `{"job_title": "Software Engineer Intern", "company": "GoCardless", "status": "accepted"}`,
+			errorMessage: "Invalid Status accepted for a new application",
 		},
 	}
 
@@ -115,6 +121,7 @@ func TestCreateApplication_422_Validation(t *testing.T) {
 			t.Error("Failed to create 'POST: /api/v1/applications' request")
 		}
 
+		req = authorize(req, uuid.NewV4())
 		rr := httptest.NewRecorder()
 		createApplicationHandler := http.HandlerFunc(handler.CreateApplication)
 		createApplicationHandler.ServeHTTP(rr, req)
@@ -154,6 +161,7 @@ func TestCreateApplication_500(t *testing.T) {
 		t.Error("Failed to create 'POST: /api/v1/applications' request")
 	}
 
+	req = authorize(req, uuid.NewV4())
 	rr := httptest.NewRecorder()
 	createApplicationHandler := http.HandlerFunc(handler.CreateApplication)
 	createApplicationHandler.ServeHTTP(rr, req)
@@ -167,3 +175,828 @@ func TestCreateApplication_500(t *testing.T) {
 	assert.Equal(t, rr.Code, 500)
 	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
 }
+
+func TestCreateApplication_401(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications", bytes.NewBufferString(`{"job_title": "Software Engineer Intern", "company": "GoCardless"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications' request")
+	}
+
+	rr := httptest.NewRecorder()
+	createApplicationHandler := http.HandlerFunc(handler.CreateApplication)
+	createApplicationHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 401)
+}
+
+func TestGetAllApplications_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	applicationsToGet := []model.Application{
+		{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			UserID:   userID,
+		},
+		{
+			JobTitle: "Software Engineer Intern",
+			Company:  "Skyscanner",
+			UserID:   userID,
+		},
+		{
+			JobTitle: "Software Engineer Intern",
+			Company:  "Monzo",
+			UserID:   uuid.NewV4(),
+		},
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &applicationsToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	getAllApplicationsHandler := http.HandlerFunc(handler.GetAllApplications)
+	getAllApplicationsHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	applications := responseMap["applications"].([]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(applications), 2)
+}
+
+func TestGetAllApplications_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.Application{},
+		IsError:      true,
+		ErrorMessage: "Table 'applications' doesn't exist",
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	rr := httptest.NewRecorder()
+	getAllApplicationsHandler := http.HandlerFunc(handler.GetAllApplications)
+	getAllApplicationsHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
+}
+
+func TestGetApplication_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	applicationToGet := model.Application{
+		JobTitle: "Software Engineer Intern",
+		Company:  "GoCardless",
+		UserID:   userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &applicationToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getApplicationHandler := http.HandlerFunc(handler.GetApplication)
+	getApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	application := responseMap["application"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, application["company"], applicationToGet.Company)
+}
+
+func TestGetApplication_404(t *testing.T) {
+
+	applicationToGet := model.Application{
+		JobTitle: "Software Engineer Intern",
+		Company:  "GoCardless",
+		UserID:   uuid.NewV4(),
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &applicationToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getApplicationHandler := http.HandlerFunc(handler.GetApplication)
+	getApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 404)
+	assert.Equal(t, responseMap["error"], "Application not found")
+}
+
+func TestGetApplication_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{},
+		IsError:      true,
+		ErrorMessage: "Table 'applications' doesn't exist",
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getApplicationHandler := http.HandlerFunc(handler.GetApplication)
+	getApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
+}
+
+func TestUpdateApplication_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	applicationUpdate := model.Application{
+		JobTitle: "Software Engineer",
+		Company:  "GoCardless",
+		UserID:   userID,
+		Location: "London, UK",
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &applicationUpdate,
+		IsError:      false,
+	}
+
+	jsonByte, err := json.Marshal(&applicationUpdate)
+	if err != nil {
+		t.Error("Failed to marshal Application struct")
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateApplicationHandler := http.HandlerFunc(handler.UpdateApplication)
+	updateApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	application := responseMap["application"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, application["location"], applicationUpdate.Location)
+}
+
+func TestUpdateApplication_200_Replaces(t *testing.T) {
+
+	userID := uuid.NewV4()
+	current := model.Application{JobTitle: "Software Engineer", Company: "GoCardless", Description: "Backend team", UserID: userID}
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &current,
+		ReturnObjects: map[string]interface{}{
+			"ReplaceApplication": &model.Application{JobTitle: "Software Engineer", Company: "GoCardless", UserID: userID},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications", bytes.NewBufferString(`{"job_title": "Software Engineer", "company": "GoCardless"}`))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateApplicationHandler := http.HandlerFunc(handler.UpdateApplication)
+	updateApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	// The description left out of the body is cleared
+	application := responseMap["application"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, application["description"], "")
+}
+
+func TestUpdateApplication_422_Validation(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications", bytes.NewBufferString(`{"location": "London, UK"}`))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateApplicationHandler := http.HandlerFunc(handler.UpdateApplication)
+	updateApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Required Job Title")
+}
+
+func TestPatchApplication_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	updatedApplication := model.Application{
+		JobTitle: "Software Engineer Intern",
+		Company:  "GoCardless",
+		UserID:   userID,
+		Location: "London, UK",
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &updatedApplication,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/applications", bytes.NewBufferString(`{"location": "London, UK"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	patchApplicationHandler := http.HandlerFunc(handler.PatchApplication)
+	patchApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	application := responseMap["application"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, application["job_title"], updatedApplication.JobTitle)
+	assert.Equal(t, application["location"], updatedApplication.Location)
+}
+
+func TestPatchApplication_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			UserID:   uuid.NewV4(),
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/applications", bytes.NewBufferString(`{"location": "London, UK"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	patchApplicationHandler := http.HandlerFunc(handler.PatchApplication)
+	patchApplicationHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestUpdateApplication_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{},
+		IsError:      true,
+		ErrorMessage: "Table 'applications' doesn't exist",
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/applications", bytes.NewBufferString(`{"location": "London, UK"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	patchApplicationHandler := http.HandlerFunc(handler.PatchApplication)
+	patchApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
+}
+
+func TestChangeApplicationStatus_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			Status:   model.StatusApplied,
+			UserID:   userID,
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/status", bytes.NewBufferString(`{"status": "interviewing"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/status' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	changeApplicationStatusHandler := http.HandlerFunc(handler.ChangeApplicationStatus)
+	changeApplicationStatusHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 200)
+}
+
+func TestChangeApplicationStatus_422(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			Status:   model.StatusAccepted,
+			UserID:   userID,
+		},
+		IsError: false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{}`,
+			errorMessage: "Required Status",
+		},
+		{
+			inputJSON:    `{"status": "hired"}`,
+			errorMessage: `Invalid Status "hired"`,
+		},
+		{
+			inputJSON:    `{"status": 3}`,
+			errorMessage: "Invalid Status 3",
+		},
+		{
+			inputJSON:    `{"status": "applied"}`,
+			errorMessage: "Invalid Status transition from accepted to applied",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/applications/status", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/applications/{id}/status' request")
+		}
+
+		req = authorize(req, userID)
+		req = withURLParam(req, "id", uuid.NewV4().String())
+		rr := httptest.NewRecorder()
+		changeApplicationStatusHandler := http.HandlerFunc(handler.ChangeApplicationStatus)
+		changeApplicationStatusHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestChangeApplicationStatus_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			Status:   model.StatusApplied,
+			UserID:   uuid.NewV4(),
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/status", bytes.NewBufferString(`{"status": "interviewing"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/status' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	changeApplicationStatusHandler := http.HandlerFunc(handler.ChangeApplicationStatus)
+	changeApplicationStatusHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestPatchApplication_422_Status(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			Status:   model.StatusRejected,
+			UserID:   userID,
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/applications", bytes.NewBufferString(`{"status": "offer"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	patchApplicationHandler := http.HandlerFunc(handler.PatchApplication)
+	patchApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Invalid Status transition from rejected to offer")
+}
+
+func TestPatchApplication_422_Wishlist(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{
+			JobTitle: "Software Engineer Intern",
+			Company:  "GoCardless",
+			Status:   model.StatusApplied,
+			UserID:   userID,
+		},
+		IsError: false,
+	}
+
+	// An explicit wishlist is a status change like any other
+	req, err := http.NewRequest("PATCH", "/api/v1/applications", bytes.NewBufferString(`{"status": "wishlist"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	patchApplicationHandler := http.HandlerFunc(handler.PatchApplication)
+	patchApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Invalid Status transition from applied to wishlist")
+}
+
+func TestDeleteApplication_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(1),
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteApplicationHandler := http.HandlerFunc(handler.DeleteApplication)
+	deleteApplicationHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+}
+
+func TestDeleteApplication_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(0),
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteApplicationHandler := http.HandlerFunc(handler.DeleteApplication)
+	deleteApplicationHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestDeleteApplication_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(0),
+		IsError:      true,
+		ErrorMessage: "Table 'applications' doesn't exist",
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/applications/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteApplicationHandler := http.HandlerFunc(handler.DeleteApplication)
+	deleteApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
+}
+
+func TestRestoreApplication_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, JobTitle: "Software Engineer", UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/restore' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreApplicationHandler := http.HandlerFunc(handler.RestoreApplication)
+	restoreApplicationHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["application"]["job_title"], "Software Engineer")
+}
+
+func TestRestoreApplication_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Application{UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/restore' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreApplicationHandler := http.HandlerFunc(handler.RestoreApplication)
+	restoreApplicationHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestGetApplicationTimeline_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	applicationID := uuid.NewV4()
+	createdAt := time.Now().Add(-72 * time.Hour)
+	eventsToGet := []model.ApplicationEvent{
+		{
+			Base:          model.Base{CreatedAt: createdAt},
+			ApplicationID: applicationID,
+			UserID:        userID,
+			Type:          model.EventCreated,
+		},
+		{
+			Base:          model.Base{CreatedAt: createdAt.Add(24 * time.Hour)},
+			ApplicationID: applicationID,
+			UserID:        userID,
+			Type:          model.EventStatusChanged,
+			FromStatus:    model.StatusWishlist,
+			ToStatus:      model.StatusApplied,
+		},
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &eventsToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications/timeline", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}/timeline' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", applicationID.String())
+	rr := httptest.NewRecorder()
+	getApplicationTimelineHandler := http.HandlerFunc(handler.GetApplicationTimeline)
+	getApplicationTimelineHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	timeline := responseMap["timeline"].([]interface{})
+	created := timeline[0].(map[string]interface{})
+	applied := timeline[1].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(timeline), 2)
+	assert.Equal(t, created["stage_duration_seconds"], float64(24*60*60))
+	assert.Equal(t, applied["to_status"], "applied")
+}
+
+func TestGetApplicationTimeline_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.ApplicationEvent{},
+		IsError:      true,
+		ErrorMessage: "Table 'application_events' doesn't exist",
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications/timeline", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}/timeline' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getApplicationTimelineHandler := http.HandlerFunc(handler.GetApplicationTimeline)
+	getApplicationTimelineHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'application_events' doesn't exist")
+}
+
+func TestApplicationResponses_NoInternalFields(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	applicationToReturn := model.Application{
+		Base:     model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt},
+		JobTitle: "Software Engineer Intern",
+		Company:  "GoCardless",
+		Status:   model.StatusApplied,
+		User:     model.User{Base: model.Base{ID: userID}, Password: string(hashedPassword)},
+		UserID:   userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &applicationToReturn,
+		ReturnObjects: map[string]interface{}{
+			"AllApplications": &[]model.Application{applicationToReturn},
+		},
+		IsError: false,
+	}
+
+	cases := []http.HandlerFunc{
+		handler.CreateApplication,
+		handler.GetAllApplications,
+		handler.GetApplication,
+		handler.PatchApplication,
+	}
+
+	for _, handlerFunc := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/applications", bytes.NewBufferString(`{"job_title": "Software Engineer Intern", "company": "GoCardless"}`))
+		if err != nil {
+			t.Error("Failed to create request")
+		}
+
+		req = authorize(req, userID)
+		req = withURLParam(req, "id", applicationToReturn.ID.String())
+		rr := httptest.NewRecorder()
+		handlerFunc.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code < 300, true)
+		assert.Equal(t, strings.Contains(rr.Body.String(), applicationToReturn.User.Password), false)
+		assert.Equal(t, strings.Contains(rr.Body.String(), `"deleted_at"`), false)
+	}
+}
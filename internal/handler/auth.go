@@ -3,18 +3,39 @@ package handler
 import (
 	"encoding/json"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/passwords"
 	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
 	"github.com/amaraliou/trackr-core/pkg/logger"
-	"golang.org/x/crypto/bcrypt"
+	uuid "github.com/satori/go.uuid"
 )
 
+// tokenResponse -> tokens returned on login and refresh
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// refreshRequest -> body of refresh and logout requests
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 // Login -> handles POST /api/v1/auth/login
 func (handler *Handler) Login(writer http.ResponseWriter, request *http.Request) {
 
+	pgRepo := handler.pgRepo
 	log := handler.logger
 	log = log.WithFields(logger.Fields{
 		"method": request.Method,
@@ -41,31 +62,418 @@ func (handler *Handler) Login(writer http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	token, err := handler.SignIn(user.Email, user.Password)
+	// Unknown emails are throttled like known ones, so lockouts don't tell which accounts exist
+	accountKey := accountThrottleKey(user.Email)
+	ipKey := "ip:" + requestIP(request)
+
+	lockedUntil, err := pgRepo.LoginLockedUntil([]string{accountKey, ipKey})
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if lockedUntil.After(time.Now()) {
+		err = handler.auditLoginFailure(request, user.Email, "Locked out")
+		if err != nil {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		log.Warnf("Refused a login during a lockout")
+		setRetryAfter(writer, lockedUntil)
+		response.ERROR(writer, http.StatusTooManyRequests, errTooManyRequests)
+		return
+	}
+
+	signedIn, err := handler.SignIn(user.Email, user.Password)
+	if err == errInvalidCredentials {
+		err = handler.recordLoginFailure(request, user.Email, accountKey, ipKey)
+		if err != nil {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		log.Warnf("Rejected a login with invalid credentials")
+		response.ERROR(writer, http.StatusUnauthorized, errInvalidCredentials)
+		return
+	}
+
+	if err == errEmailNotVerified || err == errAccountDisabled {
+		response.ERROR(writer, http.StatusForbidden, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Failures of the address aren't reset, or a valid account would let an attacker keep guessing others
+	err = pgRepo.ResetLoginFailures(accountKey)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The user still has to give a second factor, see VerifyMFA
+	if signedIn.MFAEnabled() {
+		challenge, err := handler.newMFAChallenge(signedIn)
+		if err != nil {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		log.Infof("Password accepted, waiting for the second factor.")
+		response.JSON(writer, http.StatusOK, challenge)
+		return
+	}
+
+	tokens, err := handler.newSession(request, signedIn)
 	if err != nil {
 		response.ERROR(writer, http.StatusInternalServerError, err)
 		return
 	}
 
 	log.Infof("Successfully logged in.")
-	response.JSON(writer, http.StatusOK, token)
+	response.JSON(writer, http.StatusOK, tokens)
 }
 
-// SignIn -> retrieves user JWT token given username and password
-func (handler *Handler) SignIn(email, password string) (string, error) {
+// SignIn -> retrieves the user given email and password, disabled users and unverified ones when required are refused.
+// Unknown emails, wrong passwords and users without a password all give errInvalidCredentials after as long a check.
+func (handler *Handler) SignIn(email, password string) (*model.User, error) {
 
 	pgRepo := handler.pgRepo
 
 	var err error
 	user, err := pgRepo.GetUserByEmail(email)
+	if err == storage.ErrUserNotFound {
+		model.VerifyPassword(dummyPasswordHash(), password)
+		return &model.User{}, errInvalidCredentials
+	}
+
 	if err != nil {
-		return "", err
+		return &model.User{}, err
+	}
+
+	if !user.HasPassword() {
+		model.VerifyPassword(dummyPasswordHash(), password)
+		return &model.User{}, errInvalidCredentials
 	}
 
 	err = model.VerifyPassword(user.Password, password)
-	if err != nil && err == bcrypt.ErrMismatchedHashAndPassword {
-		return "", err
+	if err != nil {
+		return &model.User{}, errInvalidCredentials
+	}
+
+	// Hashes made with older parameters are replaced while the password is at hand, failures only delay it
+	if passwords.NeedsRehash(user.Password) {
+		rehash, err := passwords.Hash(password)
+		if err == nil {
+			err = pgRepo.RehashUserPassword(user.ID.String(), user.Password, rehash)
+		}
+
+		if err != nil {
+			handler.logger.Warnf("Couldn't rehash the password: %s", err.Error())
+		}
+	}
+
+	if user.Disabled() {
+		return &model.User{}, errAccountDisabled
+	}
+
+	if handler.requireVerifiedEmail && !user.IsVerified {
+		return &model.User{}, errEmailNotVerified
+	}
+
+	return user, nil
+}
+
+// recordLoginFailure -> counts a failed login against the account and the address, and audits it
+func (handler *Handler) recordLoginFailure(request *http.Request, email, accountKey, ipKey string) error {
+
+	pgRepo := handler.pgRepo
+
+	_, err := pgRepo.RecordLoginFailure(accountKey, model.AccountLockout)
+	if err != nil {
+		return err
+	}
+
+	_, err = pgRepo.RecordLoginFailure(ipKey, model.IPLockout)
+	if err != nil {
+		return err
+	}
+
+	return handler.auditLoginFailure(request, email, "Invalid credentials")
+}
+
+// auditLoginFailure -> audit event of a failed login, about the user with the email if there's one
+func (handler *Handler) auditLoginFailure(request *http.Request, email, reason string) error {
+
+	subjectID := uuid.Nil
+	user, err := handler.pgRepo.GetUserByEmail(email)
+	if err == nil {
+		subjectID = user.ID
+	}
+
+	if err != nil && err != storage.ErrUserNotFound {
+		return err
+	}
+
+	return handler.audit(request, model.AuditLoginFailed, subjectID, "", reason)
+}
+
+// accountThrottleKey -> key failed logins of the account with the email are counted under
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// setRetryAfter -> tells clients refused until lockedUntil when to try again, in seconds
+func setRetryAfter(writer http.ResponseWriter, lockedUntil time.Time) {
+	writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds()))))
+}
+
+// dummyPasswordHash -> compared against when there's no hash to, so every failed login costs a hash comparison
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, _ := model.Hash(uuid.NewV4().String())
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// RefreshToken -> handles POST /api/v1/auth/refresh, the refresh token is rotated and can't be used again
+func (handler *Handler) RefreshToken(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	refresh := refreshRequest{}
+	err = json.Unmarshal(body, &refresh)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hash, err := auth.VerifySignedToken(string(model.TokenRefresh), refresh.RefreshToken)
+	if err != nil {
+		log.Warnf("Rejected refresh token with an invalid signature")
+		response.ERROR(writer, http.StatusUnauthorized, storage.ErrTokenInvalid)
+		return
+	}
+
+	refreshToken, nextHash, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	next, err := pgRepo.RotateRefreshToken(hash, model.RefreshToken{
+		Hash:      nextHash,
+		ExpiresAt: time.Now().Add(model.RefreshTokenTTL),
+	})
+	if err == storage.ErrTokenReused {
+		log.Warnf("Refresh token reused, its sessions were revoked")
+		response.ERROR(writer, http.StatusUnauthorized, storage.ErrTokenInvalid)
+		return
+	}
+
+	if err == storage.ErrTokenInvalid {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := pgRepo.GetUser(next.UserID.String(), next.UserID.String())
+	if err == storage.ErrUserNotFound {
+		response.ERROR(writer, http.StatusUnauthorized, storage.ErrTokenInvalid)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user.Disabled() {
+		response.ERROR(writer, http.StatusForbidden, errAccountDisabled)
+		return
+	}
+
+	tokens, err := newTokenResponse(user, refreshToken)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully refreshed tokens.")
+	response.JSON(writer, http.StatusOK, tokens)
+}
+
+// Logout -> handles POST /api/v1/auth/logout, revokes the access token and the refresh token given in the body if any
+func (handler *Handler) Logout(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	refresh := refreshRequest{}
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &refresh)
+		if err != nil {
+			response.ERROR(writer, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+
+	err = handler.denyRequestToken(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if refresh.RefreshToken != "" {
+		hash, err := auth.VerifySignedToken(string(model.TokenRefresh), refresh.RefreshToken)
+		if err == nil {
+			err = pgRepo.RevokeRefreshToken(hash, userID)
+		}
+
+		if err != nil && err != auth.ErrInvalidToken {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	log.Infof("Successfully logged out.")
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// LogoutAll -> handles POST /api/v1/auth/logout-all, revokes every token of the user
+func (handler *Handler) LogoutAll(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = pgRepo.RevokeUserSessions(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	// Revocation times have a precision of a second, so the current token is denied explicitly
+	err = handler.denyRequestToken(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully logged out of all devices.")
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// denyRequestToken -> denies the access token of the request until it expires
+func (handler *Handler) denyRequestToken(request *http.Request) error {
+
+	claims, err := auth.ParseRequest(request)
+	if err != nil {
+		return err
+	}
+
+	return handler.pgRepo.DenyToken(claims.Id, claims.ExpiresAtTime())
+}
+
+// newSession -> tokens of a new login of the user, starting a refresh token family.
+// Users who asked for their account to be deleted keep it by signing in.
+func (handler *Handler) newSession(request *http.Request, user *model.User) (*tokenResponse, error) {
+
+	if user.DeletionScheduled() {
+		_, err := handler.pgRepo.CancelUserDeletion(user.ID.String())
+		if err != nil {
+			return &tokenResponse{}, err
+		}
+
+		err = handler.audit(request, model.AuditDeletionCanceled, user.ID, "", "Signed in")
+		if err != nil {
+			return &tokenResponse{}, err
+		}
+	}
+
+	refreshToken, hash, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		return &tokenResponse{}, err
+	}
+
+	_, err = handler.pgRepo.CreateRefreshToken(model.RefreshToken{
+		Hash:      hash,
+		FamilyID:  uuid.NewV4(),
+		ExpiresAt: time.Now().Add(model.RefreshTokenTTL),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return &tokenResponse{}, err
+	}
+
+	return newTokenResponse(user, refreshToken)
+}
+
+// newTokenResponse -> new access token for the user along with the given refresh token
+func newTokenResponse(user *model.User, refreshToken string) (*tokenResponse, error) {
+
+	accessToken, err := auth.CreateToken(user.ID, string(user.Role))
+	if err != nil {
+		return &tokenResponse{}, err
 	}
 
-	return auth.CreateToken(user.ID)
+	return &tokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
 }
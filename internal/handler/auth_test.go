@@ -9,9 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/amaraliou/trackr-core/internal/auth"
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
 	"gopkg.in/go-playground/assert.v1"
 )
 
@@ -22,8 +26,13 @@ func TestLogin_200(t *testing.T) {
 		Password: "random",
 	}
 
+	hashedPassword, err := model.Hash(userLogin.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	handler.pgRepo = &mock.Repository{
-		ReturnObject: &userLogin,
+		ReturnObject: &model.User{Email: userLogin.Email, Password: string(hashedPassword)},
 		IsError:      false,
 	}
 
@@ -41,7 +50,43 @@ func TestLogin_200(t *testing.T) {
 	loginHandler := http.HandlerFunc(handler.Login)
 	loginHandler.ServeHTTP(rr, req)
 
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
 	assert.Equal(t, rr.Code, 200)
+	assert.NotEqual(t, responseMap["access_token"], "")
+	assert.NotEqual(t, responseMap["refresh_token"], "")
+	assert.Equal(t, responseMap["token_type"], "Bearer")
+}
+
+func TestLogin_200_CancelsDeletion(t *testing.T) {
+
+	hashedPassword, err := model.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dueAt := time.Now().Add(time.Hour)
+	user := &model.User{Base: model.Base{ID: uuid.NewV4()}, Email: "random@gmail.com", Password: string(hashedPassword), DeletionDueAt: &dueAt}
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(`{"email": "random@gmail.com", "password": "correct horse"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+	}
+
+	rr := httptest.NewRecorder()
+	loginHandler := http.HandlerFunc(handler.Login)
+	loginHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, user.DeletionScheduled(), false)
 }
 
 func TestLogin_422_JSON(t *testing.T) {
@@ -121,6 +166,62 @@ func TestLogin_422_Validation(t *testing.T) {
 	}
 }
 
+func TestLogin_401_InvalidCredentials(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Email: "random@gmail.com", Password: string(hashedPassword)},
+		IsError:      false,
+	}
+
+	// Unknown emails and wrong passwords can't be told apart
+	cases := []string{
+		`{"email": "unknown@gmail.com", "password": "random"}`,
+		`{"email": "random@gmail.com", "password": "wrong"}`,
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(c))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+		}
+
+		rr := httptest.NewRecorder()
+		loginHandler := http.HandlerFunc(handler.Login)
+		loginHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 401)
+		assert.Equal(t, rr.Body.String(), `{"error":"Invalid credentials"}`+"\n")
+	}
+}
+
+func TestLogin_429(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Email: "random@gmail.com"},
+		ReturnObjects: map[string]interface{}{
+			"LoginLockedUntil": time.Now().Add(90 * time.Second),
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(`{"email": "random@gmail.com", "password": "random"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+	}
+
+	rr := httptest.NewRecorder()
+	loginHandler := http.HandlerFunc(handler.Login)
+	loginHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 429)
+	assert.Equal(t, rr.Header().Get("Retry-After"), "90")
+}
+
 func TestLogin_500(t *testing.T) {
 
 	userLogin := model.User{
@@ -157,3 +258,226 @@ func TestLogin_500(t *testing.T) {
 	assert.Equal(t, rr.Code, 500)
 	assert.Equal(t, responseMap["error"], "User not found")
 }
+
+func TestRefreshToken_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject:  &model.RefreshToken{FamilyID: uuid.NewV4(), UserID: userID},
+		ReturnObjects: map[string]interface{}{"GetUser": &model.User{Base: model.Base{ID: userID}, Role: model.RoleUser}},
+		IsError:       false,
+	}
+
+	refreshToken, _, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	req, err := http.NewRequest("POST", "/api/v1/auth/refresh", bytes.NewBuffer(body))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/refresh' request")
+	}
+
+	rr := httptest.NewRecorder()
+	refreshHandler := http.HandlerFunc(handler.RefreshToken)
+	refreshHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.NotEqual(t, responseMap["refresh_token"], refreshToken)
+
+	// The new access token belongs to the user of the refresh token
+	claims, err := auth.ParseToken(responseMap["access_token"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, claims.UserID, userID.String())
+	assert.Equal(t, claims.Role, "user")
+}
+
+func TestRefreshToken_403_Disabled(t *testing.T) {
+
+	userID := uuid.NewV4()
+	disabledAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject:  &model.RefreshToken{FamilyID: uuid.NewV4(), UserID: userID},
+		ReturnObjects: map[string]interface{}{"GetUser": &model.User{Base: model.Base{ID: userID}, DisabledAt: &disabledAt}},
+		IsError:       false,
+	}
+
+	refreshToken, _, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	req, err := http.NewRequest("POST", "/api/v1/auth/refresh", bytes.NewBuffer(body))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/refresh' request")
+	}
+
+	rr := httptest.NewRecorder()
+	refreshHandler := http.HandlerFunc(handler.RefreshToken)
+	refreshHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 403)
+	assert.Equal(t, responseMap["error"], "Account disabled")
+}
+
+func TestRefreshToken_401(t *testing.T) {
+
+	refreshToken, _, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reset tokens can't be used as refresh tokens
+	resetToken, _, err := auth.NewSignedToken(string(model.TokenResetPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		refreshToken string
+		errorMessage string
+	}{
+		{
+			refreshToken: "forged.token",
+			errorMessage: "",
+		},
+		{
+			refreshToken: resetToken,
+			errorMessage: "",
+		},
+		{
+			refreshToken: refreshToken,
+			errorMessage: storage.ErrTokenInvalid.Error(),
+		},
+		{
+			refreshToken: refreshToken,
+			errorMessage: storage.ErrTokenReused.Error(),
+		},
+	}
+
+	for _, c := range cases {
+		handler.pgRepo = &mock.Repository{
+			ReturnObject: &model.RefreshToken{},
+			IsError:      c.errorMessage != "",
+			ErrorMessage: c.errorMessage,
+		}
+
+		body, _ := json.Marshal(map[string]string{"refresh_token": c.refreshToken})
+		req, err := http.NewRequest("POST", "/api/v1/auth/refresh", bytes.NewBuffer(body))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/refresh' request")
+		}
+
+		rr := httptest.NewRecorder()
+		refreshHandler := http.HandlerFunc(handler.RefreshToken)
+		refreshHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 401)
+		assert.Equal(t, responseMap["error"], "Invalid or expired token")
+	}
+}
+
+func TestLogout_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	userID := uuid.NewV4()
+	accessToken, err := auth.CreateToken(userID, string(model.RoleUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshToken, _, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{"", `{"refresh_token": "` + refreshToken + `"}`} {
+		req, err := http.NewRequest("POST", "/api/v1/auth/logout", bytes.NewBufferString(body))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/logout' request")
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req = authorize(req, userID)
+
+		rr := httptest.NewRecorder()
+		logoutHandler := http.HandlerFunc(handler.Logout)
+		logoutHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 204)
+	}
+}
+
+func TestLogoutAll_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	userID := uuid.NewV4()
+	accessToken, err := auth.CreateToken(userID, string(model.RoleUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/logout-all", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/logout-all' request")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req = authorize(req, userID)
+
+	rr := httptest.NewRecorder()
+	logoutAllHandler := http.HandlerFunc(handler.LogoutAll)
+	logoutAllHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+}
+
+func TestLogoutAll_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      true,
+		ErrorMessage: "Internal server error",
+	}
+
+	userID := uuid.NewV4()
+	req, err := http.NewRequest("POST", "/api/v1/auth/logout-all", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/logout-all' request")
+	}
+	req = authorize(req, userID)
+
+	rr := httptest.NewRecorder()
+	logoutAllHandler := http.HandlerFunc(handler.LogoutAll)
+	logoutAllHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 500)
+}
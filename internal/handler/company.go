@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateCompany ...
+func (handler *Handler) CreateCompany(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		log.Warnf("Couldn't read request body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	company := model.Company{}
+	err = json.Unmarshal(body, &company)
+	if err != nil {
+		log.Warnf("Couldn't marshal JSON body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	company.ID = uuid.Nil
+	company.UserID = uuid.FromStringOrNil(userID)
+
+	err = company.Validate("create")
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	companyCreated, err := pgRepo.CreateCompany(company)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully created company.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, companyCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"company": companyCreated})
+}
+
+// GetAllCompanies ...
+func (handler *Handler) GetAllCompanies(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	companies, err := pgRepo.AllCompanies(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all companies")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"companies": companies})
+}
+
+// GetCompany ...
+func (handler *Handler) GetCompany(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	companyID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	company, err := pgRepo.GetCompany(companyID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the company")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"company": company})
+}
+
+// UpdateCompany -> only the given fields are updated
+func (handler *Handler) UpdateCompany(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	companyID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	company := model.Company{}
+	err = json.Unmarshal(body, &company)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Ownership and identity can't be changed through the body
+	company.ID = uuid.Nil
+	company.UserID = uuid.Nil
+
+	err = company.Validate("update")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	updatedCompany, err := pgRepo.UpdateCompany(company, companyID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully updated the company")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"company": updatedCompany})
+}
+
+// DeleteCompany -> moves the company to the trash, see RestoreCompany
+func (handler *Handler) DeleteCompany(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	companyID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	_, err = pgRepo.DeleteCompany(companyID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully deleted the company")
+	writer.Header().Set("Entity", companyID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RestoreCompany -> handles POST /api/v1/companies/{id}/restore, for companies in the trash
+func (handler *Handler) RestoreCompany(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	companyID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	restoredCompany, err := pgRepo.RestoreCompany(companyID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully restored the company")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"company": restoredCompany})
+}
@@ -0,0 +1,400 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateCompany_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	companyToCreate := model.Company{
+		Name:    "GoCardless",
+		Website: "https://gocardless.com",
+		UserID:  userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &companyToCreate,
+		IsError:      false,
+	}
+
+	jsonByte, err := json.Marshal(companyToCreate)
+	if err != nil {
+		t.Error("Failed to marshal Company struct")
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/companies", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/companies' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createCompanyHandler := http.HandlerFunc(handler.CreateCompany)
+	createCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	createdCompany := responseMap["company"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, createdCompany["name"], companyToCreate.Name)
+}
+
+func TestCreateCompany_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"website": "https://gocardless.com"}`,
+			errorMessage: "Required Name",
+		},
+		{
+			inputJSON:    `{"name": "  "}`,
+			errorMessage: "Required Name",
+		},
+		{
+			inputJSON:    `{"name": "GoCardless", "website": "gocardless"}`,
+			errorMessage: "Invalid Website",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/companies", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/companies' request")
+		}
+
+		req = authorize(req, uuid.NewV4())
+		rr := httptest.NewRecorder()
+		createCompanyHandler := http.HandlerFunc(handler.CreateCompany)
+		createCompanyHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestCreateCompany_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{},
+		IsError:      true,
+		ErrorMessage: "Table 'companies' doesn't exist",
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/companies", bytes.NewBufferString(`{"name": "GoCardless"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/companies' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	rr := httptest.NewRecorder()
+	createCompanyHandler := http.HandlerFunc(handler.CreateCompany)
+	createCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'companies' doesn't exist")
+}
+
+func TestGetAllCompanies_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	companiesToGet := []model.Company{
+		{
+			Name:   "GoCardless",
+			UserID: userID,
+		},
+		{
+			Name:   "Skyscanner",
+			UserID: uuid.NewV4(),
+		},
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &companiesToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/companies", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/companies' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	getAllCompaniesHandler := http.HandlerFunc(handler.GetAllCompanies)
+	getAllCompaniesHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	companies := responseMap["companies"].([]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(companies), 1)
+}
+
+func TestGetCompany_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	companyToGet := model.Company{
+		Name:   "GoCardless",
+		UserID: userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &companyToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/companies", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getCompanyHandler := http.HandlerFunc(handler.GetCompany)
+	getCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	company := responseMap["company"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, company["name"], companyToGet.Name)
+}
+
+func TestGetCompany_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{
+			Name:   "GoCardless",
+			UserID: uuid.NewV4(),
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/companies", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getCompanyHandler := http.HandlerFunc(handler.GetCompany)
+	getCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 404)
+	assert.Equal(t, responseMap["error"], "Company not found")
+}
+
+func TestUpdateCompany_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	updatedCompany := model.Company{
+		Name:     "GoCardless",
+		Industry: "Fintech",
+		UserID:   userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &updatedCompany,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/companies", bytes.NewBufferString(`{"industry": "Fintech"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateCompanyHandler := http.HandlerFunc(handler.UpdateCompany)
+	updateCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	company := responseMap["company"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, company["industry"], updatedCompany.Industry)
+}
+
+func TestUpdateCompany_422(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/companies", bytes.NewBufferString(`{"website": "ftp://gocardless.com"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateCompanyHandler := http.HandlerFunc(handler.UpdateCompany)
+	updateCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Invalid Website")
+}
+
+func TestDeleteCompany_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(1),
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/companies", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteCompanyHandler := http.HandlerFunc(handler.DeleteCompany)
+	deleteCompanyHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+}
+
+func TestDeleteCompany_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(0),
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/companies", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/companies/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteCompanyHandler := http.HandlerFunc(handler.DeleteCompany)
+	deleteCompanyHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestRestoreCompany_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, Name: "GoCardless", UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/companies/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/companies/{id}/restore' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreCompanyHandler := http.HandlerFunc(handler.RestoreCompany)
+	restoreCompanyHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["company"]["name"], "GoCardless")
+	assert.Equal(t, responseMap["company"]["deleted_at"], nil)
+}
+
+func TestRestoreCompany_404(t *testing.T) {
+
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Company{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, Name: "GoCardless", UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/companies/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/companies/{id}/restore' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreCompanyHandler := http.HandlerFunc(handler.RestoreCompany)
+	restoreCompanyHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
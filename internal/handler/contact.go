@@ -0,0 +1,433 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateContact ...
+func (handler *Handler) CreateContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		log.Warnf("Couldn't read request body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	contact := model.Contact{}
+	err = json.Unmarshal(body, &contact)
+	if err != nil {
+		log.Warnf("Couldn't marshal JSON body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	contact.ID = uuid.Nil
+	contact.UserID = uuid.FromStringOrNil(userID)
+	contact.LastContactedAt = nil
+
+	err = contact.Validate("create")
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	contactCreated, err := pgRepo.CreateContact(contact)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully created contact.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, contactCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"contact": contactCreated})
+}
+
+// GetAllContacts ...
+func (handler *Handler) GetAllContacts(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	contacts, err := pgRepo.AllContacts(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all contacts")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contacts": contacts})
+}
+
+// GetContact ...
+func (handler *Handler) GetContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	contact, err := pgRepo.GetContact(contactID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the contact")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contact": contact})
+}
+
+// UpdateContact -> only the given fields are updated
+func (handler *Handler) UpdateContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	contact := model.Contact{}
+	err = json.Unmarshal(body, &contact)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Ownership and identity can't be changed through the body
+	contact.ID = uuid.Nil
+	contact.UserID = uuid.Nil
+	contact.LastContactedAt = nil
+
+	err = contact.Validate("update")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	updatedContact, err := pgRepo.UpdateContact(contact, contactID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully updated the contact")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contact": updatedContact})
+}
+
+// DeleteContact -> moves the contact to the trash, see RestoreContact
+func (handler *Handler) DeleteContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	_, err = pgRepo.DeleteContact(contactID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully deleted the contact")
+	writer.Header().Set("Entity", contactID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RestoreContact -> handles POST /api/v1/contacts/{id}/restore, for contacts in the trash
+func (handler *Handler) RestoreContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	restoredContact, err := pgRepo.RestoreContact(contactID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully restored the contact")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contact": restoredContact})
+}
+
+// CreateContactInteraction -> handles POST /api/v1/contacts/{id}/interactions
+func (handler *Handler) CreateContactInteraction(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		log.Warnf("Couldn't read request body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interaction := model.ContactInteraction{}
+	err = json.Unmarshal(body, &interaction)
+	if err != nil {
+		log.Warnf("Couldn't marshal JSON body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interaction.ID = uuid.Nil
+	interaction.ContactID = uuid.FromStringOrNil(contactID)
+	interaction.UserID = uuid.FromStringOrNil(userID)
+
+	err = interaction.Validate()
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interactionCreated, err := pgRepo.CreateContactInteraction(interaction)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully logged contact interaction.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, interactionCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"interaction": interactionCreated})
+}
+
+// GetContactInteractions -> handles GET /api/v1/contacts/{id}/interactions
+func (handler *Handler) GetContactInteractions(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	contactID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	interactions, err := pgRepo.AllContactInteractions(contactID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the contact interactions")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"interactions": interactions})
+}
+
+// GetApplicationContacts -> handles GET /api/v1/applications/{id}/contacts
+func (handler *Handler) GetApplicationContacts(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	contacts, err := pgRepo.ApplicationContacts(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the application contacts")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contacts": contacts})
+}
+
+// LinkContact -> handles PUT /api/v1/applications/{id}/contacts/{contactID} with a relationship
+func (handler *Handler) LinkContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	contactID := chi.URLParam(request, "contactID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	link := model.ApplicationContact{}
+	err = json.Unmarshal(body, &link)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	link.ApplicationID = uuid.FromStringOrNil(applicationID)
+	link.ContactID = uuid.FromStringOrNil(contactID)
+
+	err = link.Validate()
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	linkedContact, err := pgRepo.LinkContact(link, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully linked the contact")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"contact": linkedContact})
+}
+
+// UnlinkContact -> handles DELETE /api/v1/applications/{id}/contacts/{contactID}
+func (handler *Handler) UnlinkContact(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	contactID := chi.URLParam(request, "contactID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = pgRepo.UnlinkContact(contactID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully unlinked the contact")
+	writer.Header().Set("Entity", contactID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
@@ -0,0 +1,381 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateContact_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	contactToCreate := model.Contact{
+		Name:        "Jane Doe",
+		Email:       "jane@gocardless.com",
+		LinkedInURL: "https://www.linkedin.com/in/janedoe",
+		Role:        "Technical Recruiter",
+		UserID:      userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &contactToCreate,
+		IsError:      false,
+	}
+
+	jsonByte, err := json.Marshal(contactToCreate)
+	if err != nil {
+		t.Error("Failed to marshal Contact struct")
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/contacts", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/contacts' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createContactHandler := http.HandlerFunc(handler.CreateContact)
+	createContactHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	createdContact := responseMap["contact"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, createdContact["name"], contactToCreate.Name)
+	assert.Equal(t, createdContact["linkedin_url"], contactToCreate.LinkedInURL)
+}
+
+func TestCreateContact_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Contact{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"email": "jane@gocardless.com"}`,
+			errorMessage: "Required Name",
+		},
+		{
+			inputJSON:    `{"name": "Jane Doe", "email": "jane"}`,
+			errorMessage: "Invalid Email",
+		},
+		{
+			inputJSON:    `{"name": "Jane Doe", "linkedin_url": "linkedin.com/in/janedoe"}`,
+			errorMessage: "Invalid LinkedIn URL",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/contacts", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/contacts' request")
+		}
+
+		req = authorize(req, uuid.NewV4())
+		rr := httptest.NewRecorder()
+		createContactHandler := http.HandlerFunc(handler.CreateContact)
+		createContactHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestGetAllContacts_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.Contact{
+			{Name: "Jane Doe", UserID: userID},
+			{Name: "John Smith", UserID: userID},
+			{Name: "Someone Else", UserID: uuid.NewV4()},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/contacts", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/contacts' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	getContactsHandler := http.HandlerFunc(handler.GetAllContacts)
+	getContactsHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["contacts"].([]interface{})), 2)
+}
+
+func TestGetContact_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Contact{Name: "Jane Doe", UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/contacts", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/contacts/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getContactHandler := http.HandlerFunc(handler.GetContact)
+	getContactHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestDeleteContact_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(1),
+		IsError:      false,
+	}
+
+	contactID := uuid.NewV4().String()
+	req, err := http.NewRequest("DELETE", "/api/v1/contacts", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/contacts/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", contactID)
+	rr := httptest.NewRecorder()
+	deleteContactHandler := http.HandlerFunc(handler.DeleteContact)
+	deleteContactHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, rr.Header().Get("Entity"), contactID)
+}
+
+func TestRestoreContact_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Contact{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, Name: "Jane Recruiter", UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/contacts/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/contacts/{id}/restore' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreContactHandler := http.HandlerFunc(handler.RestoreContact)
+	restoreContactHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["contact"]["name"], "Jane Recruiter")
+	assert.Equal(t, responseMap["contact"]["deleted_at"], nil)
+}
+
+func TestRestoreContact_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Contact{UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/contacts/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/contacts/{id}/restore' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreContactHandler := http.HandlerFunc(handler.RestoreContact)
+	restoreContactHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestCreateContactInteraction_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.ContactInteraction{
+			Channel:    model.ChannelPhone,
+			OccurredAt: time.Date(2020, time.September, 1, 14, 0, 0, 0, time.UTC),
+			UserID:     userID,
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/contacts/interactions", bytes.NewBufferString(`{"channel": "phone", "occurred_at": "2020-09-01T14:00:00Z"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/contacts/{id}/interactions' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	createInteractionHandler := http.HandlerFunc(handler.CreateContactInteraction)
+	createInteractionHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	interaction := responseMap["interaction"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, interaction["channel"], "phone")
+}
+
+func TestCreateContactInteraction_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.ContactInteraction{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"channel": "pigeon"}`,
+			errorMessage: "Invalid Channel",
+		},
+		{
+			inputJSON:    `{"occurred_at": "2999-01-01T00:00:00Z"}`,
+			errorMessage: "Invalid Occurred At",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/contacts/interactions", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/contacts/{id}/interactions' request")
+		}
+
+		req = authorize(req, uuid.NewV4())
+		req = withURLParam(req, "id", uuid.NewV4().String())
+		rr := httptest.NewRecorder()
+		createInteractionHandler := http.HandlerFunc(handler.CreateContactInteraction)
+		createInteractionHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestLinkContact_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.ApplicationContact{
+			Relationship: model.RelationshipReferrer,
+			Contact:      model.Contact{Name: "Jane Doe", UserID: userID},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications/contacts", bytes.NewBufferString(`{"relationship": "referrer"}`))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}/contacts/{contactID}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "contactID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	linkContactHandler := http.HandlerFunc(handler.LinkContact)
+	linkContactHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	link := responseMap["contact"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, link["relationship"], "referrer")
+	assert.Equal(t, link["contact"].(map[string]interface{})["name"], "Jane Doe")
+}
+
+func TestLinkContact_422(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.ApplicationContact{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications/contacts", bytes.NewBufferString(`{"relationship": "friend"}`))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}/contacts/{contactID}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "contactID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	linkContactHandler := http.HandlerFunc(handler.LinkContact)
+	linkContactHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Invalid Relationship")
+}
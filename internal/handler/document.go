@@ -0,0 +1,481 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// multipartOverhead -> room for the form fields and boundaries around the file
+const multipartOverhead = 1 << 20
+
+var (
+	errNoBlobStore     = errors.New("Document storage not configured")
+	errFileTooLarge    = errors.New("File too large")
+	errUnsupportedType = errors.New("Unsupported file type")
+)
+
+// documentTypes -> accepted extensions, their content type and what http.DetectContentType reports for them
+var documentTypes = map[string]struct {
+	contentType string
+	sniffed     string
+}{
+	".pdf":  {"application/pdf", "application/pdf"},
+	".doc":  {"application/msword", "application/octet-stream"},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/zip"},
+	".odt":  {"application/vnd.oasis.opendocument.text", "application/zip"},
+	".txt":  {"text/plain; charset=utf-8", "text/plain; charset=utf-8"},
+}
+
+// detectContentType -> the extension must be accepted and match the content
+func detectContentType(fileName string, content []byte) (string, error) {
+	documentType, ok := documentTypes[strings.ToLower(filepath.Ext(fileName))]
+	if !ok || http.DetectContentType(content) != documentType.sniffed {
+		return "", errUnsupportedType
+	}
+	return documentType.contentType, nil
+}
+
+// CreateDocument -> handles multipart POST /api/v1/documents with a file and optional name and kind fields
+func (handler *Handler) CreateDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	blobStore := handler.blobStore
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if blobStore == nil {
+		response.ERROR(writer, http.StatusInternalServerError, errNoBlobStore)
+		return
+	}
+
+	request.Body = http.MaxBytesReader(writer, request.Body, handler.maxDocumentSize+multipartOverhead)
+	err = request.ParseMultipartForm(handler.maxDocumentSize)
+	if err != nil {
+		log.Warnf("Couldn't parse multipart form: %s", err.Error())
+		response.ERROR(writer, http.StatusRequestEntityTooLarge, errFileTooLarge)
+		return
+	}
+	defer request.MultipartForm.RemoveAll()
+
+	file, header, err := request.FormFile("file")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required File"))
+		return
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(file, handler.maxDocumentSize+1))
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if int64(len(content)) > handler.maxDocumentSize {
+		response.ERROR(writer, http.StatusRequestEntityTooLarge, errFileTooLarge)
+		return
+	}
+
+	fileName := model.CleanFileName(header.Filename)
+	contentType, err := detectContentType(fileName, content)
+	if err != nil {
+		log.Warnf("Rejected upload of %s", fileName)
+		response.ERROR(writer, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	sum := sha256.Sum256(content)
+	document := model.Document{
+		Name:        request.FormValue("name"),
+		Kind:        model.DocumentKind(request.FormValue("kind")),
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		UserID:      uuid.FromStringOrNil(userID),
+	}
+
+	if document.Name == "" {
+		document.Name = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+
+	if document.Kind == "" {
+		document.Kind = model.DocumentOther
+	}
+
+	err = document.Validate("create")
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Blobs are keyed by content so identical files are only stored once
+	exists, err := blobStore.Exists(document.SHA256)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !exists {
+		err = blobStore.Put(document.SHA256, bytes.NewReader(content))
+		if err != nil {
+			log.Warnf("Couldn't store document: %s", err.Error())
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	documentCreated, err := pgRepo.CreateDocument(document)
+	if err == storage.ErrDocumentExists {
+		log.Infof("Document already uploaded.")
+		response.JSON(writer, http.StatusOK, map[string]interface{}{"document": documentCreated})
+		return
+	}
+
+	if err != nil {
+		if !exists {
+			blobStore.Delete(document.SHA256)
+		}
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// A trash purge may have removed the content of a deleted document with the same hash before this one
+	// was created, purges leave it alone from now on
+	exists, err = blobStore.Exists(document.SHA256)
+	if err == nil && !exists {
+		err = blobStore.Put(document.SHA256, bytes.NewReader(content))
+	}
+
+	if err != nil {
+		log.Warnf("Couldn't store document: %s", err.Error())
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully created document.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, documentCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"document": documentCreated})
+}
+
+// GetAllDocuments ...
+func (handler *Handler) GetAllDocuments(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	documents, err := pgRepo.AllDocuments(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all documents")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"documents": documents})
+}
+
+// GetDocument ...
+func (handler *Handler) GetDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	documentID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	document, err := pgRepo.GetDocument(documentID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the document")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"document": document})
+}
+
+// GetDocumentVersions -> handles GET /api/v1/documents/{id}/versions
+func (handler *Handler) GetDocumentVersions(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	documentID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	documents, err := pgRepo.DocumentVersions(documentID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the document versions")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"documents": documents})
+}
+
+// DownloadDocument -> handles GET /api/v1/documents/{id}/content
+func (handler *Handler) DownloadDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	blobStore := handler.blobStore
+	log := handler.logger
+
+	documentID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if blobStore == nil {
+		response.ERROR(writer, http.StatusInternalServerError, errNoBlobStore)
+		return
+	}
+
+	document, err := pgRepo.GetDocument(documentID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	content, err := blobStore.Get(document.SHA256)
+	if err == blob.ErrNotFound {
+		response.ERROR(writer, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+	defer content.Close()
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	writer.Header().Set("Content-Type", document.ContentType)
+	writer.Header().Set("Content-Length", strconv.FormatInt(document.Size, 10))
+	writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": document.FileName}))
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(http.StatusOK)
+
+	_, err = io.Copy(writer, content)
+	if err != nil {
+		log.Warnf("Couldn't send document: %s", err.Error())
+		return
+	}
+
+	log.Infof("Successfully downloaded the document")
+}
+
+// DeleteDocument -> moves the document to the trash, see RestoreDocument. The file is removed once the
+// document is purged and no other document references its content.
+func (handler *Handler) DeleteDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	documentID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	_, err = pgRepo.DeleteDocument(documentID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully deleted the document")
+	writer.Header().Set("Entity", documentID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RestoreDocument -> handles POST /api/v1/documents/{id}/restore, for documents in the trash
+func (handler *Handler) RestoreDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	documentID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	restoredDocument, err := pgRepo.RestoreDocument(documentID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully restored the document")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"document": restoredDocument})
+}
+
+// GetApplicationDocuments -> handles GET /api/v1/applications/{id}/documents
+func (handler *Handler) GetApplicationDocuments(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	documents, err := pgRepo.ApplicationDocuments(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the application documents")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"documents": documents})
+}
+
+// LinkDocument -> handles PUT /api/v1/applications/{id}/documents/{documentID}
+func (handler *Handler) LinkDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	documentID := chi.URLParam(request, "documentID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = pgRepo.LinkDocument(documentID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully linked the document")
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// UnlinkDocument -> handles DELETE /api/v1/applications/{id}/documents/{documentID}
+func (handler *Handler) UnlinkDocument(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	documentID := chi.URLParam(request, "documentID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	err = pgRepo.UnlinkDocument(documentID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully unlinked the document")
+	writer.Header().Set("Entity", documentID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
@@ -0,0 +1,483 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+var pdfContent = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+// withBlobStore points the handler at a throwaway local store
+func withBlobStore(t *testing.T) (blob.Store, func()) {
+	root, err := ioutil.TempDir("", "documents")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blobStore, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.blobStore = blobStore
+	handler.maxDocumentSize = defaultMaxDocumentSize
+	return blobStore, func() {
+		handler.blobStore = nil
+		os.RemoveAll(root)
+	}
+}
+
+// uploadRequest builds a multipart upload with the given file and form fields
+func uploadRequest(t *testing.T, fileName string, content []byte, fields map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+
+	if fileName != "" {
+		part, err := writer.CreateFormFile("file", fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(content)
+	}
+	writer.Close()
+
+	req, err := http.NewRequest("POST", "/api/v1/documents", body)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/documents' request")
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCreateDocument_201(t *testing.T) {
+
+	blobStore, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{
+			Name:        "Resume",
+			Kind:        model.DocumentResume,
+			Version:     1,
+			FileName:    "resume.pdf",
+			ContentType: "application/pdf",
+			SHA256:      sha256Hex(pdfContent),
+			UserID:      userID,
+		},
+		IsError: false,
+	}
+
+	req := uploadRequest(t, "resume.pdf", pdfContent, map[string]string{"name": "Resume", "kind": "resume"})
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+	createDocumentHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err := json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	createdDocument := responseMap["document"].(map[string]interface{})
+	exists, _ := blobStore.Exists(sha256Hex(pdfContent))
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, createdDocument["version"], float64(1))
+	assert.Equal(t, createdDocument["content_type"], "application/pdf")
+	assert.Equal(t, exists, true)
+}
+
+func TestCreateDocument_200_SameContent(t *testing.T) {
+
+	_, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{
+			Name:    "resume",
+			Version: 2,
+			UserID:  userID,
+		},
+		IsError:      true,
+		ErrorMessage: storage.ErrDocumentExists.Error(),
+	}
+
+	req := uploadRequest(t, "resume.pdf", pdfContent, nil)
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+	createDocumentHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err := json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	document := responseMap["document"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, document["version"], float64(2))
+}
+
+func TestCreateDocument_415(t *testing.T) {
+
+	_, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		fileName string
+		content  []byte
+	}{
+		{
+			fileName: "payload.exe",
+			content:  []byte("MZ\x90\x00"),
+		},
+		{
+			fileName: "resume.pdf",
+			content:  []byte("<html><body>not a pdf</body></html>"),
+		},
+	}
+
+	for _, v := range cases {
+
+		req := uploadRequest(t, v.fileName, v.content, nil)
+		req = authorize(req, userID)
+		rr := httptest.NewRecorder()
+		createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+		createDocumentHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err := json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 415)
+		assert.Equal(t, responseMap["error"], "Unsupported file type")
+	}
+}
+
+func TestCreateDocument_413(t *testing.T) {
+
+	_, cleanup := withBlobStore(t)
+	defer cleanup()
+	handler.maxDocumentSize = 16
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{},
+		IsError:      false,
+	}
+
+	req := uploadRequest(t, "notes.txt", []byte(strings.Repeat("a", 64)), nil)
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+	createDocumentHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 413)
+}
+
+func TestCreateDocument_422_Validation(t *testing.T) {
+
+	_, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		fileName     string
+		fields       map[string]string
+		errorMessage string
+	}{
+		{
+			fileName:     "",
+			errorMessage: "Required File",
+		},
+		{
+			fileName:     "resume.pdf",
+			fields:       map[string]string{"kind": "portfolio"},
+			errorMessage: "Invalid Kind",
+		},
+	}
+
+	for _, v := range cases {
+
+		req := uploadRequest(t, v.fileName, pdfContent, v.fields)
+		req = authorize(req, userID)
+		rr := httptest.NewRecorder()
+		createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+		createDocumentHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err := json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], v.errorMessage)
+	}
+}
+
+func TestCreateDocument_500(t *testing.T) {
+
+	blobStore, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{},
+		IsError:      true,
+		ErrorMessage: "Some error",
+	}
+
+	req := uploadRequest(t, "resume.pdf", pdfContent, nil)
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	createDocumentHandler := http.HandlerFunc(handler.CreateDocument)
+	createDocumentHandler.ServeHTTP(rr, req)
+
+	exists, _ := blobStore.Exists(sha256Hex(pdfContent))
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, exists, false)
+}
+
+func TestGetDocument_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/documents", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/documents/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getDocumentHandler := http.HandlerFunc(handler.GetDocument)
+	getDocumentHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestGetAllDocuments_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.Document{
+			{Name: "Resume", Version: 1, UserID: userID},
+			{Name: "Cover Letter", Version: 1, UserID: userID},
+			{Name: "Someone else's", Version: 1, UserID: uuid.NewV4()},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/documents", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/documents' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	getDocumentsHandler := http.HandlerFunc(handler.GetAllDocuments)
+	getDocumentsHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["documents"].([]interface{})), 2)
+}
+
+func TestDownloadDocument_200(t *testing.T) {
+
+	blobStore, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	blobStore.Put(sha256Hex(pdfContent), bytes.NewReader(pdfContent))
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{
+			FileName:    "my \"resume\".pdf",
+			ContentType: "application/pdf",
+			Size:        int64(len(pdfContent)),
+			SHA256:      sha256Hex(pdfContent),
+			UserID:      userID,
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/documents/content", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/documents/{id}/content' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	downloadDocumentHandler := http.HandlerFunc(handler.DownloadDocument)
+	downloadDocumentHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, rr.Header().Get("Content-Type"), "application/pdf")
+	assert.Equal(t, rr.Header().Get("Content-Disposition"), `attachment; filename="my \"resume\".pdf"`)
+	assert.Equal(t, rr.Header().Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, rr.Body.Bytes(), pdfContent)
+}
+
+func TestDeleteDocument_204(t *testing.T) {
+
+	blobStore, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	userID := uuid.NewV4()
+	blobStore.Put(sha256Hex(pdfContent), bytes.NewReader(pdfContent))
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(1),
+		IsError:      false,
+	}
+
+	documentID := uuid.NewV4().String()
+	req, err := http.NewRequest("DELETE", "/api/v1/documents", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/documents/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", documentID)
+	rr := httptest.NewRecorder()
+	deleteDocumentHandler := http.HandlerFunc(handler.DeleteDocument)
+	deleteDocumentHandler.ServeHTTP(rr, req)
+
+	// The content stays until the document is purged from the trash
+	exists, _ := blobStore.Exists(sha256Hex(pdfContent))
+
+	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, rr.Header().Get("Entity"), documentID)
+	assert.Equal(t, exists, true)
+}
+
+func TestRestoreDocument_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Document{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, Name: "Resume", Version: 2, UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/documents/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/documents/{id}/restore' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreDocumentHandler := http.HandlerFunc(handler.RestoreDocument)
+	restoreDocumentHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["document"]["name"], "Resume")
+	assert.Equal(t, responseMap["document"]["deleted_at"], nil)
+}
+
+func TestLinkDocument_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications/documents", nil)
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}/documents/{documentID}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "documentID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	linkDocumentHandler := http.HandlerFunc(handler.LinkDocument)
+	linkDocumentHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+}
+
+func TestLinkDocument_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		IsError:      true,
+		ErrorMessage: "Some error",
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/applications/documents", nil)
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/applications/{id}/documents/{documentID}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "documentID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	linkDocumentHandler := http.HandlerFunc(handler.LinkDocument)
+	linkDocumentHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 500)
+}
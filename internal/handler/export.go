@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+// exportFile -> JSON file of the archive and what goes in it
+type exportFile struct {
+	name  string
+	value interface{}
+}
+
+// ExportUser -> handles GET /api/v1/users/{id}/export, a zip archive of everything stored about the user
+// with one JSON file per kind of data and the content of their documents
+func (handler *Handler) ExportUser(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	authUserID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != authUserID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	export, err := pgRepo.ExportUser(userID, authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	fileName := fmt.Sprintf("trackr-export-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	writer.Header().Set("Content-Type", "application/zip")
+	writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.WriteHeader(http.StatusOK)
+
+	// The status is sent, failures past this point can only cut the archive short
+	err = handler.writeExport(zip.NewWriter(writer), export)
+	if err != nil {
+		log.Warnf("Couldn't send export: %s", err.Error())
+		return
+	}
+
+	log.Infof("Successfully exported the user")
+}
+
+// writeExport -> documents whose content is missing are listed in documents.json but left out of documents/
+func (handler *Handler) writeExport(archive *zip.Writer, export *model.UserExport) error {
+
+	files := []exportFile{
+		{name: "user.json", value: newUserResponse(&export.User)},
+		{name: "companies.json", value: export.Companies},
+		{name: "applications.json", value: newApplicationResponses(export.Applications)},
+		{name: "application_events.json", value: export.Events},
+		{name: "interviews.json", value: export.Interviews},
+		{name: "reminders.json", value: export.Reminders},
+		{name: "documents.json", value: export.Documents},
+		{name: "contacts.json", value: export.Contacts},
+		{name: "application_contacts.json", value: export.ApplicationContacts},
+		{name: "contact_interactions.json", value: export.Interactions},
+		{name: "identities.json", value: export.Identities},
+	}
+
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.value, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+
+		_, err = entry.Write(content)
+		if err != nil {
+			return err
+		}
+	}
+
+	if handler.blobStore != nil {
+		for _, document := range export.Documents {
+			err := handler.writeExportDocument(archive, document)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return archive.Close()
+}
+
+// writeExportDocument -> versions share a file name, so entries are prefixed with the document ID
+func (handler *Handler) writeExportDocument(archive *zip.Writer, document model.Document) error {
+
+	content, err := handler.blobStore.Get(document.SHA256)
+	if err != nil {
+		handler.logger.Warnf("Couldn't export document %s: %s", document.ID.String(), err.Error())
+		return nil
+	}
+	defer content.Close()
+
+	entry, err := archive.Create(fmt.Sprintf("documents/%s-%s", document.ID.String(), document.FileName))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, content)
+	return err
+}
@@ -0,0 +1,143 @@
+// +build !integration
+
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestExportUser_200(t *testing.T) {
+
+	blobStore, cleanup := withBlobStore(t)
+	defer cleanup()
+
+	err := blobStore.Put(sha256Hex(pdfContent), bytes.NewReader(pdfContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashedPassword, err := model.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.NewV4()
+	documentID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.UserExport{
+			User:         model.User{Base: model.Base{ID: userID}, Email: "john@gmail.com", Password: string(hashedPassword)},
+			Applications: []model.Application{{JobTitle: "Software Engineer", Company: "GoCardless", UserID: userID}},
+			Documents: []model.Document{
+				{Base: model.Base{ID: documentID}, Name: "Resume", FileName: "resume.pdf", SHA256: sha256Hex(pdfContent), UserID: userID},
+				{Base: model.Base{ID: uuid.NewV4()}, Name: "Lost", FileName: "lost.pdf", SHA256: strings.Repeat("f", 64), UserID: userID},
+			},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/users/export", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/users/{id}/export' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	exportUserHandler := http.HandlerFunc(handler.ExportUser)
+	exportUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, rr.Header().Get("Content-Type"), "application/zip")
+
+	archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = content
+	}
+
+	// Documents without content are listed but left out
+	assert.Equal(t, len(files), 12)
+	assert.Equal(t, files["documents/"+documentID.String()+"-resume.pdf"], pdfContent)
+	assert.Equal(t, strings.Contains(string(files["user.json"]), "john@gmail.com"), true)
+	assert.Equal(t, strings.Contains(string(files["user.json"]), string(hashedPassword)), false)
+	assert.Equal(t, strings.Contains(string(files["applications.json"]), "GoCardless"), true)
+	assert.Equal(t, strings.Contains(string(files["documents.json"]), "lost.pdf"), true)
+}
+
+func TestExportUser_403(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.UserExport{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/users/export", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/users/{id}/export' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	exportUserHandler := http.HandlerFunc(handler.ExportUser)
+	exportUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 403)
+}
+
+func TestExportUser_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.UserExport{},
+		IsError:      true,
+		ErrorMessage: "User not found",
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/users/export", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/users/{id}/export' request")
+	}
+
+	userID := uuid.NewV4()
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	exportUserHandler := http.HandlerFunc(handler.ExportUser)
+	exportUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "User not found")
+}
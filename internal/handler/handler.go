@@ -1,20 +1,158 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/amaraliou/trackr-core/internal/auth/oidc"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
 	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
 	"github.com/amaraliou/trackr-core/pkg/logger"
 )
 
+var (
+	errUnauthorized       = errors.New("Unauthorized")
+	errForbidden          = errors.New("Forbidden")
+	errTooManyRequests    = errors.New("Too many requests")
+	errEmailNotVerified   = errors.New("Email not verified")
+	errAccountDisabled    = errors.New("Account disabled")
+	errMFAEnabled         = errors.New("Two-factor authentication already enabled")
+	errMFANotEnabled      = errors.New("Two-factor authentication not enabled")
+	errMFANotSetUp        = errors.New("Two-factor authentication not set up")
+	errRequiredCode       = errors.New("Required Code")
+	errInvalidCode        = errors.New("Invalid code")
+	errInvalidCredentials = errors.New("Invalid credentials")
+	errWrongPassword      = errors.New("Wrong current password")
+	errNoPassword         = errors.New("Account has no password, set one with a password reset")
+	errPasswordChange     = errors.New("Password can only be changed along with the current one")
+)
+
+const defaultMaxDocumentSize = 10 << 20
+
 // Handler ...
 type Handler struct {
-	pgRepo storage.PostgresInterface
-	logger logger.Logger
+	pgRepo               storage.PostgresInterface
+	logger               logger.Logger
+	blobStore            blob.Store
+	maxDocumentSize      int64
+	notifier             notifier.Notifier
+	appURL               string
+	requireVerifiedEmail bool
+	keys                 *keys.Manager
+	oidcProviders        map[string]*oidc.Provider
+	deletionGracePeriod  time.Duration
+	trashRetention       time.Duration
+}
+
+// Option -> configures the optional dependencies of a Handler
+type Option func(*Handler)
+
+// WithBlobStore -> store for document files and the maximum upload size in bytes
+func WithBlobStore(blobStore blob.Store, maxDocumentSize int64) Option {
+	return func(handler *Handler) {
+		handler.blobStore = blobStore
+		if maxDocumentSize > 0 {
+			handler.maxDocumentSize = maxDocumentSize
+		}
+	}
+}
+
+// WithNotifier -> notifier for account emails, links in them point to appURL
+func WithNotifier(notifier notifier.Notifier, appURL string) Option {
+	return func(handler *Handler) {
+		handler.notifier = notifier
+		handler.appURL = strings.TrimSuffix(appURL, "/")
+	}
+}
+
+// WithRequireVerifiedEmail -> users can't sign in until they verified their email
+func WithRequireVerifiedEmail() Option {
+	return func(handler *Handler) {
+		handler.requireVerifiedEmail = true
+	}
+}
+
+// WithKeys -> key manager whose public keys are served as a JWKS
+func WithKeys(manager *keys.Manager) Option {
+	return func(handler *Handler) {
+		handler.keys = manager
+	}
+}
+
+// WithOIDCProviders -> identity providers users can sign in with, by name
+func WithOIDCProviders(providers ...*oidc.Provider) Option {
+	return func(handler *Handler) {
+		handler.oidcProviders = map[string]*oidc.Provider{}
+		for _, provider := range providers {
+			handler.oidcProviders[provider.Name()] = provider
+		}
+	}
+}
+
+// WithDeletionGracePeriod -> time between a user asking for their account to be deleted and its move to the trash
+func WithDeletionGracePeriod(gracePeriod time.Duration) Option {
+	return func(handler *Handler) {
+		if gracePeriod > 0 {
+			handler.deletionGracePeriod = gracePeriod
+		}
+	}
+}
+
+// WithTrashRetention -> time deleted items stay in the trash, should match the scheduler's
+func WithTrashRetention(retention time.Duration) Option {
+	return func(handler *Handler) {
+		if retention > 0 {
+			handler.trashRetention = retention
+		}
+	}
 }
 
 // New ...
-func New(pgRepo storage.PostgresInterface, logger logger.Logger) *Handler {
-	return &Handler{
-		pgRepo: pgRepo,
-		logger: logger,
+func New(pgRepo storage.PostgresInterface, logger logger.Logger, options ...Option) *Handler {
+	handler := &Handler{
+		pgRepo:              pgRepo,
+		logger:              logger,
+		maxDocumentSize:     defaultMaxDocumentSize,
+		notifier:            notifier.NewLogNotifier(logger),
+		deletionGracePeriod: model.DefaultDeletionGracePeriod,
+		trashRetention:      model.DefaultTrashRetention,
+	}
+
+	for _, option := range options {
+		option(handler)
+	}
+
+	return handler
+}
+
+// requestUserID -> user ID injected by middleware.SetAuth
+func requestUserID(request *http.Request) (string, error) {
+	userID, ok := auth.UserIDFromContext(request.Context())
+	if !ok {
+		return "", errUnauthorized
+	}
+	return userID, nil
+}
+
+// errorStatus -> maps repository errors to HTTP status codes
+func errorStatus(err error) int {
+	switch err {
+	case storage.ErrUserNotFound, storage.ErrApplicationNotFound, storage.ErrCompanyNotFound, storage.ErrInterviewNotFound,
+		storage.ErrDocumentNotFound, storage.ErrContactNotFound, storage.ErrReminderNotFound, storage.ErrAPIKeyNotFound:
+		return http.StatusNotFound
+	case storage.ErrForbidden:
+		return http.StatusForbidden
+	case storage.ErrCompanyExists, storage.ErrIdentityConflict, storage.ErrEmailExists:
+		return http.StatusConflict
+	case storage.ErrTokenInvalid, auth.ErrInvalidToken:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
 	}
 }
@@ -3,12 +3,18 @@
 package handler
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
 	"testing"
 
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
 	"github.com/amaraliou/trackr-core/internal/storage/mock"
 	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
 )
 
 var handler *Handler
@@ -31,6 +37,32 @@ func TestMain(m *testing.M) {
 		log.Fatal(err)
 	}
 
-	handler = New(mockRepo, logger)
+	err = auth.UseSecret("test_secret_at_least_32_characters")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keyManager, err := keys.NewManager(keys.NewMemoryStore(), keys.EdDSA, 0, 0, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	auth.UseKeys(keyManager)
+
+	handler = New(mockRepo, logger, WithKeys(keyManager))
 	os.Exit(m.Run())
 }
+
+// authorize sets the user ID the way middleware.SetAuth does
+func authorize(request *http.Request, userID uuid.UUID) *http.Request {
+	return request.WithContext(auth.WithUserID(request.Context(), userID.String()))
+}
+
+// withURLParam sets a chi URL parameter on the request
+func withURLParam(request *http.Request, key, value string) *http.Request {
+	routeContext := chi.RouteContext(request.Context())
+	if routeContext == nil {
+		routeContext = chi.NewRouteContext()
+	}
+	routeContext.URLParams.Add(key, value)
+	return request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeContext))
+}
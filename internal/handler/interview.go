@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateInterview -> handles POST /api/v1/applications/{id}/interviews
+func (handler *Handler) CreateInterview(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		log.Warnf("Couldn't read request body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interview := model.Interview{}
+	err = json.Unmarshal(body, &interview)
+	if err != nil {
+		log.Warnf("Couldn't marshal JSON body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interview.ID = uuid.Nil
+	interview.ApplicationID = uuid.FromStringOrNil(applicationID)
+	interview.UserID = uuid.FromStringOrNil(userID)
+
+	err = interview.Validate("create")
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interviewCreated, err := pgRepo.CreateInterview(interview)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully created interview.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, interviewCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"interview": interviewCreated})
+}
+
+// GetAllInterviews -> handles GET /api/v1/applications/{id}/interviews
+func (handler *Handler) GetAllInterviews(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	interviews, err := pgRepo.AllInterviews(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all interviews")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"interviews": interviews})
+}
+
+// GetInterview -> handles GET /api/v1/applications/{id}/interviews/{interviewID}
+func (handler *Handler) GetInterview(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	interviewID := chi.URLParam(request, "interviewID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	interview, err := pgRepo.GetInterview(interviewID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the interview")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"interview": interview})
+}
+
+// UpdateInterview -> only the given fields are updated
+func (handler *Handler) UpdateInterview(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	interviewID := chi.URLParam(request, "interviewID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	interview := model.Interview{}
+	err = json.Unmarshal(body, &interview)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Ownership and identity can't be changed through the body
+	interview.ID = uuid.Nil
+	interview.ApplicationID = uuid.Nil
+	interview.UserID = uuid.Nil
+
+	err = interview.Validate("update")
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	updatedInterview, err := pgRepo.UpdateInterview(interview, interviewID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully updated the interview")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"interview": updatedInterview})
+}
+
+// DeleteInterview -> moves the interview to the trash, see RestoreInterview
+func (handler *Handler) DeleteInterview(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	interviewID := chi.URLParam(request, "interviewID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	_, err = pgRepo.DeleteInterview(interviewID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully deleted the interview")
+	writer.Header().Set("Entity", interviewID)
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RestoreInterview -> handles POST /api/v1/applications/{id}/interviews/{interviewID}/restore, for interviews in the trash
+func (handler *Handler) RestoreInterview(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	interviewID := chi.URLParam(request, "interviewID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	restoredInterview, err := pgRepo.RestoreInterview(interviewID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully restored the interview")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"interview": restoredInterview})
+}
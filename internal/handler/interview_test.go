@@ -0,0 +1,323 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateInterview_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	interviewToCreate := model.Interview{
+		Type:         model.InterviewTechnical,
+		ScheduledAt:  time.Date(2020, time.September, 1, 14, 0, 0, 0, time.UTC),
+		TimeZone:     "Europe/London",
+		Duration:     60,
+		Interviewers: model.StringList{"Jane Doe"},
+		UserID:       userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &interviewToCreate,
+		IsError:      false,
+	}
+
+	jsonByte, err := json.Marshal(interviewToCreate)
+	if err != nil {
+		t.Error("Failed to marshal Interview struct")
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/interviews", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/interviews' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	createInterviewHandler := http.HandlerFunc(handler.CreateInterview)
+	createInterviewHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	createdInterview := responseMap["interview"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, createdInterview["type"], "technical")
+	assert.Equal(t, len(createdInterview["interviewers"].([]interface{})), 1)
+}
+
+func TestCreateInterview_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Interview{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"scheduled_at": "2020-09-01T14:00:00Z"}`,
+			errorMessage: "Required Type",
+		},
+		{
+			inputJSON:    `{"type": "phone"}`,
+			errorMessage: "Required Scheduled At",
+		},
+		{
+			inputJSON:    `{"type": "video", "scheduled_at": "2020-09-01T14:00:00Z"}`,
+			errorMessage: "Invalid Type",
+		},
+		{
+			inputJSON:    `{"type": "phone", "scheduled_at": "2020-09-01T14:00:00Z", "outcome": "hired"}`,
+			errorMessage: "Invalid Outcome",
+		},
+		{
+			inputJSON:    `{"type": "phone", "scheduled_at": "2020-09-01T14:00:00Z", "duration_minutes": -30}`,
+			errorMessage: "Invalid Duration",
+		},
+		{
+			inputJSON:    `{"type": "phone", "scheduled_at": "2020-09-01T14:00:00Z", "time_zone": "Mars/Olympus"}`,
+			errorMessage: "Invalid Time Zone",
+		},
+		{
+			inputJSON:    `{"type": "phone", "scheduled_at": "2020-09-01T14:00:00Z", "meeting_url": "meet"}`,
+			errorMessage: "Invalid Meeting URL",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/applications/interviews", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/applications/{id}/interviews' request")
+		}
+
+		req = authorize(req, uuid.NewV4())
+		req = withURLParam(req, "id", uuid.NewV4().String())
+		rr := httptest.NewRecorder()
+		createInterviewHandler := http.HandlerFunc(handler.CreateInterview)
+		createInterviewHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestCreateInterview_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Interview{},
+		IsError:      true,
+		ErrorMessage: "Table 'interviews' doesn't exist",
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/interviews", bytes.NewBufferString(`{"type": "phone", "scheduled_at": "2020-09-01T14:00:00Z"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/interviews' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	createInterviewHandler := http.HandlerFunc(handler.CreateInterview)
+	createInterviewHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'interviews' doesn't exist")
+}
+
+func TestGetAllInterviews_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	interviewsToGet := []model.Interview{
+		{
+			Round:  1,
+			Type:   model.InterviewPhone,
+			UserID: userID,
+		},
+		{
+			Round:  2,
+			Type:   model.InterviewOnsite,
+			UserID: userID,
+		},
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &interviewsToGet,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications/interviews", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}/interviews' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getAllInterviewsHandler := http.HandlerFunc(handler.GetAllInterviews)
+	getAllInterviewsHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	interviews := responseMap["interviews"].([]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(interviews), len(interviewsToGet))
+}
+
+func TestGetInterview_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Interview{
+			Type:   model.InterviewPhone,
+			UserID: uuid.NewV4(),
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications/interviews", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications/{id}/interviews/{interviewID}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "interviewID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getInterviewHandler := http.HandlerFunc(handler.GetInterview)
+	getInterviewHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 404)
+	assert.Equal(t, responseMap["error"], "Interview not found")
+}
+
+func TestUpdateInterview_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	updatedInterview := model.Interview{
+		Type:    model.InterviewPhone,
+		Outcome: model.InterviewPassed,
+		UserID:  userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &updatedInterview,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PATCH", "/api/v1/applications/interviews", bytes.NewBufferString(`{"outcome": "passed"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/applications/{id}/interviews/{interviewID}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "interviewID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateInterviewHandler := http.HandlerFunc(handler.UpdateInterview)
+	updateInterviewHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	interview := responseMap["interview"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, interview["outcome"], "passed")
+}
+
+func TestDeleteInterview_204(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: int64(1),
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/applications/interviews", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/applications/{id}/interviews/{interviewID}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "interviewID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	deleteInterviewHandler := http.HandlerFunc(handler.DeleteInterview)
+	deleteInterviewHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+}
+
+func TestRestoreInterview_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	deletedAt := time.Now()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Interview{Base: model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt}, Type: model.InterviewTechnical, Round: 2, UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/interviews/restore", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/interviews/{interviewID}/restore' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "interviewID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	restoreInterviewHandler := http.HandlerFunc(handler.RestoreInterview)
+	restoreInterviewHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["interview"]["round"], float64(2))
+	assert.Equal(t, responseMap["interview"]["deleted_at"], nil)
+}
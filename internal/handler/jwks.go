@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/amaraliou/trackr-core/internal/response"
+)
+
+// jwksMaxAge -> how long verifiers may cache the key set, in seconds. Has to stay well below the key overlap.
+const jwksMaxAge = "300"
+
+// JWKS -> handles GET /.well-known/jwks.json, the public keys access tokens are verified with
+func (handler *Handler) JWKS(writer http.ResponseWriter, request *http.Request) {
+
+	set := keys.JWKSet{Keys: []keys.JWK{}}
+	if handler.keys != nil {
+		set = handler.keys.JWKS()
+	}
+
+	writer.Header().Set("Cache-Control", "public, max-age="+jwksMaxAge)
+	response.JSON(writer, http.StatusOK, set)
+}
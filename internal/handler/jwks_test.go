@@ -0,0 +1,53 @@
+// +build !integration
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestJWKS_200(t *testing.T) {
+
+	req, err := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /.well-known/jwks.json' request")
+	}
+
+	rr := httptest.NewRecorder()
+	jwksHandler := http.HandlerFunc(handler.JWKS)
+	jwksHandler.ServeHTTP(rr, req)
+
+	set := keys.JWKSet{}
+	err = json.Unmarshal(rr.Body.Bytes(), &set)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, rr.Header().Get("Cache-Control"), "public, max-age=300")
+	assert.Equal(t, len(set.Keys), 1)
+
+	// Access tokens name the published key
+	accessToken, err := auth.CreateToken(uuid.NewV4(), string(model.RoleUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := new(jwt.Parser).ParseUnverified(accessToken, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, token.Header["kid"], set.Keys[0].KeyID)
+	assert.Equal(t, token.Header["alg"], set.Keys[0].Algorithm)
+}
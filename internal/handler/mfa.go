@@ -0,0 +1,447 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+// mfaRequest -> body of requests giving a second factor, either a TOTP code or a recovery code
+type mfaRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// mfaChallengeResponse -> returned on login instead of tokens when the user has two-factor authentication
+type mfaChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// SetupTOTP -> handles POST /api/v1/auth/mfa/totp, starts an enrolment confirmed by ConfirmTOTP.
+// Setting up again before confirming replaces the secret.
+func (handler *Handler) SetupTOTP(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if user.MFAEnabled() {
+		response.ERROR(writer, http.StatusConflict, errMFAEnabled)
+		return
+	}
+
+	secret, sealed, err := auth.NewTOTPSecret()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = pgRepo.SetTOTPSecret(userID, sealed)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully started the TOTP enrolment.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{
+		"secret":      secret,
+		"otpauth_uri": auth.TOTPURI(user.Email, secret),
+	})
+}
+
+// ConfirmTOTP -> handles POST /api/v1/auth/mfa/totp/confirm, enables two-factor authentication once a code of the new secret is given.
+// The recovery codes are only ever returned in this response.
+func (handler *Handler) ConfirmTOTP(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	mfa, err := readMFARequest(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if mfa.Code == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errRequiredCode)
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if user.MFAEnabled() {
+		response.ERROR(writer, http.StatusConflict, errMFAEnabled)
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		response.ERROR(writer, http.StatusConflict, errMFANotSetUp)
+		return
+	}
+
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, mfa.Code, time.Now())
+	if !ok {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errInvalidCode)
+		return
+	}
+
+	recoveryCodes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = pgRepo.EnableTOTP(userID, step, hashes)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditMFAEnabled, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully enabled two-factor authentication.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user), "recovery_codes": recoveryCodes})
+}
+
+// DisableTOTP -> handles DELETE /api/v1/auth/mfa/totp, takes a TOTP code or a recovery code
+func (handler *Handler) DisableTOTP(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	mfa, err := readMFARequest(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if !user.MFAEnabled() {
+		response.ERROR(writer, http.StatusConflict, errMFANotEnabled)
+		return
+	}
+
+	err = handler.checkSecondFactor(user, mfa)
+	if err == errRequiredCode || err == errInvalidCode {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = pgRepo.DisableTOTP(userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditMFADisabled, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully disabled two-factor authentication.")
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// RegenerateRecoveryCodes -> handles POST /api/v1/auth/mfa/recovery-codes, the previous codes stop working
+func (handler *Handler) RegenerateRecoveryCodes(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	mfa, err := readMFARequest(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if !user.MFAEnabled() {
+		response.ERROR(writer, http.StatusConflict, errMFANotEnabled)
+		return
+	}
+
+	err = handler.checkSecondFactor(user, mfa)
+	if err == errRequiredCode || err == errInvalidCode {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	recoveryCodes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = pgRepo.ReplaceRecoveryCodes(userID, hashes)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully regenerated the recovery codes.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+// VerifyMFA -> handles POST /api/v1/auth/mfa/verify, exchanges the challenge returned by Login and a second factor for tokens
+func (handler *Handler) VerifyMFA(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	mfa, err := readMFARequest(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if mfa.MFAToken == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required MFA Token"))
+		return
+	}
+
+	hash, err := auth.VerifySignedToken(string(model.TokenMFAChallenge), mfa.MFAToken)
+	if err != nil {
+		log.Warnf("Rejected MFA challenge with an invalid signature")
+		response.ERROR(writer, http.StatusUnauthorized, storage.ErrTokenInvalid)
+		return
+	}
+
+	user, err := pgRepo.GetMFAChallenge(hash)
+	if err == storage.ErrTokenInvalid {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = handler.checkSecondFactor(user, mfa)
+	if err == errRequiredCode {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if err == errInvalidCode {
+		log.Warnf("Rejected a wrong second factor")
+		failErr := pgRepo.FailMFAChallenge(hash)
+		if failErr != nil {
+			response.ERROR(writer, http.StatusInternalServerError, failErr)
+			return
+		}
+
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = pgRepo.CompleteMFAChallenge(hash)
+	if err == storage.ErrTokenInvalid {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	// The account may have been disabled since the password was given
+	if user.Disabled() {
+		response.ERROR(writer, http.StatusForbidden, errAccountDisabled)
+		return
+	}
+
+	tokens, err := handler.newSession(request, user)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully logged in with a second factor.")
+	response.JSON(writer, http.StatusOK, tokens)
+}
+
+// newMFAChallenge -> challenge for a user who gave the right password, exchanged by VerifyMFA
+func (handler *Handler) newMFAChallenge(user *model.User) (*mfaChallengeResponse, error) {
+
+	token, hash, err := auth.NewSignedToken(string(model.TokenMFAChallenge))
+	if err != nil {
+		return &mfaChallengeResponse{}, err
+	}
+
+	_, err = handler.pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenMFAChallenge,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(model.MFAChallengeTTL),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return &mfaChallengeResponse{}, err
+	}
+
+	return &mfaChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(model.MFAChallengeTTL.Seconds()),
+	}, nil
+}
+
+// checkSecondFactor -> uses the TOTP code or recovery code of the request, errInvalidCode if it isn't accepted
+func (handler *Handler) checkSecondFactor(user *model.User, mfa mfaRequest) error {
+
+	pgRepo := handler.pgRepo
+
+	var err error
+	switch {
+	case mfa.Code != "":
+		step, ok := auth.ValidateTOTP(user.TOTPSecret, mfa.Code, time.Now())
+		if !ok {
+			return errInvalidCode
+		}
+		err = pgRepo.UseTOTPStep(user.ID.String(), step)
+	case mfa.RecoveryCode != "":
+		err = pgRepo.UseRecoveryCode(user.ID.String(), auth.HashRecoveryCode(mfa.RecoveryCode))
+	default:
+		return errRequiredCode
+	}
+
+	if err == storage.ErrTokenInvalid {
+		return errInvalidCode
+	}
+	return err
+}
+
+// readMFARequest -> body of the request, which may be empty
+func readMFARequest(request *http.Request) (mfaRequest, error) {
+
+	mfa := mfaRequest{}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return mfa, err
+	}
+
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &mfa)
+	}
+	return mfa, err
+}
+
+// newRecoveryCodes -> recovery codes to show the user along with their hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+
+	codes := make([]string, model.RecoveryCodeCount)
+	hashes := make([]string, model.RecoveryCodeCount)
+
+	for i := range codes {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes[i] = code
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	return codes, hashes, nil
+}
@@ -0,0 +1,333 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// enrolledUser returns a user with two-factor authentication enabled and its TOTP secret
+func enrolledUser(t *testing.T) (*model.User, string) {
+	secret, sealed, err := auth.NewTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabledAt := time.Now()
+	return &model.User{
+		Base:          model.Base{ID: uuid.NewV4()},
+		Email:         "random@gmail.com",
+		Password:      string(hashedPassword),
+		Role:          model.RoleUser,
+		TOTPSecret:    sealed,
+		TOTPEnabledAt: &enabledAt,
+	}, secret
+}
+
+func TestLogin_200_MFARequired(t *testing.T) {
+
+	user, _ := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	jsonByte, _ := json.Marshal(map[string]string{"email": user.Email, "password": "random"})
+	req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+	}
+
+	rr := httptest.NewRecorder()
+	loginHandler := http.HandlerFunc(handler.Login)
+	loginHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	_, err = auth.VerifySignedToken(string(model.TokenMFAChallenge), responseMap["mfa_token"].(string))
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, responseMap["mfa_required"], true)
+	assert.Equal(t, responseMap["access_token"], nil)
+}
+
+func TestSetupTOTP_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com"},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/mfa/totp", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/mfa/totp' request")
+	}
+	req = authorize(req, userID)
+
+	rr := httptest.NewRecorder()
+	setupTOTPHandler := http.HandlerFunc(handler.SetupTOTP)
+	setupTOTPHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	secret := responseMap["secret"].(string)
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, strings.HasPrefix(responseMap["otpauth_uri"].(string), "otpauth://totp/Trackr:random@gmail.com?"), true)
+	assert.Equal(t, strings.Contains(responseMap["otpauth_uri"].(string), "secret="+secret), true)
+}
+
+func TestSetupTOTP_409(t *testing.T) {
+
+	user, _ := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/mfa/totp", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/mfa/totp' request")
+	}
+	req = authorize(req, user.ID)
+
+	rr := httptest.NewRecorder()
+	setupTOTPHandler := http.HandlerFunc(handler.SetupTOTP)
+	setupTOTPHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 409)
+}
+
+func TestConfirmTOTP_200(t *testing.T) {
+
+	user, secret := enrolledUser(t)
+	user.TOTPEnabledAt = nil
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	jsonByte, _ := json.Marshal(map[string]string{"code": code})
+	req, err := http.NewRequest("POST", "/api/v1/auth/mfa/totp/confirm", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/mfa/totp/confirm' request")
+	}
+	req = authorize(req, user.ID)
+
+	rr := httptest.NewRecorder()
+	confirmTOTPHandler := http.HandlerFunc(handler.ConfirmTOTP)
+	confirmTOTPHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["recovery_codes"].([]interface{})), model.RecoveryCodeCount)
+	assert.NotEqual(t, responseMap["user"].(map[string]interface{})["totp_enabled_at"], nil)
+}
+
+func TestConfirmTOTP_422_Validation(t *testing.T) {
+
+	user, secret := enrolledUser(t)
+	user.TOTPEnabledAt = nil
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	// A code from long ago
+	code, _ := auth.TOTPCode(secret, time.Now().Add(-time.Hour))
+	cases := []struct {
+		body    map[string]string
+		message string
+	}{
+		{body: map[string]string{}, message: "Required Code"},
+		{body: map[string]string{"code": code}, message: "Invalid code"},
+	}
+
+	for _, c := range cases {
+		jsonByte, _ := json.Marshal(c.body)
+		req, err := http.NewRequest("POST", "/api/v1/auth/mfa/totp/confirm", bytes.NewBuffer(jsonByte))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/mfa/totp/confirm' request")
+		}
+		req = authorize(req, user.ID)
+
+		rr := httptest.NewRecorder()
+		confirmTOTPHandler := http.HandlerFunc(handler.ConfirmTOTP)
+		confirmTOTPHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.message)
+	}
+}
+
+func TestDisableTOTP_204(t *testing.T) {
+
+	user, _ := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	jsonByte, _ := json.Marshal(map[string]string{"recovery_code": "abcde-fghij"})
+	req, err := http.NewRequest("DELETE", "/api/v1/auth/mfa/totp", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/auth/mfa/totp' request")
+	}
+	req = authorize(req, user.ID)
+
+	rr := httptest.NewRecorder()
+	disableTOTPHandler := http.HandlerFunc(handler.DisableTOTP)
+	disableTOTPHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, user.MFAEnabled(), false)
+}
+
+func TestRegenerateRecoveryCodes_200(t *testing.T) {
+
+	user, secret := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	jsonByte, _ := json.Marshal(map[string]string{"code": code})
+	req, err := http.NewRequest("POST", "/api/v1/auth/mfa/recovery-codes", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/mfa/recovery-codes' request")
+	}
+	req = authorize(req, user.ID)
+
+	rr := httptest.NewRecorder()
+	regenerateHandler := http.HandlerFunc(handler.RegenerateRecoveryCodes)
+	regenerateHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["recovery_codes"].([]interface{})), model.RecoveryCodeCount)
+}
+
+func TestVerifyMFA_200(t *testing.T) {
+
+	user, secret := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	mfaToken, _, err := auth.NewSignedToken(string(model.TokenMFAChallenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	jsonByte, _ := json.Marshal(map[string]string{"mfa_token": mfaToken, "code": code})
+	req, err := http.NewRequest("POST", "/api/v1/auth/mfa/verify", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/mfa/verify' request")
+	}
+
+	rr := httptest.NewRecorder()
+	verifyMFAHandler := http.HandlerFunc(handler.VerifyMFA)
+	verifyMFAHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	claims, err := auth.ParseToken(responseMap["access_token"].(string))
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, claims.UserID, user.ID.String())
+	assert.NotEqual(t, responseMap["refresh_token"], "")
+}
+
+func TestVerifyMFA_401(t *testing.T) {
+
+	user, secret := enrolledUser(t)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	mfaToken, _, err := auth.NewSignedToken(string(model.TokenMFAChallenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Refresh tokens can't stand in for challenges
+	refreshToken, _, err := auth.NewSignedToken(string(model.TokenRefresh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now())
+	staleCode, _ := auth.TOTPCode(secret, time.Now().Add(-time.Hour))
+	cases := []map[string]string{
+		{"mfa_token": mfaToken, "code": staleCode},
+		{"mfa_token": refreshToken, "code": code},
+	}
+
+	for _, c := range cases {
+		jsonByte, _ := json.Marshal(c)
+		req, err := http.NewRequest("POST", "/api/v1/auth/mfa/verify", bytes.NewBuffer(jsonByte))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/mfa/verify' request")
+		}
+
+		rr := httptest.NewRecorder()
+		verifyMFAHandler := http.HandlerFunc(handler.VerifyMFA)
+		verifyMFAHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 401)
+	}
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/oidc"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+var (
+	errProviderNotFound = errors.New("Identity provider not found")
+	errSignInFailed     = errors.New("Sign in with the identity provider failed")
+	errNoEmail          = errors.New("The identity provider didn't share an email")
+)
+
+// oidcCallbackRequest -> parameters the provider redirected to the app with
+type oidcCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// OIDCAuthorize -> handles GET /api/v1/auth/oidc/{provider}, returns the URL to send the user to.
+// The provider redirects back to the app, which passes the code and state on to OIDCCallback.
+func (handler *Handler) OIDCAuthorize(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	provider, ok := handler.oidcProviders[chi.URLParam(request, "provider")]
+	if !ok {
+		response.ERROR(writer, http.StatusNotFound, errProviderNotFound)
+		return
+	}
+
+	state, hash, err := auth.NewSignedToken(string(model.TokenOIDCState))
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(request.Context(), state, nonce, verifier)
+	if err != nil {
+		log.Errorf("Failed to discover the identity provider: %s", err.Error())
+		response.ERROR(writer, http.StatusBadGateway, errSignInFailed)
+		return
+	}
+
+	_, err = pgRepo.CreateOIDCState(model.OIDCState{
+		Provider:  provider.Name(),
+		Hash:      hash,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(model.OIDCStateTTL),
+	})
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully started a sign in with an identity provider.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"authorization_url": authorizationURL})
+}
+
+// OIDCCallback -> handles POST /api/v1/auth/oidc/{provider}/callback, signs in like Login.
+// Users are linked by verified email or created without a password on their first sign in.
+func (handler *Handler) OIDCCallback(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	provider, ok := handler.oidcProviders[chi.URLParam(request, "provider")]
+	if !ok {
+		response.ERROR(writer, http.StatusNotFound, errProviderNotFound)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	callback := oidcCallbackRequest{}
+	err = json.Unmarshal(body, &callback)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if callback.Code == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errRequiredCode)
+		return
+	}
+
+	if callback.State == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required State"))
+		return
+	}
+
+	hash, err := auth.VerifySignedToken(string(model.TokenOIDCState), callback.State)
+	if err != nil {
+		log.Warnf("Rejected OIDC state with an invalid signature")
+		response.ERROR(writer, http.StatusUnauthorized, storage.ErrTokenInvalid)
+		return
+	}
+
+	state, err := pgRepo.ConsumeOIDCState(hash, provider.Name())
+	if err == storage.ErrTokenInvalid {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	identity, err := provider.Exchange(request.Context(), callback.Code, state.Verifier, state.Nonce)
+	if err == oidc.ErrExchangeFailed || err == oidc.ErrInvalidIDToken {
+		log.Warnf("Identity provider sign in rejected: %s", err.Error())
+		response.ERROR(writer, http.StatusUnauthorized, errSignInFailed)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("Failed to reach the identity provider: %s", err.Error())
+		response.ERROR(writer, http.StatusBadGateway, errSignInFailed)
+		return
+	}
+
+	if identity.Email == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errNoEmail)
+		return
+	}
+
+	firstName := identity.GivenName
+	if firstName == "" && identity.FamilyName == "" {
+		firstName = identity.Name
+	}
+
+	user, err := pgRepo.SignInWithIdentity(
+		model.Identity{Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email},
+		model.User{Email: identity.Email, IsVerified: identity.EmailVerified, FirstName: firstName, LastName: identity.FamilyName},
+	)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if user.Disabled() {
+		response.ERROR(writer, http.StatusForbidden, errAccountDisabled)
+		return
+	}
+
+	if handler.requireVerifiedEmail && !user.IsVerified {
+		response.ERROR(writer, http.StatusForbidden, errEmailNotVerified)
+		return
+	}
+
+	// The identity provider stands in for the password, not for the second factor
+	if user.MFAEnabled() {
+		challenge, err := handler.newMFAChallenge(user)
+		if err != nil {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		log.Infof("Identity accepted, waiting for the second factor.")
+		response.JSON(writer, http.StatusOK, challenge)
+		return
+	}
+
+	tokens, err := handler.newSession(request, user)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully signed in with an identity provider.")
+	response.JSON(writer, http.StatusOK, tokens)
+}
@@ -0,0 +1,250 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/oidc"
+	"github.com/amaraliou/trackr-core/internal/auth/oidc/oidctest"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// newOIDCServer starts a mock provider and registers it with the handler as "test"
+func newOIDCServer(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	server, err := oidctest.NewServer("trackr", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := oidc.NewProvider(oidc.Config{
+		Name:         "test",
+		DiscoveryURL: server.URL,
+		ClientID:     "trackr",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/auth/oidc/test/callback",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	WithOIDCProviders(provider)(handler)
+	return server, provider
+}
+
+// signInAtProvider signs in at the mock provider, returning the callback body and the state it was started with
+func signInAtProvider(t *testing.T, server *oidctest.Server, provider *oidc.Provider) (map[string]string, *model.OIDCState) {
+	state, _, err := auth.NewSignedToken(string(model.TokenOIDCState))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce, _ := oidc.NewNonce()
+	verifier, _ := oidc.NewVerifier()
+	authorizationURL, err := provider.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, err := server.Authorize(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]string{"code": code, "state": state}, &model.OIDCState{Provider: "test", Nonce: nonce, Verifier: verifier}
+}
+
+func TestOIDCAuthorize_200(t *testing.T) {
+
+	server, _ := newOIDCServer(t)
+	defer server.Close()
+
+	handler.pgRepo = &mock.Repository{
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/auth/oidc/test", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/auth/oidc/{provider}' request")
+	}
+	req = withURLParam(req, "provider", "test")
+
+	rr := httptest.NewRecorder()
+	oidcAuthorizeHandler := http.HandlerFunc(handler.OIDCAuthorize)
+	oidcAuthorizeHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, strings.HasPrefix(responseMap["authorization_url"].(string), server.URL+"/authorize?"), true)
+}
+
+func TestOIDCAuthorize_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/auth/oidc/unknown", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/auth/oidc/{provider}' request")
+	}
+	req = withURLParam(req, "provider", "unknown")
+
+	rr := httptest.NewRecorder()
+	oidcAuthorizeHandler := http.HandlerFunc(handler.OIDCAuthorize)
+	oidcAuthorizeHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
+
+func TestOIDCCallback_200(t *testing.T) {
+
+	server, provider := newOIDCServer(t)
+	defer server.Close()
+
+	server.User = oidctest.User{Subject: "1234", Email: "random@gmail.com", EmailVerified: true}
+	body, state := signInAtProvider(t, server, provider)
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObjects: map[string]interface{}{
+			"ConsumeOIDCState":   state,
+			"SignInWithIdentity": &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com", Role: model.RoleUser},
+		},
+		IsError: false,
+	}
+
+	jsonByte, _ := json.Marshal(body)
+	req, err := http.NewRequest("POST", "/api/v1/auth/oidc/test/callback", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/oidc/{provider}/callback' request")
+	}
+	req = withURLParam(req, "provider", "test")
+
+	rr := httptest.NewRecorder()
+	oidcCallbackHandler := http.HandlerFunc(handler.OIDCCallback)
+	oidcCallbackHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	claims, err := auth.ParseToken(responseMap["access_token"].(string))
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, claims.UserID, userID.String())
+}
+
+func TestOIDCCallback_401(t *testing.T) {
+
+	server, provider := newOIDCServer(t)
+	defer server.Close()
+
+	server.User = oidctest.User{Subject: "1234", Email: "random@gmail.com", EmailVerified: true}
+
+	// State of another sign in, its nonce doesn't match the ID token
+	body, _ := signInAtProvider(t, server, provider)
+	_, otherState := signInAtProvider(t, server, provider)
+
+	// State signed for another purpose
+	refreshToken, _, _ := auth.NewSignedToken(string(model.TokenRefresh))
+
+	cases := []struct {
+		body  map[string]string
+		state *model.OIDCState
+	}{
+		{body: body, state: otherState},
+		{body: map[string]string{"code": body["code"], "state": refreshToken}, state: otherState},
+	}
+
+	for _, c := range cases {
+		handler.pgRepo = &mock.Repository{
+			ReturnObjects: map[string]interface{}{
+				"ConsumeOIDCState":   c.state,
+				"SignInWithIdentity": &model.User{Base: model.Base{ID: uuid.NewV4()}, Role: model.RoleUser},
+			},
+			IsError: false,
+		}
+
+		jsonByte, _ := json.Marshal(c.body)
+		req, err := http.NewRequest("POST", "/api/v1/auth/oidc/test/callback", bytes.NewBuffer(jsonByte))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/oidc/{provider}/callback' request")
+		}
+		req = withURLParam(req, "provider", "test")
+
+		rr := httptest.NewRecorder()
+		oidcCallbackHandler := http.HandlerFunc(handler.OIDCCallback)
+		oidcCallbackHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 401)
+	}
+}
+
+func TestOIDCCallback_422_NoEmail(t *testing.T) {
+
+	server, provider := newOIDCServer(t)
+	defer server.Close()
+
+	server.User = oidctest.User{Subject: "1234"}
+	body, state := signInAtProvider(t, server, provider)
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObjects: map[string]interface{}{
+			"ConsumeOIDCState": state,
+		},
+		IsError: false,
+	}
+
+	jsonByte, _ := json.Marshal(body)
+	req, err := http.NewRequest("POST", "/api/v1/auth/oidc/test/callback", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/oidc/{provider}/callback' request")
+	}
+	req = withURLParam(req, "provider", "test")
+
+	rr := httptest.NewRecorder()
+	oidcCallbackHandler := http.HandlerFunc(handler.OIDCCallback)
+	oidcCallbackHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 422)
+}
+
+func TestLogin_401_NoPassword(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Email: "random@gmail.com"},
+		IsError:      false,
+	}
+
+	jsonByte, _ := json.Marshal(map[string]string{"email": "random@gmail.com", "password": "random"})
+	req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+	}
+
+	rr := httptest.NewRecorder()
+	loginHandler := http.HandlerFunc(handler.Login)
+	loginHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 401)
+}
@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/passwords"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+)
+
+const (
+	resetTokenTTL = 30 * time.Minute
+	// At most resetLimit reset emails are sent per user within resetWindow, further requests are ignored
+	resetLimit  = 3
+	resetWindow = time.Hour
+)
+
+// passwordChangeRequest -> the current password proves the session's owner is the one changing it
+type passwordChangeRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ForgotPassword -> handles POST /api/v1/auth/forgot-password.
+// The response is the same whether or not the email belongs to a user.
+func (handler *Handler) ForgotPassword(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	forgot := struct {
+		Email string `json:"email"`
+	}{}
+	err = json.Unmarshal(body, &forgot)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if strings.TrimSpace(forgot.Email) == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Email"))
+		return
+	}
+
+	user, err := pgRepo.GetUserByEmail(forgot.Email)
+	if err == nil {
+		err = handler.sendPasswordReset(user)
+	}
+
+	if err != nil && err != storage.ErrUserNotFound {
+		log.Warnf("Couldn't send password reset email: %s", err.Error())
+	}
+
+	log.Infof("Password reset requested.")
+	response.JSON(writer, http.StatusAccepted, map[string]interface{}{})
+}
+
+// sendPasswordReset -> issues a reset token, the email is sent in the background so response times don't tell users apart
+func (handler *Handler) sendPasswordReset(user *model.User) error {
+
+	issued, err := handler.pgRepo.CountUserTokens(user.ID.String(), model.TokenResetPassword, time.Now().Add(-resetWindow))
+	if err != nil {
+		return err
+	}
+
+	if issued >= resetLimit {
+		return errTooManyRequests
+	}
+
+	token, hash, err := auth.NewSignedToken(string(model.TokenResetPassword))
+	if err != nil {
+		return err
+	}
+
+	_, err = handler.pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenResetPassword,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(resetTokenTTL),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	notification := notifier.Notification{
+		UserID:   user.ID,
+		To:       user.Email,
+		Template: notifier.TemplateResetPassword,
+		Data: map[string]interface{}{
+			"FirstName": user.FirstName,
+			"Link":      handler.appURL + "/reset-password?token=" + url.QueryEscape(token),
+			"ExpiresIn": "30 minutes",
+		},
+	}
+
+	go func() {
+		err := handler.notifier.Notify(context.Background(), notification)
+		if err != nil {
+			handler.logger.Warnf("Couldn't send password reset email: %s", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword -> handles POST /api/v1/auth/reset-password, every session of the user ends
+func (handler *Handler) ResetPassword(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	reset := struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}{}
+	err = json.Unmarshal(body, &reset)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if reset.Password == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Password"))
+		return
+	}
+
+	hash, err := auth.VerifySignedToken(string(model.TokenResetPassword), reset.Token)
+	if err != nil {
+		log.Warnf("Rejected reset token with an invalid signature")
+		response.ERROR(writer, http.StatusBadRequest, storage.ErrTokenInvalid)
+		return
+	}
+
+	user, err := pgRepo.GetTokenUser(hash, model.TokenResetPassword)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = passwords.Check(reset.Password, user.Email)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hashedPassword, err := model.Hash(reset.Password)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = pgRepo.ResetUserPassword(hash, string(hashedPassword))
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully reset password.")
+	response.JSON(writer, http.StatusNoContent, "")
+}
+
+// ChangePassword -> handles POST /api/v1/users/{id}/password. Other sessions of the user end,
+// the caller gets tokens of a new one. Wrong current passwords count towards the account's lockout.
+func (handler *Handler) ChangePassword(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	userID := chi.URLParam(request, "id")
+
+	authUserID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != authUserID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	change := passwordChangeRequest{}
+	err = json.Unmarshal(body, &change)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if change.CurrentPassword == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Current Password"))
+		return
+	}
+
+	if change.NewPassword == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required New Password"))
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	if !user.HasPassword() {
+		response.ERROR(writer, http.StatusConflict, errNoPassword)
+		return
+	}
+
+	accountKey := accountThrottleKey(user.Email)
+	lockedUntil, err := pgRepo.LoginLockedUntil([]string{accountKey})
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if lockedUntil.After(time.Now()) {
+		log.Warnf("Refused a password change during a lockout")
+		setRetryAfter(writer, lockedUntil)
+		response.ERROR(writer, http.StatusTooManyRequests, errTooManyRequests)
+		return
+	}
+
+	err = model.VerifyPassword(user.Password, change.CurrentPassword)
+	if err != nil {
+		_, err = pgRepo.RecordLoginFailure(accountKey, model.AccountLockout)
+		if err != nil {
+			response.ERROR(writer, http.StatusInternalServerError, err)
+			return
+		}
+
+		log.Warnf("Rejected a password change with a wrong current password")
+		response.ERROR(writer, http.StatusForbidden, errWrongPassword)
+		return
+	}
+
+	err = passwords.Check(change.NewPassword, user.Email)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	hashedPassword, err := model.Hash(change.NewPassword)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err = pgRepo.ChangeUserPassword(userID, string(hashedPassword), authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditPasswordChanged, user.ID, "", "")
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	tokens, err := handler.newSession(request, user)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully changed password.")
+	response.JSON(writer, http.StatusOK, tokens)
+}
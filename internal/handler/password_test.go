@@ -0,0 +1,388 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestForgotPassword_202(t *testing.T) {
+
+	cases := []*mock.Repository{
+		{
+			ReturnObject: &model.User{Base: model.Base{ID: uuid.NewV4()}, Email: "random@gmail.com"},
+		},
+		{
+			// Unknown emails get the same response
+			ReturnObject: &model.User{Base: model.Base{ID: uuid.NewV4()}, Email: "other@gmail.com"},
+		},
+		{
+			// So do users that already asked too many times
+			ReturnObject:  &model.User{Base: model.Base{ID: uuid.NewV4()}, Email: "random@gmail.com"},
+			ReturnObjects: map[string]interface{}{"CountUserTokens": resetLimit},
+		},
+	}
+
+	for _, repo := range cases {
+		handler.pgRepo = repo
+
+		req, err := http.NewRequest("POST", "/api/v1/auth/forgot-password", bytes.NewBufferString(`{"email": "random@gmail.com"}`))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/forgot-password' request")
+		}
+
+		rr := httptest.NewRecorder()
+		forgotPasswordHandler := http.HandlerFunc(handler.ForgotPassword)
+		forgotPasswordHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 202)
+	}
+}
+
+func TestForgotPassword_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/forgot-password", bytes.NewBufferString(`{"email": " "}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/forgot-password' request")
+	}
+
+	rr := httptest.NewRecorder()
+	forgotPasswordHandler := http.HandlerFunc(handler.ForgotPassword)
+	forgotPasswordHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Required Email")
+}
+
+func TestResetPassword_204(t *testing.T) {
+
+	user := &model.User{Email: "random@gmail.com"}
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: user,
+		IsError:      false,
+	}
+
+	token, _, err := auth.NewSignedToken(string(model.TokenResetPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"token": token, "password": "new password"})
+	req, err := http.NewRequest("POST", "/api/v1/auth/reset-password", bytes.NewBuffer(body))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/reset-password' request")
+	}
+
+	rr := httptest.NewRecorder()
+	resetPasswordHandler := http.HandlerFunc(handler.ResetPassword)
+	resetPasswordHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, model.VerifyPassword(user.Password, "new password"), nil)
+	assert.NotEqual(t, user.SessionsRevokedAt, nil)
+}
+
+func TestResetPassword_400(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	// Verification tokens can't be used to reset a password
+	verifyToken, _, err := auth.NewSignedToken(string(model.TokenVerifyEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"", "forged.token", verifyToken} {
+		body, _ := json.Marshal(map[string]string{"token": token, "password": "new password"})
+		req, err := http.NewRequest("POST", "/api/v1/auth/reset-password", bytes.NewBuffer(body))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/reset-password' request")
+		}
+
+		rr := httptest.NewRecorder()
+		resetPasswordHandler := http.HandlerFunc(handler.ResetPassword)
+		resetPasswordHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 400)
+		assert.Equal(t, responseMap["error"], "Invalid or expired token")
+	}
+}
+
+func TestResetPassword_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/reset-password", bytes.NewBufferString(`{"token": "random"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/reset-password' request")
+	}
+
+	rr := httptest.NewRecorder()
+	resetPasswordHandler := http.HandlerFunc(handler.ResetPassword)
+	resetPasswordHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Required Password")
+}
+
+func TestResetPassword_422_Policy(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Email: "random@gmail.com"},
+		IsError:      false,
+	}
+
+	token, _, err := auth.NewSignedToken(string(model.TokenResetPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		password     string
+		errorMessage string
+	}{
+		{password: "short", errorMessage: "Password must be at least 8 characters"},
+		{password: "my random password", errorMessage: "Password can't contain the email"},
+	}
+
+	for _, c := range cases {
+		body, _ := json.Marshal(map[string]string{"token": token, "password": c.password})
+		req, err := http.NewRequest("POST", "/api/v1/auth/reset-password", bytes.NewBuffer(body))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/reset-password' request")
+		}
+
+		rr := httptest.NewRecorder()
+		resetPasswordHandler := http.HandlerFunc(handler.ResetPassword)
+		resetPasswordHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestChangePassword_200(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com", Password: string(hashedPassword)},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/users/{id}/password", bytes.NewBufferString(`{"current_password": "random", "new_password": "correct horse"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/users/{id}/password' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	changePasswordHandler := http.HandlerFunc(handler.ChangePassword)
+	changePasswordHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.NotEqual(t, responseMap["access_token"], nil)
+	assert.NotEqual(t, responseMap["refresh_token"], nil)
+}
+
+func TestChangePassword_403(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com", Password: string(hashedPassword)},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		actorID      uuid.UUID
+		errorMessage string
+	}{
+		{actorID: uuid.NewV4(), errorMessage: "Forbidden"},
+		{actorID: userID, errorMessage: "Wrong current password"},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/users/{id}/password", bytes.NewBufferString(`{"current_password": "wrong", "new_password": "correct horse"}`))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/users/{id}/password' request")
+		}
+
+		req = authorize(req, c.actorID)
+		req = withURLParam(req, "id", userID.String())
+		rr := httptest.NewRecorder()
+		changePasswordHandler := http.HandlerFunc(handler.ChangePassword)
+		changePasswordHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 403)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestChangePassword_409_NoPassword(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com"},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/users/{id}/password", bytes.NewBufferString(`{"current_password": "random", "new_password": "correct horse"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/users/{id}/password' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	changePasswordHandler := http.HandlerFunc(handler.ChangePassword)
+	changePasswordHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 409)
+}
+
+func TestChangePassword_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"new_password": "correct horse"}`,
+			errorMessage: "Required Current Password",
+		},
+		{
+			inputJSON:    `{"current_password": "random"}`,
+			errorMessage: "Required New Password",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/users/{id}/password", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/users/{id}/password' request")
+		}
+
+		userID := uuid.NewV4()
+		req = authorize(req, userID)
+		req = withURLParam(req, "id", userID.String())
+		rr := httptest.NewRecorder()
+		changePasswordHandler := http.HandlerFunc(handler.ChangePassword)
+		changePasswordHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestChangePassword_422_Policy(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "random@gmail.com", Password: string(hashedPassword)},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/users/{id}/password", bytes.NewBufferString(`{"current_password": "random", "new_password": "short"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/users/{id}/password' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	changePasswordHandler := http.HandlerFunc(handler.ChangePassword)
+	changePasswordHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 422)
+	assert.Equal(t, responseMap["error"], "Password must be at least 8 characters")
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+)
+
+// snoozeRequest -> either a point in time or a number of minutes from now
+type snoozeRequest struct {
+	Until   time.Time `json:"until"`
+	Minutes int       `json:"minutes"`
+}
+
+// CreateReminder -> handles POST /api/v1/applications/{id}/reminders
+func (handler *Handler) CreateReminder(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		log.Warnf("Couldn't read request body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	reminder := model.Reminder{}
+	err = json.Unmarshal(body, &reminder)
+	if err != nil {
+		log.Warnf("Couldn't marshal JSON body")
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	reminder.ID = uuid.Nil
+	reminder.ApplicationID = uuid.FromStringOrNil(applicationID)
+	reminder.UserID = uuid.FromStringOrNil(userID)
+
+	err = reminder.Validate("create")
+	if err != nil {
+		log.Warnf(err.Error())
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	reminderCreated, err := pgRepo.CreateReminder(reminder)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully created reminder.")
+	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, reminderCreated.ID.String()))
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"reminder": reminderCreated})
+}
+
+// GetAllReminders -> handles GET /api/v1/applications/{id}/reminders
+func (handler *Handler) GetAllReminders(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	reminders, err := pgRepo.AllReminders(applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved all reminders")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"reminders": reminders})
+}
+
+// GetActiveReminders -> handles GET /api/v1/reminders, the reminders of the user that weren't dismissed
+func (handler *Handler) GetActiveReminders(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	reminders, err := pgRepo.ActiveReminders(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved active reminders")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"reminders": reminders})
+}
+
+// SnoozeReminder -> handles POST /api/v1/applications/{id}/reminders/{reminderID}/snooze
+func (handler *Handler) SnoozeReminder(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	reminderID := chi.URLParam(request, "reminderID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	snooze := snoozeRequest{}
+	err = json.Unmarshal(body, &snooze)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	now := time.Now()
+	if snooze.Until.IsZero() && snooze.Minutes > 0 {
+		snooze.Until = now.Add(time.Duration(snooze.Minutes) * time.Minute)
+	}
+
+	if snooze.Until.IsZero() {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Until"))
+		return
+	}
+
+	if !snooze.Until.After(now) {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Invalid Until"))
+		return
+	}
+
+	reminder, err := pgRepo.SnoozeReminder(reminderID, applicationID, userID, snooze.Until)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully snoozed the reminder")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"reminder": reminder})
+}
+
+// DismissReminder -> handles POST /api/v1/applications/{id}/reminders/{reminderID}/dismiss
+func (handler *Handler) DismissReminder(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	applicationID := chi.URLParam(request, "id")
+	reminderID := chi.URLParam(request, "reminderID")
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	reminder, err := pgRepo.DismissReminder(reminderID, applicationID, userID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully dismissed the reminder")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"reminder": reminder})
+}
@@ -0,0 +1,244 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateReminder_201(t *testing.T) {
+
+	userID := uuid.NewV4()
+	reminderToCreate := model.Reminder{
+		DueAt:   time.Date(2020, time.September, 1, 9, 0, 0, 0, time.UTC),
+		Message: "Follow up with the recruiter",
+		Repeat:  model.RepeatWeekly,
+		UserID:  userID,
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &reminderToCreate,
+		IsError:      false,
+	}
+
+	jsonByte, err := json.Marshal(reminderToCreate)
+	if err != nil {
+		t.Error("Failed to marshal Reminder struct")
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/reminders", bytes.NewBuffer(jsonByte))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/reminders' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	createReminderHandler := http.HandlerFunc(handler.CreateReminder)
+	createReminderHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	createdReminder := responseMap["reminder"].(map[string]interface{})
+
+	assert.Equal(t, rr.Code, 201)
+	assert.Equal(t, createdReminder["message"], reminderToCreate.Message)
+	assert.Equal(t, createdReminder["repeat"], "weekly")
+}
+
+func TestCreateReminder_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Reminder{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"message": "Follow up"}`,
+			errorMessage: "Required Due At",
+		},
+		{
+			inputJSON:    `{"due_at": "2020-09-01T09:00:00Z"}`,
+			errorMessage: "Required Message",
+		},
+		{
+			inputJSON:    `{"due_at": "2020-09-01T09:00:00Z", "message": "Follow up", "repeat": "hourly"}`,
+			errorMessage: "Invalid Repeat",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/applications/reminders", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/applications/{id}/reminders' request")
+		}
+
+		req = authorize(req, uuid.NewV4())
+		req = withURLParam(req, "id", uuid.NewV4().String())
+		rr := httptest.NewRecorder()
+		createReminderHandler := http.HandlerFunc(handler.CreateReminder)
+		createReminderHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestGetActiveReminders_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.Reminder{
+			{Message: "Follow up", UserID: userID},
+			{Message: "Someone else's", UserID: uuid.NewV4()},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/reminders", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/reminders' request")
+	}
+
+	req = authorize(req, userID)
+	rr := httptest.NewRecorder()
+	getRemindersHandler := http.HandlerFunc(handler.GetActiveReminders)
+	getRemindersHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["reminders"].([]interface{})), 1)
+}
+
+func TestSnoozeReminder_200(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Reminder{Message: "Follow up", UserID: userID},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/reminders/snooze", bytes.NewBufferString(`{"minutes": 60}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/reminders/{reminderID}/snooze' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "reminderID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	snoozeReminderHandler := http.HandlerFunc(handler.SnoozeReminder)
+	snoozeReminderHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	dueAt, err := time.Parse(time.RFC3339, responseMap["reminder"].(map[string]interface{})["due_at"].(string))
+	if err != nil {
+		t.Error("Failed to parse due_at")
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, dueAt.After(time.Now().Add(59*time.Minute)), true)
+}
+
+func TestSnoozeReminder_422_Validation(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Reminder{UserID: userID},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{}`,
+			errorMessage: "Required Until",
+		},
+		{
+			inputJSON:    `{"until": "2020-09-01T09:00:00Z"}`,
+			errorMessage: "Invalid Until",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("POST", "/api/v1/applications/reminders/snooze", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/applications/{id}/reminders/{reminderID}/snooze' request")
+		}
+
+		req = authorize(req, userID)
+		req = withURLParam(req, "id", uuid.NewV4().String())
+		req = withURLParam(req, "reminderID", uuid.NewV4().String())
+		rr := httptest.NewRecorder()
+		snoozeReminderHandler := http.HandlerFunc(handler.SnoozeReminder)
+		snoozeReminderHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
+
+func TestDismissReminder_404(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.Reminder{UserID: uuid.NewV4()},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/applications/reminders/dismiss", nil)
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/applications/{id}/reminders/{reminderID}/dismiss' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	req = withURLParam(req, "reminderID", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	dismissReminderHandler := http.HandlerFunc(handler.DismissReminder)
+	dismissReminderHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 404)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+// trashItemResponse -> deleted item along with when it's purged for good
+type trashItemResponse struct {
+	model.TrashItem
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// GetTrash -> handles GET /api/v1/trash, everything the user deleted and can still restore
+func (handler *Handler) GetTrash(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+
+	userID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	items, err := pgRepo.Trash(userID)
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses := make([]trashItemResponse, 0, len(*items))
+	for _, item := range *items {
+		responses = append(responses, trashItemResponse{TrashItem: item, PurgeAt: item.DeletedAt.Add(handler.trashRetention)})
+	}
+
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+	log.Infof("Successfully retrieved the trash")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"trash": responses})
+}
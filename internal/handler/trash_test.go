@@ -0,0 +1,78 @@
+// +build !integration
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestGetTrash_200(t *testing.T) {
+
+	deletedAt := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.TrashItem{
+			{Kind: model.TrashApplication, ID: uuid.NewV4(), Title: "Software Engineer at GoCardless", DeletedAt: deletedAt},
+		},
+		IsError: false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/trash", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/trash' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	rr := httptest.NewRecorder()
+	getTrashHandler := http.HandlerFunc(handler.GetTrash)
+	getTrashHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string][]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, len(responseMap["trash"]), 1)
+	assert.Equal(t, responseMap["trash"][0]["kind"], "application")
+	assert.Equal(t, responseMap["trash"][0]["title"], "Software Engineer at GoCardless")
+	assert.Equal(t, responseMap["trash"][0]["purge_at"], deletedAt.Add(model.DefaultTrashRetention).Format(time.RFC3339))
+}
+
+func TestGetTrash_500(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &[]model.TrashItem{},
+		IsError:      true,
+		ErrorMessage: "Table 'applications' doesn't exist",
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/trash", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/trash' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	rr := httptest.NewRecorder()
+	getTrashHandler := http.HandlerFunc(handler.GetTrash)
+	getTrashHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 500)
+	assert.Equal(t, responseMap["error"], "Table 'applications' doesn't exist")
+}
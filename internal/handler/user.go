@@ -5,13 +5,75 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
 	"github.com/amaraliou/trackr-core/internal/response"
 	"github.com/amaraliou/trackr-core/pkg/logger"
 	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
 )
 
+// userRequest -> fields users give about themselves, the server decides everything else
+type userRequest struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// user -> model of the request, to validate and store
+func (body *userRequest) user() model.User {
+	return model.User{
+		Email:     body.Email,
+		Password:  body.Password,
+		FirstName: body.FirstName,
+		LastName:  body.LastName,
+	}
+}
+
+// userResponse -> user as the API returns it, passwords, secrets and deletion never leave the server
+type userResponse struct {
+	ID            uuid.UUID  `json:"ID"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"update_at"`
+	Email         string     `json:"email"`
+	IsVerified    bool       `json:"is_verified"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Role          model.Role `json:"role"`
+	DisabledAt    *time.Time `json:"disabled_at"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at"`
+	DeletionDueAt *time.Time `json:"deletion_due_at"`
+}
+
+// newUserResponse ...
+func newUserResponse(user *model.User) *userResponse {
+	return &userResponse{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		IsVerified:    user.IsVerified,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Role:          user.Role,
+		DisabledAt:    user.DisabledAt,
+		TOTPEnabledAt: user.TOTPEnabledAt,
+		DeletionDueAt: user.DeletionDueAt,
+	}
+}
+
+// newUserResponses ...
+func newUserResponses(users []model.User) []*userResponse {
+	responses := make([]*userResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, newUserResponse(&users[i]))
+	}
+	return responses
+}
+
 // CreateUser ...
 func (handler *Handler) CreateUser(writer http.ResponseWriter, request *http.Request) {
 
@@ -30,14 +92,18 @@ func (handler *Handler) CreateUser(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	user := model.User{}
-	err = json.Unmarshal(body, &user)
+	userBody := userRequest{}
+	err = json.Unmarshal(body, &userBody)
 	if err != nil {
 		log.Warnf("Couldn't marshal JSON body")
 		response.ERROR(writer, http.StatusUnprocessableEntity, err)
 		return
 	}
 
+	// Only a verification token can verify the email, and only admins grant roles
+	user := userBody.user()
+	user.Role = model.RoleUser
+
 	err = user.Validate("create")
 	if err != nil {
 		log.Warnf(err.Error())
@@ -51,19 +117,22 @@ func (handler *Handler) CreateUser(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
+	err = handler.sendVerification(request.Context(), userCreated)
+	if err != nil {
+		log.Warnf("Couldn't send verification email: %s", err.Error())
+	}
+
 	log.Infof("Successfully created user.")
 	writer.Header().Set("Location", fmt.Sprintf("%s%s/%s", request.Host, request.RequestURI, userCreated.ID.String()))
-	response.JSON(writer, http.StatusCreated, map[string]interface{}{"user": userCreated})
+	response.JSON(writer, http.StatusCreated, map[string]interface{}{"user": newUserResponse(userCreated)})
 }
 
-// GetAllUsers ...
+// GetAllUsers -> routed behind middleware.RequireRole for admins
 func (handler *Handler) GetAllUsers(writer http.ResponseWriter, request *http.Request) {
 
 	pgRepo := handler.pgRepo
 	log := handler.logger
 
-	// Check role/token
-
 	users, err := pgRepo.AllUsers()
 	if err != nil {
 		response.ERROR(writer, http.StatusInternalServerError, err)
@@ -76,7 +145,7 @@ func (handler *Handler) GetAllUsers(writer http.ResponseWriter, request *http.Re
 		"path":   request.URL.Path,
 	})
 	log.Infof("Successfully retrieved all users")
-	response.JSON(writer, http.StatusOK, map[string]interface{}{"users": users})
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"users": newUserResponses(*users)})
 }
 
 // GetUser ...
@@ -85,12 +154,22 @@ func (handler *Handler) GetUser(writer http.ResponseWriter, request *http.Reques
 	pgRepo := handler.pgRepo
 	log := handler.logger
 
-	// Check role/token
 	userID := chi.URLParam(request, "id")
 
-	user, err := pgRepo.GetUser(userID)
+	authUserID, err := requestUserID(request)
 	if err != nil {
-		response.ERROR(writer, http.StatusInternalServerError, err)
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != authUserID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	user, err := pgRepo.GetUser(userID, authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
 		return
 	}
 
@@ -100,34 +179,65 @@ func (handler *Handler) GetUser(writer http.ResponseWriter, request *http.Reques
 		"path":   request.URL.Path,
 	})
 	log.Infof("Successfully retrieved the user")
-	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": user})
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user)})
 }
 
-// UpdateUser ...
+// UpdateUser -> handles PATCH and, for older clients, PUT. Only the given profile fields are updated,
+// a changed email has to be verified again and the password is changed by ChangePassword.
 func (handler *Handler) UpdateUser(writer http.ResponseWriter, request *http.Request) {
 
 	pgRepo := handler.pgRepo
 	log := handler.logger
 
-	// Check role/token
 	userID := chi.URLParam(request, "id")
 
+	authUserID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != authUserID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
 	body, err := ioutil.ReadAll(request.Body)
 	if err != nil {
 		response.ERROR(writer, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	user := model.User{}
-	err = json.Unmarshal(body, &user)
+	userBody := userRequest{}
+	err = json.Unmarshal(body, &userBody)
 	if err != nil {
 		response.ERROR(writer, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	updatedUser, err := pgRepo.UpdateUser(user, userID)
+	if userBody.Password != "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errPasswordChange)
+		return
+	}
+
+	// Identity, verification and permissions can't be changed through the body
+	user := userBody.user()
+
+	err = user.Validate("update")
 	if err != nil {
-		response.ERROR(writer, http.StatusInternalServerError, err)
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	current, err := pgRepo.GetUser(userID, authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	updatedUser, err := pgRepo.UpdateUser(user, userID, authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
 		return
 	}
 
@@ -136,31 +246,69 @@ func (handler *Handler) UpdateUser(writer http.ResponseWriter, request *http.Req
 		"host":   request.Host,
 		"path":   request.URL.Path,
 	})
+
+	if updatedUser.Email != current.Email {
+		err = handler.sendVerification(request.Context(), updatedUser)
+		if err != nil {
+			log.Warnf("Couldn't send verification email: %s", err.Error())
+		}
+	}
+
 	log.Infof("Successfully updated the user")
-	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": updatedUser})
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(updatedUser)})
 }
 
-// DeleteUser ...
+// DeleteUser -> schedules the deletion of the account after the grace period and signs the user out everywhere.
+// Signing in again before it's due keeps the account, see newSession.
 func (handler *Handler) DeleteUser(writer http.ResponseWriter, request *http.Request) {
 
 	pgRepo := handler.pgRepo
 	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
 
-	// Check role/token
 	userID := chi.URLParam(request, "id")
 
-	_, err := pgRepo.DeleteUser(userID)
+	authUserID, err := requestUserID(request)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != authUserID {
+		response.ERROR(writer, http.StatusForbidden, errForbidden)
+		return
+	}
+
+	user, err := pgRepo.ScheduleUserDeletion(userID, time.Now().Add(handler.deletionGracePeriod), authUserID)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	err = handler.audit(request, model.AuditDeletionRequested, user.ID, "", "")
 	if err != nil {
 		response.ERROR(writer, http.StatusInternalServerError, err)
 		return
 	}
 
-	log = log.WithFields(logger.Fields{
-		"method": request.Method,
-		"host":   request.Host,
-		"path":   request.URL.Path,
+	err = handler.notifier.Notify(request.Context(), notifier.Notification{
+		UserID:   user.ID,
+		To:       user.Email,
+		Template: notifier.TemplateAccountDeletion,
+		Data: map[string]interface{}{
+			"FirstName": user.FirstName,
+			"DeleteOn":  user.DeletionDueAt.UTC().Format("January 2, 2006 at 15:04 MST"),
+		},
 	})
-	log.Infof("Successfully deleted the user")
+	if err != nil {
+		log.Warnf("Couldn't send deletion email: %s", err.Error())
+	}
+
+	log.Infof("Successfully scheduled the deletion of the user")
 	writer.Header().Set("Entity", userID)
-	response.JSON(writer, http.StatusNoContent, "")
+	response.JSON(writer, http.StatusAccepted, map[string]interface{}{"user": newUserResponse(user)})
 }
@@ -4,15 +4,18 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
 	"github.com/amaraliou/trackr-core/internal/storage/mock"
-	"github.com/gorilla/mux"
 	uuid "github.com/satori/go.uuid"
 	"gopkg.in/go-playground/assert.v1"
 )
@@ -21,7 +24,7 @@ func TestCreateUser_201(t *testing.T) {
 
 	userToCreate := model.User{
 		Email:     "random@gmail.com",
-		Password:  "random",
+		Password:  "correct horse",
 		FirstName: "John",
 		LastName:  "Doe",
 	}
@@ -85,7 +88,7 @@ func TestCreateUser_422_JSON(t *testing.T) {
 	}
 
 	assert.Equal(t, rr.Code, 422)
-	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type model.User")
+	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type handler.userRequest")
 }
 
 func TestCreateUser_422_Validation(t *testing.T) {
@@ -100,7 +103,7 @@ func TestCreateUser_422_Validation(t *testing.T) {
 		errorMessage string
 	}{
 		{
-			inputJSON:    `{"password": "random"}`,
+			inputJSON:    `{"password": "correct horse"}`,
 			errorMessage: "Required Email",
 		},
 		{
@@ -108,15 +111,15 @@ func TestCreateUser_422_Validation(t *testing.T) {
 			errorMessage: "Required Password",
 		},
 		{
-			inputJSON:    `{"email": "randomgmail.com", "password": "random"}`,
+			inputJSON:    `{"email": "randomgmail.com", "password": "correct horse"}`,
 			errorMessage: "Invalid Email",
 		},
 		{
-			inputJSON:    `{"email": "random@gmail.com", "password": "random"}`,
+			inputJSON:    `{"email": "random@gmail.com", "password": "correct horse"}`,
 			errorMessage: "Required First Name",
 		},
 		{
-			inputJSON:    `{"email": "random@gmail.com", "password": "random", "first_name": "John"}`,
+			inputJSON:    `{"email": "random@gmail.com", "password": "correct horse", "first_name": "John"}`,
 			errorMessage: "Required Last Name",
 		},
 	}
@@ -146,7 +149,7 @@ func TestCreateUser_500(t *testing.T) {
 
 	userToCreate := model.User{
 		Email:     "random@gmail.com",
-		Password:  "random",
+		Password:  "correct horse",
 		FirstName: "John",
 		LastName:  "Doe",
 	}
@@ -186,13 +189,13 @@ func TestGetAllUsers_200(t *testing.T) {
 	usersToGet := []model.User{
 		{
 			Email:     "john@gmail.com",
-			Password:  "random",
+			Password:  "correct horse",
 			FirstName: "John",
 			LastName:  "Doe",
 		},
 		{
 			Email:     "mario@gmail.com",
-			Password:  "random",
+			Password:  "correct horse",
 			FirstName: "Mario",
 			LastName:  "Draghi",
 		},
@@ -254,7 +257,7 @@ func TestGetUser_200(t *testing.T) {
 
 	userToGet := model.User{
 		Email:     "john@gmail.com",
-		Password:  "random",
+		Password:  "correct horse",
 		FirstName: "John",
 		LastName:  "Doe",
 	}
@@ -270,7 +273,8 @@ func TestGetUser_200(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	getUserHandler := http.HandlerFunc(handler.GetUser)
 	getUserHandler.ServeHTTP(rr, req)
@@ -286,6 +290,54 @@ func TestGetUser_200(t *testing.T) {
 	assert.Equal(t, user["first_name"], userToGet.FirstName)
 }
 
+func TestGetUser_401(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/users", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/users/{id}' request")
+	}
+
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getUserHandler := http.HandlerFunc(handler.GetUser)
+	getUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 401)
+}
+
+func TestGetUser_403(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/users", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/users/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	getUserHandler := http.HandlerFunc(handler.GetUser)
+	getUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 403)
+	assert.Equal(t, responseMap["error"], "Forbidden")
+}
+
 func TestGetUser_500(t *testing.T) {
 
 	handler.pgRepo = &mock.Repository{
@@ -300,7 +352,8 @@ func TestGetUser_500(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	getUserHandler := http.HandlerFunc(handler.GetUser)
 	getUserHandler.ServeHTTP(rr, req)
@@ -324,7 +377,7 @@ func TestUpdateUser_200(t *testing.T) {
 
 	updatedUser := model.User{
 		Email:     "john@gmail.com",
-		Password:  "random",
+		Password:  "correct horse",
 		FirstName: "Mario",
 		LastName:  "Draghi",
 	}
@@ -345,7 +398,8 @@ func TestUpdateUser_200(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	updateUserHandler := http.HandlerFunc(handler.UpdateUser)
 	updateUserHandler.ServeHTTP(rr, req)
@@ -361,6 +415,27 @@ func TestUpdateUser_200(t *testing.T) {
 	assert.Equal(t, user["first_name"], updatedUser.FirstName)
 }
 
+func TestUpdateUser_403(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("PUT", "/api/v1/users", bytes.NewBufferString(`{"first_name": "Mario"}`))
+	if err != nil {
+		t.Error("Failed to create 'PUT: /api/v1/users/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
+	rr := httptest.NewRecorder()
+	updateUserHandler := http.HandlerFunc(handler.UpdateUser)
+	updateUserHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 403)
+}
+
 func TestUpdateUser_422(t *testing.T) {
 
 	handler.pgRepo = &mock.Repository{
@@ -379,7 +454,8 @@ func TestUpdateUser_422(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	updateUserHandler := http.HandlerFunc(handler.UpdateUser)
 	updateUserHandler.ServeHTTP(rr, req)
@@ -391,7 +467,7 @@ func TestUpdateUser_422(t *testing.T) {
 	}
 
 	assert.Equal(t, rr.Code, 422)
-	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type model.User")
+	assert.Equal(t, responseMap["error"], "json: cannot unmarshal string into Go value of type handler.userRequest")
 }
 
 func TestUpdateUser_500(t *testing.T) {
@@ -418,7 +494,8 @@ func TestUpdateUser_500(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	updateUserHandler := http.HandlerFunc(handler.UpdateUser)
 	updateUserHandler.ServeHTTP(rr, req)
@@ -433,31 +510,73 @@ func TestUpdateUser_500(t *testing.T) {
 	assert.Equal(t, responseMap["error"], "User not found")
 }
 
-func TestDeleteUser_204(t *testing.T) {
+func TestDeleteUser_202(t *testing.T) {
 
+	userID := uuid.NewV4()
 	handler.pgRepo = &mock.Repository{
-		ReturnObject: int64(1),
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "john@gmail.com", FirstName: "John"},
 		IsError:      false,
 	}
 
+	recorder := &recordingNotifier{}
+	previous := handler.notifier
+	handler.notifier = recorder
+	defer func() { handler.notifier = previous }()
+
 	req, err := http.NewRequest("DELETE", "/api/v1/users", nil)
 	if err != nil {
 		t.Error("Failed to create 'DELETE: /api/v1/users/{id}' request")
 	}
 
-	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	deleteUserHandler := http.HandlerFunc(handler.DeleteUser)
+	deleteUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 202)
+	assert.NotEqual(t, responseMap["user"]["deletion_due_at"], nil)
+
+	dueAt, err := time.Parse(time.RFC3339, responseMap["user"]["deletion_due_at"].(string))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, dueAt.After(time.Now().Add(model.DefaultDeletionGracePeriod-time.Minute)), true)
+
+	assert.Equal(t, len(recorder.notifications), 1)
+	assert.Equal(t, recorder.notifications[0].Template, notifier.TemplateAccountDeletion)
+	assert.Equal(t, recorder.notifications[0].To, "john@gmail.com")
+}
+
+func TestDeleteUser_403(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	req, err := http.NewRequest("DELETE", "/api/v1/users", nil)
+	if err != nil {
+		t.Error("Failed to create 'DELETE: /api/v1/users/{id}' request")
+	}
+
+	req = authorize(req, uuid.NewV4())
+	req = withURLParam(req, "id", uuid.NewV4().String())
 	rr := httptest.NewRecorder()
 	deleteUserHandler := http.HandlerFunc(handler.DeleteUser)
 	deleteUserHandler.ServeHTTP(rr, req)
 
-	assert.Equal(t, rr.Code, 204)
+	assert.Equal(t, rr.Code, 403)
 }
 
 func TestDeleteUser_500(t *testing.T) {
 
 	handler.pgRepo = &mock.Repository{
-		ReturnObject: int64(0),
+		ReturnObject: &model.User{},
 		IsError:      true,
 		ErrorMessage: "User not found",
 	}
@@ -468,7 +587,8 @@ func TestDeleteUser_500(t *testing.T) {
 	}
 
 	userID := uuid.NewV4()
-	req = mux.SetURLVars(req, map[string]string{"id": userID.String()})
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
 	rr := httptest.NewRecorder()
 	deleteUserHandler := http.HandlerFunc(handler.DeleteUser)
 	deleteUserHandler.ServeHTTP(rr, req)
@@ -482,3 +602,161 @@ func TestDeleteUser_500(t *testing.T) {
 	assert.Equal(t, rr.Code, 500)
 	assert.Equal(t, responseMap["error"], "User not found")
 }
+
+func TestUserResponses_NoPassword(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletedAt := time.Now()
+	userToReturn := model.User{
+		Base:      model.Base{ID: uuid.NewV4(), DeletedAt: &deletedAt},
+		Email:     "john@gmail.com",
+		Password:  string(hashedPassword),
+		FirstName: "John",
+		LastName:  "Doe",
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &userToReturn,
+		ReturnObjects: map[string]interface{}{
+			"AllUsers": &[]model.User{userToReturn},
+		},
+		IsError: false,
+	}
+
+	userID := userToReturn.ID
+	createBody := `{"email": "john@gmail.com", "password": "correct horse", "first_name": "John", "last_name": "Doe"}`
+
+	cases := []struct {
+		method      string
+		body        string
+		handlerFunc http.HandlerFunc
+		actorID     uuid.UUID
+	}{
+		{method: "POST", body: createBody, handlerFunc: handler.CreateUser},
+		{method: "GET", handlerFunc: handler.GetAllUsers, actorID: userID},
+		{method: "GET", handlerFunc: handler.GetUser, actorID: userID},
+		{method: "PATCH", body: `{"first_name": "John"}`, handlerFunc: handler.UpdateUser, actorID: userID},
+		{method: "POST", handlerFunc: handler.DisableUser, actorID: uuid.NewV4()},
+		{method: "POST", handlerFunc: handler.EnableUser, actorID: uuid.NewV4()},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, "/api/v1/users", bytes.NewBufferString(c.body))
+		if err != nil {
+			t.Error("Failed to create request")
+		}
+
+		if c.actorID != uuid.Nil {
+			req = authorize(req, c.actorID)
+		}
+		req = withURLParam(req, "id", userID.String())
+
+		rr := httptest.NewRecorder()
+		c.handlerFunc.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code < 300, true)
+		assert.Equal(t, strings.Contains(rr.Body.String(), userToReturn.Password), false)
+		assert.Equal(t, strings.Contains(rr.Body.String(), `"password"`), false)
+		assert.Equal(t, strings.Contains(rr.Body.String(), `"deleted_at"`), false)
+	}
+}
+
+// recordingNotifier keeps the notifications instead of sending them
+type recordingNotifier struct {
+	notifications []notifier.Notification
+}
+
+func (recorder *recordingNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	recorder.notifications = append(recorder.notifications, notification)
+	return nil
+}
+
+func TestUpdateUser_200_EmailChanged(t *testing.T) {
+
+	userID := uuid.NewV4()
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Base: model.Base{ID: userID}, Email: "john@gmail.com", IsVerified: true},
+		ReturnObjects: map[string]interface{}{
+			"UpdateUser": &model.User{Base: model.Base{ID: userID}, Email: "mario@gmail.com", IsVerified: false},
+		},
+		IsError: false,
+	}
+
+	recorder := &recordingNotifier{}
+	previous := handler.notifier
+	handler.notifier = recorder
+	defer func() { handler.notifier = previous }()
+
+	req, err := http.NewRequest("PATCH", "/api/v1/users", bytes.NewBufferString(`{"email": "mario@gmail.com"}`))
+	if err != nil {
+		t.Error("Failed to create 'PATCH: /api/v1/users/{id}' request")
+	}
+
+	req = authorize(req, userID)
+	req = withURLParam(req, "id", userID.String())
+	rr := httptest.NewRecorder()
+	updateUserHandler := http.HandlerFunc(handler.UpdateUser)
+	updateUserHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	user := responseMap["user"].(map[string]interface{})
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, user["is_verified"], false)
+	assert.Equal(t, len(recorder.notifications), 1)
+	assert.Equal(t, recorder.notifications[0].To, "mario@gmail.com")
+	assert.Equal(t, recorder.notifications[0].Template, notifier.TemplateVerifyEmail)
+}
+
+func TestUpdateUser_422_Validation(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	cases := []struct {
+		inputJSON    string
+		errorMessage string
+	}{
+		{
+			inputJSON:    `{"email": "randomgmail.com"}`,
+			errorMessage: "Invalid Email",
+		},
+		{
+			inputJSON:    `{"first_name": "John", "password": "correct horse"}`,
+			errorMessage: "Password can only be changed along with the current one",
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("PATCH", "/api/v1/users", bytes.NewBufferString(c.inputJSON))
+		if err != nil {
+			t.Error("Failed to create 'PATCH: /api/v1/users/{id}' request")
+		}
+
+		userID := uuid.NewV4()
+		req = authorize(req, userID)
+		req = withURLParam(req, "id", userID.String())
+		rr := httptest.NewRecorder()
+		updateUserHandler := http.HandlerFunc(handler.UpdateUser)
+		updateUserHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 422)
+		assert.Equal(t, responseMap["error"], c.errorMessage)
+	}
+}
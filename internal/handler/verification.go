@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
+	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+const (
+	verificationTokenTTL = 24 * time.Hour
+	// At most verificationResendLimit tokens are issued per user within verificationResendWindow
+	verificationResendLimit  = 3
+	verificationResendWindow = time.Hour
+)
+
+// sendVerification -> issues a verification token and emails its link to the user
+func (handler *Handler) sendVerification(ctx context.Context, user *model.User) error {
+
+	token, hash, err := auth.NewSignedToken(string(model.TokenVerifyEmail))
+	if err != nil {
+		return err
+	}
+
+	_, err = handler.pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenVerifyEmail,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(verificationTokenTTL),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return handler.notifier.Notify(ctx, notifier.Notification{
+		UserID:   user.ID,
+		To:       user.Email,
+		Template: notifier.TemplateVerifyEmail,
+		Data: map[string]interface{}{
+			"FirstName": user.FirstName,
+			"Link":      handler.appURL + "/api/v1/auth/verify?token=" + url.QueryEscape(token),
+			"ExpiresIn": "24 hours",
+		},
+	})
+}
+
+// VerifyEmail -> handles GET /api/v1/auth/verify?token=
+func (handler *Handler) VerifyEmail(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	hash, err := auth.VerifySignedToken(string(model.TokenVerifyEmail), request.URL.Query().Get("token"))
+	if err != nil {
+		log.Warnf("Rejected verification token with an invalid signature")
+		response.ERROR(writer, http.StatusBadRequest, storage.ErrTokenInvalid)
+		return
+	}
+
+	user, err := pgRepo.VerifyUserEmail(hash)
+	if err != nil {
+		response.ERROR(writer, errorStatus(err), err)
+		return
+	}
+
+	log.Infof("Successfully verified email.")
+	response.JSON(writer, http.StatusOK, map[string]interface{}{"user": newUserResponse(user)})
+}
+
+// ResendVerification -> handles POST /api/v1/auth/verify/resend, accepted whether or not the email is known
+func (handler *Handler) ResendVerification(writer http.ResponseWriter, request *http.Request) {
+
+	pgRepo := handler.pgRepo
+	log := handler.logger
+	log = log.WithFields(logger.Fields{
+		"method": request.Method,
+		"host":   request.Host,
+		"path":   request.URL.Path,
+	})
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	resend := struct {
+		Email string `json:"email"`
+	}{}
+	err = json.Unmarshal(body, &resend)
+	if err != nil {
+		response.ERROR(writer, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if strings.TrimSpace(resend.Email) == "" {
+		response.ERROR(writer, http.StatusUnprocessableEntity, errors.New("Required Email"))
+		return
+	}
+
+	user, err := pgRepo.GetUserByEmail(resend.Email)
+	if err == storage.ErrUserNotFound || (err == nil && user.IsVerified) {
+		response.JSON(writer, http.StatusAccepted, map[string]interface{}{})
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	issued, err := pgRepo.CountUserTokens(user.ID.String(), model.TokenVerifyEmail, time.Now().Add(-verificationResendWindow))
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if issued >= verificationResendLimit {
+		log.Warnf("Verification resend limit reached for user %s", user.ID.String())
+		writer.Header().Set("Retry-After", "3600")
+		response.ERROR(writer, http.StatusTooManyRequests, errTooManyRequests)
+		return
+	}
+
+	err = handler.sendVerification(request.Context(), user)
+	if err != nil {
+		log.Warnf("Couldn't send verification email: %s", err.Error())
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Infof("Successfully resent verification email.")
+	response.JSON(writer, http.StatusAccepted, map[string]interface{}{})
+}
@@ -0,0 +1,170 @@
+// +build !integration
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestVerifyEmail_200(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{Email: "random@gmail.com"},
+		IsError:      false,
+	}
+
+	token, _, err := auth.NewSignedToken(string(model.TokenVerifyEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/auth/verify?token="+url.QueryEscape(token), nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/auth/verify' request")
+	}
+
+	rr := httptest.NewRecorder()
+	verifyEmailHandler := http.HandlerFunc(handler.VerifyEmail)
+	verifyEmailHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, responseMap["user"].(map[string]interface{})["is_verified"], true)
+}
+
+func TestVerifyEmail_400(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{},
+		IsError:      false,
+	}
+
+	// Tokens signed for another purpose are rejected too
+	otherToken, _, err := auth.NewSignedToken("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"", "forged.token", otherToken} {
+		req, err := http.NewRequest("GET", "/api/v1/auth/verify?token="+url.QueryEscape(token), nil)
+		if err != nil {
+			t.Error("Failed to create 'GET: /api/v1/auth/verify' request")
+		}
+
+		rr := httptest.NewRecorder()
+		verifyEmailHandler := http.HandlerFunc(handler.VerifyEmail)
+		verifyEmailHandler.ServeHTTP(rr, req)
+
+		responseMap := make(map[string]interface{})
+		err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+		if err != nil {
+			fmt.Printf("Cannot convert to json: %v", err)
+		}
+
+		assert.Equal(t, rr.Code, 400)
+		assert.Equal(t, responseMap["error"], "Invalid or expired token")
+	}
+}
+
+func TestResendVerification_202(t *testing.T) {
+
+	cases := []*mock.Repository{
+		{
+			ReturnObject: &model.User{Email: "random@gmail.com", IsVerified: true},
+		},
+		{
+			ReturnObject:  &model.User{Email: "random@gmail.com"},
+			ReturnObjects: map[string]interface{}{"CountUserTokens": 1},
+		},
+	}
+
+	for _, repo := range cases {
+		handler.pgRepo = repo
+
+		req, err := http.NewRequest("POST", "/api/v1/auth/verify/resend", bytes.NewBufferString(`{"email": "random@gmail.com"}`))
+		if err != nil {
+			t.Error("Failed to create 'POST: /api/v1/auth/verify/resend' request")
+		}
+
+		rr := httptest.NewRecorder()
+		resendHandler := http.HandlerFunc(handler.ResendVerification)
+		resendHandler.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 202)
+	}
+}
+
+func TestResendVerification_429(t *testing.T) {
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject:  &model.User{Email: "random@gmail.com"},
+		ReturnObjects: map[string]interface{}{"CountUserTokens": verificationResendLimit},
+	}
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/verify/resend", bytes.NewBufferString(`{"email": "random@gmail.com"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/verify/resend' request")
+	}
+
+	rr := httptest.NewRecorder()
+	resendHandler := http.HandlerFunc(handler.ResendVerification)
+	resendHandler.ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 429)
+	assert.Equal(t, rr.Header().Get("Retry-After"), "3600")
+}
+
+func TestLogin_403_Unverified(t *testing.T) {
+
+	hashedPassword, err := model.Hash("random")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.pgRepo = &mock.Repository{
+		ReturnObject: &model.User{
+			Base:     model.Base{ID: uuid.NewV4()},
+			Email:    "random@gmail.com",
+			Password: string(hashedPassword),
+		},
+		IsError: false,
+	}
+
+	handler.requireVerifiedEmail = true
+	defer func() { handler.requireVerifiedEmail = false }()
+
+	req, err := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBufferString(`{"email": "random@gmail.com", "password": "random"}`))
+	if err != nil {
+		t.Error("Failed to create 'POST: /api/v1/auth/login' request")
+	}
+
+	rr := httptest.NewRecorder()
+	loginHandler := http.HandlerFunc(handler.Login)
+	loginHandler.ServeHTTP(rr, req)
+
+	responseMap := make(map[string]interface{})
+	err = json.Unmarshal(rr.Body.Bytes(), &responseMap)
+	if err != nil {
+		fmt.Printf("Cannot convert to json: %v", err)
+	}
+
+	assert.Equal(t, rr.Code, 403)
+	assert.Equal(t, responseMap["error"], "Email not verified")
+}
@@ -1,20 +1,204 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/amaraliou/trackr-core/internal/response"
+	"github.com/amaraliou/trackr-core/internal/storage"
 )
 
-// SetAuth ...
-func SetAuth(next http.Handler) http.Handler {
+// realm -> protection space named in WWW-Authenticate challenges
+const realm = "trackr"
+
+// Authorization schemes
+const (
+	schemeBearer = "Bearer"
+	schemeAPIKey = "ApiKey"
+)
+
+// SessionStore -> where SetAuth checks the user and the revocation of a token or API key
+type SessionStore interface {
+	FindUser(string) (*model.User, error)
+	TokenDenied(string) (bool, error)
+	AuthenticateAPIKey(string, string) (*model.APIKey, error)
+}
+
+// SetAuth -> rejects requests without a valid token or API key and injects the user ID in the request context.
+// Tokens that were logged out, of deleted or disabled users and issued before the user's sessions were revoked are rejected too.
+func SetAuth(store SessionStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			apiKey := auth.ExtractAPIKey(request)
+			if apiKey != "" {
+				setAPIKeyAuth(store, next, writer, request, apiKey)
+				return
+			}
+
+			claims, err := auth.ParseRequest(request)
+			if err != nil {
+				challenge(writer, schemeBearer, err)
+				return
+			}
+
+			denied, err := store.TokenDenied(claims.Id)
+			if err != nil {
+				response.ERROR(writer, http.StatusInternalServerError, err)
+				return
+			}
+
+			if denied {
+				challenge(writer, schemeBearer, auth.ErrTokenRevoked)
+				return
+			}
+
+			user, err := store.FindUser(claims.UserID)
+			if err == storage.ErrUserNotFound {
+				challenge(writer, schemeBearer, auth.ErrTokenRevoked)
+				return
+			}
+
+			if err != nil {
+				response.ERROR(writer, http.StatusInternalServerError, err)
+				return
+			}
+
+			if user.Disabled() {
+				response.ERROR(writer, http.StatusForbidden, errors.New("Account disabled"))
+				return
+			}
+
+			// Tokens issued before a role change are rejected too so the role claim can be trusted
+			if !user.SessionValid(claims.IssuedAtTime()) || claims.Role != string(user.Role) {
+				challenge(writer, schemeBearer, auth.ErrTokenRevoked)
+				return
+			}
+
+			ctx := auth.WithUserID(request.Context(), claims.UserID)
+			ctx = auth.WithRole(ctx, claims.Role)
+			if claims.ActorID() != "" {
+				ctx = auth.WithActorID(ctx, claims.ActorID())
+			}
+
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// setAPIKeyAuth -> SetAuth for requests with an Authorization: ApiKey header, the key's scopes have to allow the method
+func setAPIKeyAuth(store SessionStore, next http.Handler, writer http.ResponseWriter, request *http.Request, apiKey string) {
+	prefix, hash, err := auth.ParseAPIKey(apiKey)
+	if err != nil {
+		challenge(writer, schemeAPIKey, err)
+		return
+	}
+
+	key, err := store.AuthenticateAPIKey(prefix, hash)
+	if err == storage.ErrTokenInvalid {
+		challenge(writer, schemeAPIKey, auth.ErrInvalidToken)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	user, err := store.FindUser(key.UserID.String())
+	if err == storage.ErrUserNotFound {
+		challenge(writer, schemeAPIKey, auth.ErrTokenRevoked)
+		return
+	}
+
+	if err != nil {
+		response.ERROR(writer, http.StatusInternalServerError, err)
+		return
+	}
+
+	if user.Disabled() {
+		response.ERROR(writer, http.StatusForbidden, errors.New("Account disabled"))
+		return
+	}
+
+	if !key.Allows(request.Method) {
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s", error="insufficient_scope"`, schemeAPIKey, realm))
+		response.ERROR(writer, http.StatusForbidden, errors.New("Forbidden"))
+		return
+	}
+
+	ctx := auth.WithUserID(request.Context(), user.ID.String())
+	ctx = auth.WithRole(ctx, string(user.Role))
+	ctx = auth.WithAPIKeyID(ctx, key.ID.String())
+
+	next.ServeHTTP(writer, request.WithContext(ctx))
+}
+
+// SessionOnly -> rejects requests authenticated with an API key or an impersonation token, for account management
+// that needs the user at hand. Staff impersonating a user can't create credentials that outlive their token.
+func SessionOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
-		err := auth.TokenValid(request)
-		if err != nil {
-			response.ERROR(writer, http.StatusUnauthorized, err)
+		if _, ok := auth.APIKeyIDFromContext(request.Context()); ok {
+			response.ERROR(writer, http.StatusForbidden, errors.New("Not allowed with an API key"))
+			return
+		}
+
+		if _, ok := auth.ActorIDFromContext(request.Context()); ok {
+			response.ERROR(writer, http.StatusForbidden, errors.New("Not allowed while impersonating"))
 			return
 		}
+
 		next.ServeHTTP(writer, request)
 	})
 }
+
+// RequireRole -> rejects requests of users without one of the given roles, goes after SetAuth.
+// Staff roles aren't granted to API keys.
+func RequireRole(roles ...model.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			role, ok := auth.RoleFromContext(request.Context())
+			if !ok {
+				challenge(writer, schemeBearer, auth.ErrTokenMissing)
+				return
+			}
+
+			if _, ok := auth.APIKeyIDFromContext(request.Context()); ok {
+				response.ERROR(writer, http.StatusForbidden, errors.New("Not allowed with an API key"))
+				return
+			}
+
+			for _, allowed := range roles {
+				if role == string(allowed) {
+					next.ServeHTTP(writer, request)
+					return
+				}
+			}
+
+			writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s", error="insufficient_scope"`, schemeBearer, realm))
+			response.ERROR(writer, http.StatusForbidden, errors.New("Forbidden"))
+		})
+	}
+}
+
+// challenge -> rejects the request with the WWW-Authenticate challenge of scheme matching err, see RFC 6750
+func challenge(writer http.ResponseWriter, scheme string, err error) {
+	switch err {
+	case auth.ErrTokenMissing:
+		// Either scheme is accepted
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s"`, schemeBearer, realm))
+		writer.Header().Add("WWW-Authenticate", fmt.Sprintf(`%s realm="%s"`, schemeAPIKey, realm))
+		response.ERROR(writer, http.StatusUnauthorized, err)
+	case auth.ErrTokenMalformed:
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s", error="invalid_request", error_description="%s"`, scheme, realm, err.Error()))
+		response.ERROR(writer, http.StatusBadRequest, err)
+	case auth.ErrTokenExpired, auth.ErrTokenRevoked:
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s", error="invalid_token", error_description="%s"`, scheme, realm, err.Error()))
+		response.ERROR(writer, http.StatusUnauthorized, err)
+	default:
+		writer.Header().Set("WWW-Authenticate", fmt.Sprintf(`%s realm="%s", error="invalid_token"`, scheme, realm))
+		response.ERROR(writer, http.StatusUnauthorized, auth.ErrInvalidToken)
+	}
+}
@@ -0,0 +1,452 @@
+// +build !integration
+
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-chi/chi"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// sessions is a SessionStore holding a single user, the denied token IDs and an API key
+type sessions struct {
+	user   model.User
+	denied map[string]bool
+	apiKey model.APIKey
+}
+
+func (store *sessions) FindUser(id string) (*model.User, error) {
+	if store.user.ID.String() != id {
+		return &model.User{}, storage.ErrUserNotFound
+	}
+	return &store.user, nil
+}
+
+func (store *sessions) TokenDenied(tokenID string) (bool, error) {
+	return store.denied[tokenID], nil
+}
+
+func (store *sessions) AuthenticateAPIKey(prefix, hash string) (*model.APIKey, error) {
+	if store.apiKey.Prefix != prefix || store.apiKey.Hash != hash {
+		return &model.APIKey{}, storage.ErrTokenInvalid
+	}
+	return &store.apiKey, nil
+}
+
+var keyManager *keys.Manager
+
+func TestMain(m *testing.M) {
+
+	logger, err := logger.NewZapLogger(logger.Config{
+		EnableConsole: false,
+		EnableFile:    false,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keyManager, err = keys.NewManager(keys.NewMemoryStore(), keys.EdDSA, 0, 0, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	auth.UseKeys(keyManager)
+
+	os.Exit(m.Run())
+}
+
+// signClaims signs claims with the current key the way auth.CreateToken does
+func signClaims(t *testing.T, claims *auth.Claims) string {
+	key, err := keyManager.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := jwt.NewWithClaims(key.SigningMethod(), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSetAuth(t *testing.T) {
+
+	userID := uuid.NewV4()
+	token, err := auth.CreateToken(userID, string(model.RoleUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := auth.ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenID := claims.Id
+
+	// Symmetric tokens naming a known key are rejected, whatever the secret
+	signingKey, err := keyManager.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	symmetric := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	symmetric.Header["kid"] = signingKey.ID
+	symmetricToken, err := symmetric.SignedString([]byte("test_secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := *claims
+	expired.IssuedAt = time.Now().Add(-time.Hour).Unix()
+	expired.NotBefore = expired.IssuedAt
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+
+	// Within the leeway
+	skewed := *claims
+	skewed.ExpiresAt = time.Now().Add(-10 * time.Second).Unix()
+
+	otherAudience := *claims
+	otherAudience.Audience = "other-api"
+
+	missingUser := *claims
+	missingUser.UserID = ""
+
+	revokedAt := time.Now().Add(time.Minute)
+	user := model.User{Role: model.RoleUser, Base: model.Base{ID: userID}}
+
+	cases := []struct {
+		token     string
+		store     *sessions
+		code      int
+		challenge string
+	}{
+		{
+			token: token,
+			store: &sessions{user: user},
+			code:  200,
+		},
+		{
+			token: signClaims(t, &skewed),
+			store: &sessions{user: user},
+			code:  200,
+		},
+		{
+			token:     "",
+			store:     &sessions{user: user},
+			code:      401,
+			challenge: `Bearer realm="trackr"`,
+		},
+		{
+			token:     "forged.token",
+			store:     &sessions{user: user},
+			code:      400,
+			challenge: `Bearer realm="trackr", error="invalid_request", error_description="Malformed token"`,
+		},
+		{
+			token:     signClaims(t, &missingUser),
+			store:     &sessions{user: user},
+			code:      400,
+			challenge: `Bearer realm="trackr", error="invalid_request", error_description="Malformed token"`,
+		},
+		{
+			token:     symmetricToken,
+			store:     &sessions{user: user},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token"`,
+		},
+		{
+			token:     signClaims(t, &otherAudience),
+			store:     &sessions{user: user},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token"`,
+		},
+		{
+			token:     signClaims(t, &expired),
+			store:     &sessions{user: user},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token", error_description="Token expired"`,
+		},
+		{
+			// Logged out
+			token:     token,
+			store:     &sessions{user: user, denied: map[string]bool{tokenID: true}},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token", error_description="Token revoked"`,
+		},
+		{
+			// Logged out of all devices
+			token:     token,
+			store:     &sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: userID}, SessionsRevokedAt: &revokedAt}},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token", error_description="Token revoked"`,
+		},
+		{
+			// Disabled user
+			token: token,
+			store: &sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: userID}, DisabledAt: &revokedAt}},
+			code:  403,
+		},
+		{
+			// Promoted since the token was issued
+			token:     token,
+			store:     &sessions{user: model.User{Role: model.RoleAdmin, Base: model.Base{ID: userID}}},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token", error_description="Token revoked"`,
+		},
+		{
+			// Deleted user
+			token:     token,
+			store:     &sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: uuid.NewV4()}}},
+			code:      401,
+			challenge: `Bearer realm="trackr", error="invalid_token", error_description="Token revoked"`,
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+		if err != nil {
+			t.Error("Failed to create 'GET: /api/v1/applications' request")
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+
+		contextUserID := ""
+		next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			contextUserID, _ = auth.UserIDFromContext(request.Context())
+		})
+
+		rr := httptest.NewRecorder()
+		SetAuth(c.store)(next).ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, c.code)
+		assert.Equal(t, rr.Header().Get("WWW-Authenticate"), c.challenge)
+		if c.code == 200 {
+			assert.Equal(t, contextUserID, userID.String())
+		}
+	}
+}
+
+func TestSetAuth_TokenSources(t *testing.T) {
+
+	userID := uuid.NewV4()
+	token, err := auth.CreateToken(userID, string(model.RoleUser))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: userID}}}
+	next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})
+
+	// The query string is ignored unless configured
+	req, _ := http.NewRequest("GET", "/api/v1/applications?token="+token, nil)
+	rr := httptest.NewRecorder()
+	SetAuth(store)(next).ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, 401)
+
+	auth.Configure(auth.Config{Sources: []auth.TokenSource{auth.SourceHeader, auth.SourceCookie}})
+	defer auth.Configure(auth.Config{})
+
+	req, _ = http.NewRequest("GET", "/api/v1/applications", nil)
+	req.AddCookie(&http.Cookie{Name: auth.AccessTokenCookie, Value: token})
+	rr = httptest.NewRecorder()
+	SetAuth(store)(next).ServeHTTP(rr, req)
+	assert.Equal(t, rr.Code, 200)
+}
+
+func TestSetAuth_Impersonation(t *testing.T) {
+
+	userID := uuid.NewV4()
+	actorID := uuid.NewV4()
+	token, _, err := auth.CreateImpersonationToken(userID, string(model.RoleUser), actorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", "/api/v1/applications", nil)
+	if err != nil {
+		t.Error("Failed to create 'GET: /api/v1/applications' request")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	contextUserID, contextActorID := "", ""
+	next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contextUserID, _ = auth.UserIDFromContext(request.Context())
+		contextActorID, _ = auth.ActorIDFromContext(request.Context())
+	})
+
+	rr := httptest.NewRecorder()
+	SetAuth(&sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: userID}}})(next).ServeHTTP(rr, req)
+
+	assert.Equal(t, rr.Code, 200)
+	assert.Equal(t, contextUserID, userID.String())
+	assert.Equal(t, contextActorID, actorID.String())
+}
+
+func TestSessionOnly_Impersonation(t *testing.T) {
+
+	userID := uuid.NewV4()
+	token, _, err := auth.CreateImpersonationToken(userID, string(model.RoleUser), uuid.NewV4())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &sessions{user: model.User{Role: model.RoleUser, Base: model.Base{ID: userID}}}
+	router := chi.NewRouter()
+	router.With(SetAuth(store), SessionOnly).Post("/api/v1/api-keys", func(http.ResponseWriter, *http.Request) {})
+	router.With(SetAuth(store), SessionOnly).Delete("/api/v1/users/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	cases := []struct {
+		method string
+		path   string
+	}{
+		{method: "POST", path: "/api/v1/api-keys"},
+		{method: "DELETE", path: "/api/v1/users/" + userID.String()},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, c.path, nil)
+		if err != nil {
+			t.Errorf("Failed to create '%s: %s' request", c.method, c.path)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, 403)
+	}
+}
+
+func TestSetAuth_APIKey(t *testing.T) {
+
+	userID := uuid.NewV4()
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := model.User{Role: model.RoleAdmin, Base: model.Base{ID: userID}}
+	apiKey := model.APIKey{Base: model.Base{ID: uuid.NewV4()}, Prefix: prefix, Hash: hash, Scopes: "read", UserID: userID}
+	disabledAt := time.Now()
+
+	cases := []struct {
+		method    string
+		key       string
+		store     *sessions
+		code      int
+		challenge string
+	}{
+		{
+			method: "GET",
+			key:    key,
+			store:  &sessions{user: user, apiKey: apiKey},
+			code:   200,
+		},
+		{
+			// Read scope only
+			method:    "POST",
+			key:       key,
+			store:     &sessions{user: user, apiKey: apiKey},
+			code:      403,
+			challenge: `ApiKey realm="trackr", error="insufficient_scope"`,
+		},
+		{
+			method:    "GET",
+			key:       "not-a-key",
+			store:     &sessions{user: user, apiKey: apiKey},
+			code:      400,
+			challenge: `ApiKey realm="trackr", error="invalid_request", error_description="Malformed token"`,
+		},
+		{
+			// Unknown, revoked or expired key
+			method:    "GET",
+			key:       key + "x",
+			store:     &sessions{user: user, apiKey: apiKey},
+			code:      401,
+			challenge: `ApiKey realm="trackr", error="invalid_token"`,
+		},
+		{
+			method: "GET",
+			key:    key,
+			store:  &sessions{user: model.User{Base: model.Base{ID: userID}, DisabledAt: &disabledAt}, apiKey: apiKey},
+			code:   403,
+		},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest(c.method, "/api/v1/applications", nil)
+		if err != nil {
+			t.Error("Failed to create '/api/v1/applications' request")
+		}
+		req.Header.Set("Authorization", "ApiKey "+c.key)
+
+		contextUserID, contextAPIKeyID := "", ""
+		sessionOnly, requireAdmin := 0, 0
+		next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			contextUserID, _ = auth.UserIDFromContext(request.Context())
+			contextAPIKeyID, _ = auth.APIKeyIDFromContext(request.Context())
+
+			rr := httptest.NewRecorder()
+			SessionOnly(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, request)
+			sessionOnly = rr.Code
+
+			rr = httptest.NewRecorder()
+			RequireRole(model.RoleAdmin)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, request)
+			requireAdmin = rr.Code
+		})
+
+		rr := httptest.NewRecorder()
+		SetAuth(c.store)(next).ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, c.code)
+		assert.Equal(t, rr.Header().Get("WWW-Authenticate"), c.challenge)
+		if c.code == 200 {
+			assert.Equal(t, contextUserID, userID.String())
+			assert.Equal(t, contextAPIKeyID, apiKey.ID.String())
+			assert.Equal(t, sessionOnly, 403)
+			assert.Equal(t, requireAdmin, 403)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+
+	cases := []struct {
+		role string
+		code int
+	}{
+		{role: "admin", code: 200},
+		{role: "support", code: 200},
+		{role: "user", code: 403},
+		{role: "", code: 401},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest("GET", "/api/v1/users", nil)
+		if err != nil {
+			t.Error("Failed to create 'GET: /api/v1/users' request")
+		}
+		req = req.WithContext(auth.WithRole(req.Context(), c.role))
+
+		next := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})
+
+		rr := httptest.NewRecorder()
+		RequireRole(model.RoleAdmin, model.RoleSupport)(next).ServeHTTP(rr, req)
+
+		assert.Equal(t, rr.Code, c.code)
+	}
+}
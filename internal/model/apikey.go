@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// APIKeyScope -> what requests an API key can make
+type APIKeyScope string
+
+// API key scopes
+const (
+	ScopeRead  APIKeyScope = "read"  // GET and HEAD requests
+	ScopeWrite APIKeyScope = "write" // Every request, reads included
+)
+
+// APIKey -> personal key for scripts, only the hash of its secret is stored.
+// The prefix identifies the key in listings and is where lookups start.
+type APIKey struct {
+	Base
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"unique;not null"`
+	Hash       string     `json:"-" gorm:"not null"`
+	Scopes     string     `json:"scopes" gorm:"not null"` // Space separated, like OAuth scopes
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"-"`
+	User       User       `json:"-" gorm:"foreignkey:UserID"`
+	UserID     uuid.UUID  `json:"user_id" sql:"type:uuid;index"`
+}
+
+// Validate ..
+func (key *APIKey) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if strings.TrimSpace(key.Name) == "" {
+			return errors.New("Required Name")
+		}
+
+		scopes := strings.Fields(key.Scopes)
+		if len(scopes) == 0 {
+			return errors.New("Required Scopes")
+		}
+
+		for _, scope := range scopes {
+			switch APIKeyScope(scope) {
+			case ScopeRead, ScopeWrite:
+			default:
+				return errors.New("Invalid Scopes")
+			}
+		}
+
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+			return errors.New("Invalid Expires At")
+		}
+
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// Allows -> whether the key's scopes cover a request with the given method
+func (key *APIKey) Allows(method string) bool {
+	for _, scope := range strings.Fields(key.Scopes) {
+		switch APIKeyScope(scope) {
+		case ScopeWrite:
+			return true
+		case ScopeRead:
+			if method == "GET" || method == "HEAD" {
+				return true
+			}
+		}
+	}
+	return false
+}
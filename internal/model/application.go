@@ -2,7 +2,9 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	uuid "github.com/satori/go.uuid"
 )
@@ -10,31 +12,56 @@ import (
 // Application ..
 type Application struct {
 	Base
-	JobTitle    string    `json:"job_title"`
-	Company     string    `json:"company"` // Add struct/table for company details later
-	Description string    `json:"description"`
-	JobPosting  string    `json:"job_url"`
-	Location    string    `json:"location"`
-	Status      int       `json:"status"` // Define iota
-	Type        string    `json:"type"`
-	User        User      `json:"-" gorm:"foreignkey:UserID"`
-	UserID      uuid.UUID `json:"user_id" gorm:"user_id"`
+	JobTitle        string     `json:"job_title"`
+	Company         string     `json:"company"` // Name of the linked company, kept in sync by the repository
+	CompanyID       *uuid.UUID `json:"company_id" sql:"type:uuid;index"`
+	Description     string     `json:"description"`
+	JobPosting      string     `json:"job_url"`
+	Location        string     `json:"location"`
+	Status          Status     `json:"status"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+	StatusChangedBy *uuid.UUID `json:"status_changed_by" sql:"type:uuid"`
+	PreviousStatus  Status     `json:"-" gorm:"-"` // Persisted status, set before Validate("update")
+	Type            string     `json:"type"`
+	User            User       `json:"-" gorm:"foreignkey:UserID"`
+	UserID          uuid.UUID  `json:"user_id" gorm:"user_id"`
 }
 
 // Validate ..
 func (application *Application) Validate(action string) error {
 	switch strings.ToLower(action) {
 	case "create":
-		if application.JobTitle == "" {
-			return errors.New("Required Job Title")
+		err := application.validateRequired()
+		if err != nil {
+			return err
 		}
 
-		if application.Company == "" {
-			return errors.New("Required Company")
+		if !application.Status.IsValid() {
+			return errors.New("Invalid Status")
 		}
 
-		if application.UserID.String() == "00000000-0000-0000-0000-000000000000" {
-			return errors.New("Required User ID")
+		if !application.Status.IsInitial() {
+			return fmt.Errorf("Invalid Status %s for a new application", application.Status)
+		}
+
+		return nil
+
+	// A replacement needs every required field, its status follows the transitions of an update
+	case "replace":
+		err := application.validateRequired()
+		if err != nil {
+			return err
+		}
+
+		fallthrough
+
+	case "update":
+		if !application.Status.IsValid() {
+			return errors.New("Invalid Status")
+		}
+
+		if !application.PreviousStatus.CanTransitionTo(application.Status) {
+			return fmt.Errorf("Invalid Status transition from %s to %s", application.PreviousStatus, application.Status)
 		}
 
 		return nil
@@ -43,3 +70,27 @@ func (application *Application) Validate(action string) error {
 		return nil
 	}
 }
+
+func (application *Application) validateRequired() error {
+	if application.JobTitle == "" {
+		return errors.New("Required Job Title")
+	}
+
+	if application.Company == "" && application.CompanyID == nil {
+		return errors.New("Required Company")
+	}
+
+	if application.UserID.String() == "00000000-0000-0000-0000-000000000000" {
+		return errors.New("Required User ID")
+	}
+
+	return nil
+}
+
+// ChangeStatus -> records who moved the application to the given status and when
+func (application *Application) ChangeStatus(status Status, changedBy uuid.UUID) {
+	now := time.Now()
+	application.Status = status
+	application.StatusChangedAt = &now
+	application.StatusChangedBy = &changedBy
+}
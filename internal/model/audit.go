@@ -0,0 +1,33 @@
+package model
+
+import uuid "github.com/satori/go.uuid"
+
+// AuditAction -> what an audit event records
+type AuditAction string
+
+// Audit actions
+const (
+	AuditUserDisabled      AuditAction = "user.disabled"
+	AuditUserEnabled       AuditAction = "user.enabled"
+	AuditUserImpersonated  AuditAction = "user.impersonated"
+	AuditMFAEnabled        AuditAction = "user.mfa_enabled"
+	AuditMFADisabled       AuditAction = "user.mfa_disabled"
+	AuditPasswordChanged   AuditAction = "user.password_changed"
+	AuditDeletionRequested AuditAction = "user.deletion_requested"
+	AuditDeletionCanceled  AuditAction = "user.deletion_canceled"
+	AuditUserDeleted       AuditAction = "user.deleted"
+	AuditUserRestored      AuditAction = "user.restored"
+	AuditUserPurged        AuditAction = "user.purged"
+	AuditLoginFailed       AuditAction = "login.failed"
+)
+
+// AuditEvent -> record of a sensitive action, ActorID did it to SubjectID
+type AuditEvent struct {
+	Base
+	Action    AuditAction `json:"action" gorm:"not null;index"`
+	ActorID   *uuid.UUID  `json:"actor_id" sql:"type:uuid;index"`
+	SubjectID *uuid.UUID  `json:"subject_id" sql:"type:uuid;index"`
+	TokenID   string      `json:"token_id"` // jti of the token issued by the action, if any
+	Reason    string      `json:"reason"`
+	IP        string      `json:"ip"`
+}
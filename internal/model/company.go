@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Company -> company a user applies to, names are unique per user ignoring case
+type Company struct {
+	Base
+	Name         string    `json:"name" gorm:"not null"`
+	Website      string    `json:"website"`
+	Industry     string    `json:"industry"`
+	Size         string    `json:"size"`
+	Headquarters string    `json:"headquarters"`
+	Notes        string    `json:"notes"`
+	User         User      `json:"-" gorm:"foreignkey:UserID"`
+	UserID       uuid.UUID `json:"user_id" sql:"type:uuid;index"`
+}
+
+// Validate ..
+func (company *Company) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if strings.TrimSpace(company.Name) == "" {
+			return errors.New("Required Name")
+		}
+
+		if company.UserID.String() == "00000000-0000-0000-0000-000000000000" {
+			return errors.New("Required User ID")
+		}
+
+		return company.validateWebsite()
+
+	case "update":
+		return company.validateWebsite()
+
+	default:
+		return nil
+	}
+}
+
+func (company *Company) validateWebsite() error {
+	if company.Website == "" {
+		return nil
+	}
+
+	website, err := url.Parse(company.Website)
+	if err != nil || (website.Scheme != "http" && website.Scheme != "https") || website.Host == "" {
+		return errors.New("Invalid Website")
+	}
+
+	return nil
+}
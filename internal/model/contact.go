@@ -0,0 +1,152 @@
+package model
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/badoux/checkmail"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ContactRelationship -> role a contact plays in an application
+type ContactRelationship string
+
+// Contact relationships
+const (
+	RelationshipRecruiter     ContactRelationship = "recruiter"
+	RelationshipReferrer      ContactRelationship = "referrer"
+	RelationshipHiringManager ContactRelationship = "hiring_manager"
+)
+
+// InteractionChannel ...
+type InteractionChannel string
+
+// Interaction channels
+const (
+	ChannelEmail    InteractionChannel = "email"
+	ChannelPhone    InteractionChannel = "phone"
+	ChannelLinkedIn InteractionChannel = "linkedin"
+	ChannelMeeting  InteractionChannel = "meeting"
+	ChannelOther    InteractionChannel = "other"
+)
+
+// Contact -> recruiter, referrer or anyone else the user talks to about jobs
+type Contact struct {
+	Base
+	Name            string     `json:"name" gorm:"not null"`
+	Email           string     `json:"email"`
+	Phone           string     `json:"phone"`
+	LinkedInURL     string     `json:"linkedin_url"`
+	Role            string     `json:"role"`
+	Company         string     `json:"company"` // Name of the linked company when CompanyID is set
+	CompanyID       *uuid.UUID `json:"company_id" sql:"type:uuid;index"`
+	Notes           string     `json:"notes"`
+	LastContactedAt *time.Time `json:"last_contacted_at"` // Latest logged interaction, kept by the repository
+	User            User       `json:"-" gorm:"foreignkey:UserID"`
+	UserID          uuid.UUID  `json:"user_id" sql:"type:uuid;index"`
+}
+
+// ApplicationContact -> links a contact to an application
+type ApplicationContact struct {
+	ApplicationID uuid.UUID           `json:"application_id" gorm:"primary_key" sql:"type:uuid"`
+	ContactID     uuid.UUID           `json:"contact_id" gorm:"primary_key" sql:"type:uuid"`
+	Relationship  ContactRelationship `json:"relationship" gorm:"not null"`
+	Contact       Contact             `json:"contact" gorm:"foreignkey:ContactID"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// ContactInteraction -> call, email or meeting with a contact, optionally about an application
+type ContactInteraction struct {
+	Base
+	Channel       InteractionChannel `json:"channel"`
+	OccurredAt    time.Time          `json:"occurred_at"`
+	Notes         string             `json:"notes"`
+	ContactID     uuid.UUID          `json:"contact_id" sql:"type:uuid;index"`
+	ApplicationID *uuid.UUID         `json:"application_id" sql:"type:uuid;index"`
+	UserID        uuid.UUID          `json:"user_id" sql:"type:uuid;index"`
+}
+
+// Validate ..
+func (contact *Contact) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if strings.TrimSpace(contact.Name) == "" {
+			return errors.New("Required Name")
+		}
+
+		if contact.UserID.String() == "00000000-0000-0000-0000-000000000000" {
+			return errors.New("Required User ID")
+		}
+
+		return contact.validateFields()
+
+	case "update":
+		return contact.validateFields()
+
+	default:
+		return nil
+	}
+}
+
+// validateFields -> checks the fields that are set
+func (contact *Contact) validateFields() error {
+	if contact.Email != "" {
+		if err := checkmail.ValidateFormat(contact.Email); err != nil {
+			return errors.New("Invalid Email")
+		}
+	}
+
+	if contact.LinkedInURL != "" {
+		linkedInURL, err := url.Parse(contact.LinkedInURL)
+		if err != nil || (linkedInURL.Scheme != "http" && linkedInURL.Scheme != "https") || linkedInURL.Host == "" {
+			return errors.New("Invalid LinkedIn URL")
+		}
+	}
+
+	return nil
+}
+
+// Validate ..
+func (link *ApplicationContact) Validate() error {
+	switch link.Relationship {
+	case "":
+		return errors.New("Required Relationship")
+	case RelationshipRecruiter, RelationshipReferrer, RelationshipHiringManager:
+		return nil
+	default:
+		return errors.New("Invalid Relationship")
+	}
+}
+
+// Validate -> the channel defaults to other and the time to now
+func (interaction *ContactInteraction) Validate() error {
+	if interaction.Channel == "" {
+		interaction.Channel = ChannelOther
+	}
+
+	switch interaction.Channel {
+	case ChannelEmail, ChannelPhone, ChannelLinkedIn, ChannelMeeting, ChannelOther:
+	default:
+		return errors.New("Invalid Channel")
+	}
+
+	if interaction.OccurredAt.IsZero() {
+		interaction.OccurredAt = time.Now()
+	}
+
+	if interaction.OccurredAt.After(time.Now().Add(time.Minute)) {
+		return errors.New("Invalid Occurred At")
+	}
+
+	if interaction.ContactID == uuid.Nil {
+		return errors.New("Required Contact ID")
+	}
+
+	if interaction.UserID == uuid.Nil {
+		return errors.New("Required User ID")
+	}
+
+	return nil
+}
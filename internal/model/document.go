@@ -0,0 +1,82 @@
+package model
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// DocumentKind ...
+type DocumentKind string
+
+// Document kinds
+const (
+	DocumentResume      DocumentKind = "resume"
+	DocumentCoverLetter DocumentKind = "cover_letter"
+	DocumentOther       DocumentKind = "other"
+)
+
+// Document -> uploaded file, uploads with the same name are versions of the same document
+type Document struct {
+	Base
+	Name         string        `json:"name" gorm:"not null"`
+	Kind         DocumentKind  `json:"kind"`
+	Version      int           `json:"version"`
+	FileName     string        `json:"file_name"`
+	ContentType  string        `json:"content_type"`
+	Size         int64         `json:"size"`
+	SHA256       string        `json:"sha256" sql:"index"`
+	User         User          `json:"-" gorm:"foreignkey:UserID"`
+	UserID       uuid.UUID     `json:"user_id" sql:"type:uuid;index"`
+	Applications []Application `json:"-" gorm:"many2many:application_documents"`
+}
+
+// Validate ..
+func (document *Document) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if strings.TrimSpace(document.Name) == "" {
+			return errors.New("Required Name")
+		}
+
+		switch document.Kind {
+		case DocumentResume, DocumentCoverLetter, DocumentOther:
+		default:
+			return errors.New("Invalid Kind")
+		}
+
+		if document.FileName == "" {
+			return errors.New("Required File")
+		}
+
+		if document.SHA256 == "" {
+			return errors.New("Required SHA256")
+		}
+
+		if document.UserID.String() == "00000000-0000-0000-0000-000000000000" {
+			return errors.New("Required User ID")
+		}
+
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// CleanFileName -> base name of an uploaded file without characters unsafe for headers
+func CleanFileName(fileName string) string {
+	fileName = filepath.Base(strings.Replace(fileName, "\\", "/", -1))
+	if fileName == "." || fileName == "/" {
+		return ""
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == '"' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, fileName)
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// MaxEmailAttempts -> deliveries tried before an email is given up on
+const MaxEmailAttempts = 8
+
+// Email -> rendered email waiting in the outbound queue
+type Email struct {
+	Base
+	To            string     `json:"to" gorm:"not null"`
+	Subject       string     `json:"subject"`
+	Text          string     `json:"text"`
+	HTML          string     `json:"html"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" sql:"index"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	FailedAt      *time.Time `json:"failed_at"` // Set once MaxEmailAttempts deliveries failed
+}
+
+// Backoff -> wait before the next delivery, doubling from a minute up to six hours
+func (email *Email) Backoff() time.Duration {
+	backoff := time.Minute
+	for i := 1; i < email.Attempts && backoff < 6*time.Hour; i++ {
+		backoff *= 2
+	}
+
+	if backoff > 6*time.Hour {
+		backoff = 6 * time.Hour
+	}
+
+	return backoff
+}
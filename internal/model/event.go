@@ -0,0 +1,117 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// EventType ...
+type EventType string
+
+// Application event types
+const (
+	EventCreated       EventType = "created"
+	EventStatusChanged EventType = "status_changed"
+	EventUpdated       EventType = "updated"
+)
+
+// FieldChange -> previous and new value of an edited field
+type FieldChange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// FieldChanges -> edited fields keyed by their JSON name, stored as jsonb
+type FieldChanges map[string]FieldChange
+
+// Value ...
+func (changes FieldChanges) Value() (driver.Value, error) {
+	if changes == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(changes)
+	return string(b), err
+}
+
+// Scan ...
+func (changes *FieldChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*changes = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, changes)
+	case string:
+		return json.Unmarshal([]byte(v), changes)
+	default:
+		return errors.New("Invalid FieldChanges value")
+	}
+}
+
+// ApplicationEvent -> one entry of an application timeline
+type ApplicationEvent struct {
+	Base
+	ApplicationID uuid.UUID    `json:"application_id" sql:"type:uuid;index"`
+	UserID        uuid.UUID    `json:"user_id" sql:"type:uuid"`
+	Type          EventType    `json:"type"`
+	FromStatus    Status       `json:"from_status"`
+	ToStatus      Status       `json:"to_status"`
+	Changes       FieldChanges `json:"changes,omitempty" sql:"type:jsonb"`
+	StageDuration int64        `json:"stage_duration_seconds,omitempty" gorm:"-"`
+}
+
+// DiffApplication -> fields an update would change, zero values are ignored like gorm Updates does
+func DiffApplication(current, update Application) FieldChanges {
+	return diffApplication(current, update, false)
+}
+
+// DiffApplicationReplace -> fields a replacement would change, cleared fields included
+func DiffApplicationReplace(current, update Application) FieldChanges {
+	return diffApplication(current, update, true)
+}
+
+func diffApplication(current, update Application, replace bool) FieldChanges {
+	changes := FieldChanges{}
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"job_title", current.JobTitle, update.JobTitle},
+		{"company", current.Company, update.Company},
+		{"description", current.Description, update.Description},
+		{"job_url", current.JobPosting, update.JobPosting},
+		{"location", current.Location, update.Location},
+		{"type", current.Type, update.Type},
+	}
+
+	for _, field := range fields {
+		if (replace || field.to != "") && field.to != field.from {
+			changes[field.name] = FieldChange{From: field.from, To: field.to}
+		}
+	}
+
+	return changes
+}
+
+// FillStageDurations -> sets how long the application stayed in the status each event moved it to.
+// Events must be ordered oldest first, the last stage is measured until now.
+func FillStageDurations(events []ApplicationEvent, now time.Time) {
+	last := -1
+	for i := range events {
+		if events[i].Type != EventCreated && events[i].Type != EventStatusChanged {
+			continue
+		}
+		if last >= 0 {
+			events[last].StageDuration = int64(events[i].CreatedAt.Sub(events[last].CreatedAt).Seconds())
+		}
+		last = i
+	}
+
+	if last >= 0 {
+		events[last].StageDuration = int64(now.Sub(events[last].CreatedAt).Seconds())
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+// UserExport -> everything stored about a user, for them to download before their account is deleted
+type UserExport struct {
+	User                User
+	Companies           []Company
+	Applications        []Application
+	Events              []ApplicationEvent
+	Interviews          []Interview
+	Reminders           []Reminder
+	Documents           []Document
+	Contacts            []Contact
+	ApplicationContacts []ApplicationContact
+	Interactions        []ContactInteraction
+	Identities          []Identity
+}
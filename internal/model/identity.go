@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// OIDCStateTTL -> how long a user has to sign in at the identity provider
+const OIDCStateTTL = 10 * time.Minute
+
+// Identity -> account of a user at an identity provider they can sign in with
+type Identity struct {
+	Base
+	Provider string    `json:"provider" gorm:"not null;unique_index:idx_identities_provider_subject"`
+	Subject  string    `json:"subject" gorm:"not null;unique_index:idx_identities_provider_subject"`
+	Email    string    `json:"email"` // As given by the provider when the identity was linked
+	User     User      `json:"-" gorm:"foreignkey:UserID"`
+	UserID   uuid.UUID `json:"user_id" sql:"type:uuid;index"`
+}
+
+// OIDCState -> sign in started with an identity provider, only the hash of the state is stored.
+// The nonce and PKCE verifier are useless without the code the provider redirects with.
+type OIDCState struct {
+	Base
+	Provider  string    `gorm:"not null"`
+	Hash      string    `gorm:"unique;not null"`
+	Nonce     string    `gorm:"not null"`
+	Verifier  string    `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+}
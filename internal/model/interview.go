@@ -0,0 +1,166 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// InterviewType ...
+type InterviewType string
+
+// Interview types
+const (
+	InterviewPhone     InterviewType = "phone"
+	InterviewOnsite    InterviewType = "onsite"
+	InterviewTechnical InterviewType = "technical"
+)
+
+// InterviewOutcome ...
+type InterviewOutcome string
+
+// Interview outcomes, InterviewPending until the interview took place
+const (
+	InterviewPending   InterviewOutcome = "pending"
+	InterviewPassed    InterviewOutcome = "passed"
+	InterviewFailed    InterviewOutcome = "failed"
+	InterviewCancelled InterviewOutcome = "cancelled"
+)
+
+// StringList -> list of strings stored as jsonb
+type StringList []string
+
+// Value ...
+func (list StringList) Value() (driver.Value, error) {
+	if list == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(list)
+	return string(b), err
+}
+
+// Scan ...
+func (list *StringList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*list = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, list)
+	case string:
+		return json.Unmarshal([]byte(v), list)
+	default:
+		return errors.New("Invalid StringList value")
+	}
+}
+
+// Interview -> interview round of an application
+type Interview struct {
+	Base
+	Round         int              `json:"round"`
+	Type          InterviewType    `json:"type"`
+	ScheduledAt   time.Time        `json:"scheduled_at"`
+	TimeZone      string           `json:"time_zone"` // IANA name, scheduled_at is returned in this zone
+	Duration      int              `json:"duration_minutes"`
+	Interviewers  StringList       `json:"interviewers" sql:"type:jsonb"`
+	Location      string           `json:"location"`
+	MeetingURL    string           `json:"meeting_url"`
+	Outcome       InterviewOutcome `json:"outcome"`
+	Notes         string           `json:"notes"`
+	ApplicationID uuid.UUID        `json:"application_id" sql:"type:uuid;index"`
+	UserID        uuid.UUID        `json:"user_id" sql:"type:uuid;index"`
+}
+
+// AfterFind -> returns scheduled_at in the interview time zone
+func (interview *Interview) AfterFind() error {
+	interview.localize()
+	return nil
+}
+
+// AfterSave -> same as AfterFind for the interview that was created or updated
+func (interview *Interview) AfterSave() error {
+	interview.localize()
+	return nil
+}
+
+func (interview *Interview) localize() {
+	if interview.TimeZone == "" {
+		return
+	}
+
+	location, err := time.LoadLocation(interview.TimeZone)
+	if err != nil {
+		return
+	}
+	interview.ScheduledAt = interview.ScheduledAt.In(location)
+}
+
+// Validate ..
+func (interview *Interview) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if interview.Type == "" {
+			return errors.New("Required Type")
+		}
+
+		if interview.ScheduledAt.IsZero() {
+			return errors.New("Required Scheduled At")
+		}
+
+		if interview.Outcome == "" {
+			interview.Outcome = InterviewPending
+		}
+
+		return interview.validateFields()
+
+	case "update":
+		return interview.validateFields()
+
+	default:
+		return nil
+	}
+}
+
+// validateFields -> checks the fields that are set
+func (interview *Interview) validateFields() error {
+	switch interview.Type {
+	case "", InterviewPhone, InterviewOnsite, InterviewTechnical:
+	default:
+		return errors.New("Invalid Type")
+	}
+
+	switch interview.Outcome {
+	case "", InterviewPending, InterviewPassed, InterviewFailed, InterviewCancelled:
+	default:
+		return errors.New("Invalid Outcome")
+	}
+
+	if interview.Round < 0 {
+		return errors.New("Invalid Round")
+	}
+
+	if interview.Duration < 0 {
+		return errors.New("Invalid Duration")
+	}
+
+	if interview.TimeZone != "" {
+		_, err := time.LoadLocation(interview.TimeZone)
+		if err != nil {
+			return errors.New("Invalid Time Zone")
+		}
+	}
+
+	if interview.MeetingURL != "" {
+		meetingURL, err := url.Parse(interview.MeetingURL)
+		if err != nil || (meetingURL.Scheme != "http" && meetingURL.Scheme != "https") || meetingURL.Host == "" {
+			return errors.New("Invalid Meeting URL")
+		}
+	}
+
+	return nil
+}
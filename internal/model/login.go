@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// LockoutPolicy -> failed logins allowed before lockouts start, each further failure doubles the lockout up to Max.
+// Failures are forgotten after Window without any.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+	Window    time.Duration
+}
+
+// Lockout policies, addresses are allowed more failures since users behind a NAT share one
+var (
+	AccountLockout = LockoutPolicy{Threshold: 5, Base: 30 * time.Second, Max: 15 * time.Minute, Window: time.Hour}
+	IPLockout      = LockoutPolicy{Threshold: 20, Base: 30 * time.Second, Max: time.Hour, Window: time.Hour}
+)
+
+// Lockout -> how long logins are refused after the given number of consecutive failures
+func (policy LockoutPolicy) Lockout(failures int) time.Duration {
+	if failures < policy.Threshold {
+		return 0
+	}
+
+	lockout := policy.Base
+	for i := policy.Threshold; i < failures && lockout < policy.Max; i++ {
+		lockout *= 2
+	}
+
+	if lockout > policy.Max {
+		return policy.Max
+	}
+	return lockout
+}
+
+// LoginThrottle -> failed logins of an account or address, keyed like account:<email> or ip:<address>
+type LoginThrottle struct {
+	Key           string     `gorm:"primary_key"`
+	Failures      int        `gorm:"not null;default:0"`
+	LastFailureAt time.Time  `gorm:"index"`
+	LockedUntil   *time.Time `gorm:"index"`
+}
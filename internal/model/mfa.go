@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RecoveryCodeCount -> recovery codes issued when two-factor authentication is enabled or the codes regenerated
+const RecoveryCodeCount = 10
+
+// RecoveryCode -> single-use code accepted instead of a TOTP code, only its hash is stored
+type RecoveryCode struct {
+	Base
+	Hash   string     `json:"-" gorm:"not null"`
+	UsedAt *time.Time `json:"used_at"`
+	User   User       `json:"-" gorm:"foreignkey:UserID"`
+	UserID uuid.UUID  `json:"user_id" sql:"type:uuid;index"`
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// RepeatRule -> how a reminder is rescheduled once it fired, RepeatNone fires once
+type RepeatRule string
+
+// Repeat rules
+const (
+	RepeatNone    RepeatRule = ""
+	RepeatDaily   RepeatRule = "daily"
+	RepeatWeekly  RepeatRule = "weekly"
+	RepeatMonthly RepeatRule = "monthly"
+)
+
+// Reminder -> follow-up of an application, fired by the scheduler once due
+type Reminder struct {
+	Base
+	DueAt         time.Time   `json:"due_at" sql:"index"`
+	Message       string      `json:"message"`
+	Repeat        RepeatRule  `json:"repeat"`
+	FiredAt       *time.Time  `json:"fired_at"` // Last time the reminder fired
+	DismissedAt   *time.Time  `json:"dismissed_at"`
+	Application   Application `json:"-" gorm:"foreignkey:ApplicationID"`
+	ApplicationID uuid.UUID   `json:"application_id" sql:"type:uuid;index"`
+	User          User        `json:"-" gorm:"foreignkey:UserID"`
+	UserID        uuid.UUID   `json:"user_id" sql:"type:uuid;index"`
+}
+
+// Validate ..
+func (reminder *Reminder) Validate(action string) error {
+	switch strings.ToLower(action) {
+	case "create":
+		if reminder.DueAt.IsZero() {
+			return errors.New("Required Due At")
+		}
+
+		if strings.TrimSpace(reminder.Message) == "" {
+			return errors.New("Required Message")
+		}
+
+		switch reminder.Repeat {
+		case RepeatNone, RepeatDaily, RepeatWeekly, RepeatMonthly:
+		default:
+			return errors.New("Invalid Repeat")
+		}
+
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+// Next -> the first occurrence of a repeating reminder after now, nil if it doesn't repeat
+func (reminder *Reminder) Next(now time.Time) *time.Time {
+	next := reminder.DueAt
+	for !next.After(now) {
+		switch reminder.Repeat {
+		case RepeatDaily:
+			next = next.AddDate(0, 0, 1)
+		case RepeatWeekly:
+			next = next.AddDate(0, 0, 7)
+		case RepeatMonthly:
+			next = next.AddDate(0, 1, 0)
+		default:
+			return nil
+		}
+	}
+
+	return &next
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Status -> stage an application is in, stored as an int and serialised as a string.
+// Only append new values, the integers are persisted.
+type Status int
+
+// Application statuses, StatusWishlist is the default initial one
+const (
+	StatusWishlist Status = iota
+	StatusApplied
+	StatusScreening
+	StatusInterviewing
+	StatusOffer
+	StatusAccepted
+	StatusRejected
+	StatusWithdrawn
+	StatusGhosted
+)
+
+var statusNames = map[Status]string{
+	StatusWishlist:     "wishlist",
+	StatusApplied:      "applied",
+	StatusScreening:    "screening",
+	StatusInterviewing: "interviewing",
+	StatusOffer:        "offer",
+	StatusAccepted:     "accepted",
+	StatusRejected:     "rejected",
+	StatusWithdrawn:    "withdrawn",
+	StatusGhosted:      "ghosted",
+}
+
+// initialStatuses -> statuses a new application can start at, it may have been sent before being tracked
+var initialStatuses = []Status{StatusWishlist, StatusApplied}
+
+// statusTransitions -> statuses reachable from each status, nothing goes back to wishlist
+var statusTransitions = map[Status][]Status{
+	StatusWishlist:     {StatusApplied, StatusWithdrawn},
+	StatusApplied:      {StatusScreening, StatusInterviewing, StatusOffer, StatusRejected, StatusWithdrawn, StatusGhosted},
+	StatusScreening:    {StatusInterviewing, StatusOffer, StatusRejected, StatusWithdrawn, StatusGhosted},
+	StatusInterviewing: {StatusOffer, StatusRejected, StatusWithdrawn, StatusGhosted},
+	StatusOffer:        {StatusAccepted, StatusRejected, StatusWithdrawn},
+	StatusAccepted:     {},
+	StatusRejected:     {},
+	StatusWithdrawn:    {},
+	StatusGhosted:      {StatusScreening, StatusInterviewing, StatusOffer, StatusRejected, StatusWithdrawn},
+}
+
+// ParseStatus -> returns the status with the given name
+func ParseStatus(name string) (Status, error) {
+	for status, statusName := range statusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	return StatusWishlist, fmt.Errorf("Invalid Status %q", name)
+}
+
+// String ...
+func (status Status) String() string {
+	name, ok := statusNames[status]
+	if !ok {
+		return fmt.Sprintf("Status(%d)", int(status))
+	}
+	return name
+}
+
+// IsValid ...
+func (status Status) IsValid() bool {
+	_, ok := statusNames[status]
+	return ok
+}
+
+// IsInitial -> whether a new application can start at the status
+func (status Status) IsInitial() bool {
+	for _, initial := range initialStatuses {
+		if initial == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanTransitionTo -> staying on the same status is always allowed
+func (status Status) CanTransitionTo(next Status) bool {
+	if status == next {
+		return true
+	}
+
+	for _, allowed := range statusTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarshalJSON ...
+func (status Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(status.String())
+}
+
+// UnmarshalJSON ...
+func (status *Status) UnmarshalJSON(data []byte) error {
+	var name string
+	err := json.Unmarshal(data, &name)
+	if err != nil {
+		return fmt.Errorf("Invalid Status %s", string(data))
+	}
+
+	parsed, err := ParseStatus(name)
+	if err != nil {
+		return err
+	}
+
+	*status = parsed
+	return nil
+}
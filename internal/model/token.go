@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// TokenPurpose -> what a user token can be used for
+type TokenPurpose string
+
+// Token purposes
+const (
+	TokenVerifyEmail   TokenPurpose = "verify_email"
+	TokenResetPassword TokenPurpose = "reset_password"
+	TokenRefresh       TokenPurpose = "refresh"
+	TokenMFAChallenge  TokenPurpose = "mfa_challenge"
+	TokenOIDCState     TokenPurpose = "oidc_state" // Signs the state of OIDCState, which isn't a UserToken
+)
+
+// RefreshTokenTTL -> how long a refresh token can be exchanged for new tokens
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// MFAChallengeTTL -> how long after the password a TOTP or recovery code can be given
+const MFAChallengeTTL = 5 * time.Minute
+
+// MFAChallengeAttempts -> wrong codes accepted before the challenge is used up and the password needed again
+const MFAChallengeAttempts = 5
+
+// UserToken -> single-use token sent to a user, only its hash is stored
+type UserToken struct {
+	Base
+	Purpose   TokenPurpose `json:"purpose" gorm:"not null"`
+	Hash      string       `json:"-" gorm:"unique;not null"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at"`
+	Attempts  int          `json:"-" gorm:"not null;default:0"` // Failed attempts, only counted for MFA challenges
+	User      User         `json:"-" gorm:"foreignkey:UserID"`
+	UserID    uuid.UUID    `json:"user_id" sql:"type:uuid;index"`
+}
+
+// RefreshToken -> rotating token exchanged for new access tokens, only its hash is stored.
+// Tokens rotated from the same login share their FamilyID.
+type RefreshToken struct {
+	Base
+	Hash      string     `json:"-" gorm:"unique;not null"`
+	FamilyID  uuid.UUID  `json:"family_id" sql:"type:uuid;index"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	User      User       `json:"-" gorm:"foreignkey:UserID"`
+	UserID    uuid.UUID  `json:"user_id" sql:"type:uuid;index"`
+}
+
+// DeniedToken -> access token revoked before it expired, kept until it does
+type DeniedToken struct {
+	JTI       string    `gorm:"primary_key"`
+	ExpiresAt time.Time `gorm:"index"`
+}
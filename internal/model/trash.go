@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// DefaultTrashRetention -> time deleted items can be restored before they're purged
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashKind -> what a deleted item was
+type TrashKind string
+
+// Trash kinds
+const (
+	TrashApplication TrashKind = "application"
+	TrashContact     TrashKind = "contact"
+	TrashCompany     TrashKind = "company"
+	TrashDocument    TrashKind = "document"
+	TrashInterview   TrashKind = "interview"
+	TrashUser        TrashKind = "user"
+)
+
+// TrashItem -> soft deleted item of a user, or user, restorable until the retention passes
+type TrashItem struct {
+	Kind          TrashKind  `json:"kind"`
+	ID            uuid.UUID  `json:"id"`
+	Title         string     `json:"title"`                    // Job title and company of applications, name of contacts, companies and documents, email of users
+	ApplicationID *uuid.UUID `json:"application_id,omitempty"` // Only for interviews, which are restored through their application
+	DeletedAt     time.Time  `json:"deleted_at"`
+}
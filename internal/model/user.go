@@ -5,9 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/amaraliou/trackr-core/internal/auth/passwords"
 	"github.com/badoux/checkmail"
 	uuid "github.com/satori/go.uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Base -> Struct to substitute gorm.Model when I want a UUID
@@ -18,28 +18,83 @@ type Base struct {
 	DeletedAt *time.Time `sql:"index" json:"deleted_at"`
 }
 
+// Role -> what a user is allowed to do
+type Role string
+
+// Roles
+const (
+	RoleUser    Role = "user"
+	RoleAdmin   Role = "admin"
+	RoleSupport Role = "support"
+)
+
 // User ...
 type User struct {
 	Base
-	Email      string `json:"email" gorm:"unique;not null"`
-	Password   string `json:"password"`
-	IsVerified bool   `json:"is_verified"`
-	FirstName  string `json:"first_name"`
-	LastName   string `json:"last_name"`
+	Email             string     `json:"email" gorm:"unique;not null"`
+	Password          string     `json:"password"`
+	IsVerified        bool       `json:"is_verified"`
+	FirstName         string     `json:"first_name"`
+	LastName          string     `json:"last_name"`
+	Role              Role       `json:"role" gorm:"not null;default:'user'"`
+	DisabledAt        *time.Time `json:"disabled_at"`
+	SessionsRevokedAt *time.Time `json:"-"` // Tokens issued before are rejected by middleware.SetAuth
+	TOTPSecret        string     `json:"-"` // Sealed, set on enrolment and kept once confirmed
+	TOTPEnabledAt     *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep      int64      `json:"-"`                           // Period of the last accepted code, codes can't be replayed
+	DeletionDueAt     *time.Time `json:"deletion_due_at" sql:"index"` // Set when the user asked for their account to be deleted
+}
+
+// DefaultDeletionGracePeriod -> time users have to change their mind after asking for their account to be deleted
+const DefaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// DeletionScheduled -> users scheduled for deletion keep their account by signing in before it's due
+func (user *User) DeletionScheduled() bool {
+	return user.DeletionDueAt != nil
+}
+
+// Disabled -> disabled users can't sign in and their tokens are rejected
+func (user *User) Disabled() bool {
+	return user.DisabledAt != nil
 }
 
-// Hash -> Generate hash for given password
+// MFAEnabled -> users with two-factor authentication need a TOTP or recovery code to sign in
+func (user *User) MFAEnabled() bool {
+	return user.TOTPEnabledAt != nil
+}
+
+// SessionValid -> whether a token issued at issuedAt is still accepted
+func (user *User) SessionValid(issuedAt time.Time) bool {
+	if user.SessionsRevokedAt == nil {
+		return true
+	}
+
+	// Token times have a precision of a second
+	return !issuedAt.Before(user.SessionsRevokedAt.Truncate(time.Second))
+}
+
+// Hash -> Generate hash for given password, with the hasher set by passwords.Use
 func Hash(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := passwords.Hash(password)
+	return []byte(hash), err
 }
 
-// VerifyPassword -> Verify a password given it's hash
+// VerifyPassword -> Verify a password given it's hash, of any algorithm passwords supports
 func VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return passwords.Verify(hashedPassword, password)
+}
+
+// HasPassword -> users created through an identity provider have no local password until they reset it
+func (user *User) HasPassword() bool {
+	return user.Password != ""
 }
 
-// BeforeSave will check hashes for passwords
+// BeforeSave will check hashes for passwords, an empty password stays empty
 func (user *User) BeforeSave() error {
+	if user.Password == "" {
+		return nil
+	}
+
 	hashedPassword, err := Hash(user.Password)
 	if err != nil {
 		return err
@@ -64,6 +119,10 @@ func (user *User) Validate(action string) error {
 			return errors.New("Invalid Email")
 		}
 
+		if err := passwords.Check(user.Password, user.Email); err != nil {
+			return err
+		}
+
 		if user.FirstName == "" {
 			return errors.New("Required First Name")
 		}
@@ -74,6 +133,17 @@ func (user *User) Validate(action string) error {
 
 		return nil
 
+	case "update":
+		if user.Email == "" {
+			return nil
+		}
+
+		if err := checkmail.ValidateFormat(user.Email); err != nil {
+			return errors.New("Invalid Email")
+		}
+
+		return nil
+
 	case "login":
 		if user.Email == "" {
 			return errors.New("Required Email")
@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+// Queue -> persists emails that couldn't be sent right away
+type Queue interface {
+	EnqueueEmail(model.Email) (*model.Email, error)
+	DeliverQueuedEmails(time.Time, func(model.Email) error) (int, error)
+}
+
+// EmailNotifier -> renders notifications and sends them, failed sends are queued and retried
+type EmailNotifier struct {
+	sender Sender
+	queue  Queue
+	logger logger.Logger
+}
+
+// NewEmailNotifier ...
+func NewEmailNotifier(sender Sender, queue Queue, logger logger.Logger) *EmailNotifier {
+	return &EmailNotifier{
+		sender: sender,
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+// Notify -> only fails when the notification can be neither sent nor queued, emails carrying a token are never queued
+func (notifier *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	message, err := Render(notification)
+	if err != nil {
+		return err
+	}
+
+	err = notifier.sender.Send(ctx, message)
+	if err == nil {
+		return nil
+	}
+
+	if unqueuedTemplates[notification.Template] {
+		return err
+	}
+
+	notifier.logger.Warnf("Failed to send %s email, queueing it: %s", notification.Template, err.Error())
+
+	email := model.Email{
+		To:        message.To,
+		Subject:   message.Subject,
+		Text:      message.Text,
+		HTML:      message.HTML,
+		Attempts:  1,
+		LastError: err.Error(),
+	}
+	email.NextAttemptAt = time.Now().Add(email.Backoff())
+
+	_, err = notifier.queue.EnqueueEmail(email)
+	return err
+}
+
+// Retry -> sends the queued emails due at now, returns how many were sent
+func (notifier *EmailNotifier) Retry(ctx context.Context, now time.Time) (int, error) {
+	return notifier.queue.DeliverQueuedEmails(now, func(email model.Email) error {
+		return notifier.sender.Send(ctx, Message{
+			To:      email.To,
+			Subject: email.Subject,
+			Text:    email.Text,
+			HTML:    email.HTML,
+		})
+	})
+}
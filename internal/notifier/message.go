@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Message -> rendered email
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Bytes -> RFC 5322 email with text and HTML alternatives
+func (message Message) Bytes(from string, date time.Time) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.TrimSuffix(from[at+1:], ">")
+	}
+
+	body := multipart.NewWriter(&buffer)
+	headers := []string{
+		"From: " + headerValue(from),
+		"To: " + headerValue(message.To),
+		"Subject: " + mime.QEncoding.Encode("utf-8", message.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", uuid.NewV4().String(), domain),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+
+	// Headers go before the parts, the boundary is already known
+	var email bytes.Buffer
+	email.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(writer)
+		_, err = encoder.Write([]byte(part.content))
+		if err != nil {
+			return nil, err
+		}
+
+		err = encoder.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	email.Write(buffer.Bytes())
+	return email.Bytes(), nil
+}
+
+// headerValue -> line breaks would let the value add headers of its own
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
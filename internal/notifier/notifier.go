@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Notification -> message for a user, rendered from one of the templates
+type Notification struct {
+	UserID   uuid.UUID
+	To       string
+	Template string
+	Data     map[string]interface{}
+}
+
+// Notifier -> delivers notifications to users
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogNotifier -> writes notifications to the log, used when nothing else is configured
+type LogNotifier struct {
+	logger logger.Logger
+}
+
+// NewLogNotifier ...
+func NewLogNotifier(logger logger.Logger) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
+
+// Notify ...
+func (notifier *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	message, err := Render(notification)
+	if err != nil {
+		return err
+	}
+
+	notifier.logger.WithFields(logger.Fields{
+		"user_id":  notification.UserID.String(),
+		"to":       notification.To,
+		"template": notification.Template,
+	}).Infof("Notification: %s", message.Subject)
+	return nil
+}
@@ -0,0 +1,254 @@
+// +build !integration
+
+package notifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+var reminder = Notification{
+	To:       "jane@example.com",
+	Template: TemplateReminder,
+	Data: map[string]interface{}{
+		"Message":  "Follow up with <b>Bob</b>",
+		"JobTitle": "Software Engineer",
+		"Company":  "GoCardless",
+	},
+}
+
+type failingSender struct{}
+
+func (sender failingSender) Send(ctx context.Context, message Message) error {
+	return errors.New("Connection refused")
+}
+
+func newLogger() logger.Logger {
+	logger, err := logger.NewZapLogger(logger.Config{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return logger
+}
+
+func TestRender(t *testing.T) {
+
+	message, err := Render(reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, message.Subject, "Reminder: Software Engineer at GoCardless")
+	assert.Equal(t, strings.Contains(message.Text, "Follow up with <b>Bob</b>"), true)
+	assert.Equal(t, strings.Contains(message.HTML, "Follow up with &lt;b&gt;Bob&lt;/b&gt;"), true)
+
+	_, err = Render(Notification{Template: "unknown"})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestMessageBytes(t *testing.T) {
+
+	message, err := Render(reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.To = "jane@example.com\r\nBcc: everyone@example.com"
+
+	email, err := message.Bytes("Trackr <no-reply@trackr.local>", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(email))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, subject, "Reminder: Software Engineer at GoCardless")
+	assert.Equal(t, mediaType, "multipart/alternative")
+	assert.Equal(t, parsed.Header.Get("Bcc"), "")
+}
+
+func TestFileSender(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sender, err := NewFileSender(dir, "no-reply@trackr.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := NewEmailNotifier(sender, &mock.Repository{}, newLogger())
+	err = notifier.Notify(context.Background(), reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, len(files), 1)
+}
+
+// serveSMTP -> accepts one message and sends its data on the returned channel
+func serveSMTP(t *testing.T) (string, int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := make(chan string, 1)
+	go func() {
+		defer listener.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var body strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				data <- body.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber, data
+}
+
+func TestSMTPSender(t *testing.T) {
+
+	host, port, data := serveSMTP(t)
+	sender := NewSMTPSender(SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "Trackr <no-reply@trackr.local>",
+	})
+
+	message, err := Render(reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sender.Send(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case email := <-data:
+		assert.Equal(t, strings.Contains(email, "To: jane@example.com"), true)
+	case <-time.After(time.Second):
+		t.Fatal("SMTP server didn't receive the message")
+	}
+}
+
+func TestEmailNotifier_Queue(t *testing.T) {
+
+	queue := &mock.Repository{
+		ReturnObject: &[]model.Email{{To: "jane@example.com"}},
+	}
+	notifier := NewEmailNotifier(failingSender{}, queue, newLogger())
+
+	err := notifier.Notify(context.Background(), reminder)
+	assert.Equal(t, err, nil)
+
+	sent, err := notifier.Retry(context.Background(), time.Now())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, sent, 0)
+
+	queue.IsError = true
+	queue.ErrorMessage = "Table 'emails' doesn't exist"
+	err = notifier.Notify(context.Background(), reminder)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestEmailNotifier_QueueWithoutToken(t *testing.T) {
+
+	queue := &mock.Repository{}
+	notifier := NewEmailNotifier(failingSender{}, queue, newLogger())
+
+	err := notifier.Notify(context.Background(), Notification{
+		To:       "jane@example.com",
+		Template: TemplateResetPassword,
+		Data: map[string]interface{}{
+			"FirstName": "Jane",
+			"Link":      "https://trackr.local/reset-password?token=secret-token",
+			"ExpiresIn": "30 minutes",
+		},
+	})
+	assert.NotEqual(t, err, nil)
+
+	err = notifier.Notify(context.Background(), reminder)
+	assert.Equal(t, err, nil)
+
+	assert.Equal(t, len(queue.Emails), 1)
+	for _, email := range queue.Emails {
+		assert.Equal(t, strings.Contains(email.Text+email.HTML, "secret-token"), false)
+	}
+}
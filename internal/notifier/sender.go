@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Sender -> delivers rendered messages
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTPConfig ...
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Authentication is skipped without a username, as with MailHog
+	Password string
+	From     string
+}
+
+// SMTPSender -> sends messages through an SMTP server, using STARTTLS when the server offers it
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender ...
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	if config.Port == 0 {
+		config.Port = 25
+	}
+
+	return &SMTPSender{
+		config: config,
+	}
+}
+
+// Send ...
+func (sender *SMTPSender) Send(ctx context.Context, message Message) error {
+	email, err := message.Bytes(sender.config.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if sender.config.Username != "" {
+		auth = smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+	}
+
+	address := net.JoinHostPort(sender.config.Host, strconv.Itoa(sender.config.Port))
+	return smtp.SendMail(address, auth, envelopeAddress(sender.config.From), []string{envelopeAddress(message.To)}, email)
+}
+
+// envelopeAddress -> address without its display name, "Trackr <no-reply@trackr.io>" becomes no-reply@trackr.io
+func envelopeAddress(address string) string {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return address
+	}
+	return parsed.Address
+}
+
+// FileSender -> writes messages as .eml files, for local development
+type FileSender struct {
+	dir  string
+	from string
+}
+
+// NewFileSender ...
+func NewFileSender(dir, from string) (*FileSender, error) {
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSender{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+// Send ...
+func (sender *FileSender) Send(ctx context.Context, message Message) error {
+	now := time.Now()
+	email, err := message.Bytes(sender.from, now)
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), uuid.NewV4().String())
+	return ioutil.WriteFile(filepath.Join(sender.dir, fileName), email, 0640)
+}
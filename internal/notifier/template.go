@@ -0,0 +1,121 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+// Notification templates
+const (
+	TemplateReminder        = "reminder"
+	TemplateVerifyEmail     = "verify_email"
+	TemplateResetPassword   = "reset_password"
+	TemplateAccountDeletion = "account_deletion"
+)
+
+// unqueuedTemplates -> emails carrying a token aren't queued when sending fails, the token would be stored
+// in plain text with the email. Users ask for a new link instead.
+var unqueuedTemplates = map[string]bool{
+	TemplateVerifyEmail:   true,
+	TemplateResetPassword: true,
+}
+
+// emailTemplate -> subject and text body are plain text, the HTML body is escaped
+type emailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+var emailTemplates = map[string]emailTemplate{
+	TemplateReminder: newEmailTemplate(TemplateReminder,
+		`Reminder{{if .JobTitle}}: {{.JobTitle}} at {{.Company}}{{end}}`,
+		`{{.Message}}
+{{if .JobTitle}}
+Application: {{.JobTitle}} at {{.Company}}
+{{end}}`,
+		`<p>{{.Message}}</p>
+{{if .JobTitle}}<p>Application: <strong>{{.JobTitle}}</strong> at {{.Company}}</p>{{end}}`,
+	),
+	TemplateVerifyEmail: newEmailTemplate(TemplateVerifyEmail,
+		`Verify your email address`,
+		`Hi {{.FirstName}},
+
+Please verify your email address by opening the link below:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}}. If you didn't create an account, you can ignore this email.`,
+		`<p>Hi {{.FirstName}},</p>
+<p>Please verify your email address by opening the link below:</p>
+<p><a href="{{.Link}}">Verify my email address</a></p>
+<p>The link expires in {{.ExpiresIn}}. If you didn't create an account, you can ignore this email.</p>`,
+	),
+	TemplateResetPassword: newEmailTemplate(TemplateResetPassword,
+		`Reset your password`,
+		`Hi {{.FirstName}},
+
+Someone asked to reset the password of your account. To choose a new password, open the link below:
+
+{{.Link}}
+
+The link expires in {{.ExpiresIn}} and can only be used once. If you didn't ask for a new password, you can ignore this email.`,
+		`<p>Hi {{.FirstName}},</p>
+<p>Someone asked to reset the password of your account. To choose a new password, open the link below:</p>
+<p><a href="{{.Link}}">Reset my password</a></p>
+<p>The link expires in {{.ExpiresIn}} and can only be used once. If you didn't ask for a new password, you can ignore this email.</p>`,
+	),
+	TemplateAccountDeletion: newEmailTemplate(TemplateAccountDeletion,
+		`Your account will be deleted`,
+		`Hi {{.FirstName}},
+
+As you asked, your account and all of its data will be deleted on {{.DeleteOn}}.
+
+If you change your mind, sign in before then and your account will be kept.`,
+		`<p>Hi {{.FirstName}},</p>
+<p>As you asked, your account and all of its data will be deleted on {{.DeleteOn}}.</p>
+<p>If you change your mind, sign in before then and your account will be kept.</p>`,
+	),
+}
+
+func newEmailTemplate(name, subject, text, html string) emailTemplate {
+	return emailTemplate{
+		subject: texttemplate.Must(texttemplate.New(name + ".subject").Option("missingkey=zero").Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New(name + ".text").Option("missingkey=zero").Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.New(name + ".html").Option("missingkey=zero").Parse(html)),
+	}
+}
+
+// Render -> builds the message of the notification from its template
+func Render(notification Notification) (Message, error) {
+	template, ok := emailTemplates[notification.Template]
+	if !ok {
+		return Message{}, fmt.Errorf("Unknown template %q", notification.Template)
+	}
+
+	var subject, text, html bytes.Buffer
+
+	err := template.subject.Execute(&subject, notification.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = template.text.Execute(&text, notification.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = template.html.Execute(&html, notification.Data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      notification.To,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
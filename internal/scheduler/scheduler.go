@@ -0,0 +1,223 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+)
+
+// DefaultInterval -> time between two runs when none is configured
+const DefaultInterval = time.Minute
+
+// ReminderStore -> part of the repository the scheduler needs
+type ReminderStore interface {
+	FireDueReminders(time.Time, func(model.Reminder) error) (int, error)
+}
+
+// DeletionStore -> part of the repository deleting the users whose deletion is due
+type DeletionStore interface {
+	DueUserDeletions(time.Time) ([]string, error)
+	DeleteUser(string, time.Time) error
+}
+
+// TrashStore -> part of the repository purging the items deleted before a time
+type TrashStore interface {
+	PurgeTrash(time.Time, func(string) error) (int, error)
+}
+
+// Retrier -> notifiers queueing failed deliveries, the queue is retried on every run
+type Retrier interface {
+	Retry(context.Context, time.Time) (int, error)
+}
+
+// Scheduler -> fires due reminders through the notifier and runs the optional jobs at a regular interval
+type Scheduler struct {
+	store     ReminderStore
+	notifier  notifier.Notifier
+	logger    logger.Logger
+	interval  time.Duration
+	deletions DeletionStore
+	blobStore blob.Store
+	trash     TrashStore
+	retention time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option -> configures the optional jobs of a Scheduler
+type Option func(*Scheduler)
+
+// WithUserDeletions -> moves the users whose deletion is due to the trash, see WithTrashRetention
+func WithUserDeletions(deletions DeletionStore) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.deletions = deletions
+	}
+}
+
+// WithTrashRetention -> purges the items and users deleted longer than retention ago, along with their files in blobStore
+func WithTrashRetention(trash TrashStore, blobStore blob.Store, retention time.Duration) Option {
+	return func(scheduler *Scheduler) {
+		scheduler.trash = trash
+		scheduler.blobStore = blobStore
+		scheduler.retention = retention
+	}
+}
+
+// New ...
+func New(store ReminderStore, notifier notifier.Notifier, logger logger.Logger, interval time.Duration, options ...Option) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	scheduler := &Scheduler{
+		store:    store,
+		notifier: notifier,
+		logger:   logger,
+		interval: interval,
+	}
+
+	for _, option := range options {
+		option(scheduler)
+	}
+
+	return scheduler
+}
+
+// Start -> runs the scheduler in the background until Stop is called
+func (scheduler *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.cancel = cancel
+
+	scheduler.wg.Add(1)
+	go func() {
+		defer scheduler.wg.Done()
+
+		ticker := time.NewTicker(scheduler.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				scheduler.Run(ctx, now)
+			}
+		}
+	}()
+}
+
+// Stop -> waits for the current run to finish
+func (scheduler *Scheduler) Stop() {
+	if scheduler.cancel == nil {
+		return
+	}
+
+	scheduler.cancel()
+	scheduler.wg.Wait()
+}
+
+// Run -> fires the reminders due at now and runs the optional jobs, returns how many reminders fired
+func (scheduler *Scheduler) Run(ctx context.Context, now time.Time) int {
+	fired, err := scheduler.store.FireDueReminders(now, func(reminder model.Reminder) error {
+		return scheduler.notifier.Notify(ctx, reminderNotification(reminder))
+	})
+	if err != nil {
+		scheduler.logger.Errorf("Failed to fire due reminders: %s", err.Error())
+	}
+
+	if fired > 0 {
+		scheduler.logger.Infof("Fired %d reminders", fired)
+	}
+
+	if retrier, ok := scheduler.notifier.(Retrier); ok {
+		sent, err := retrier.Retry(ctx, now)
+		if err != nil {
+			scheduler.logger.Errorf("Failed to retry queued notifications: %s", err.Error())
+		}
+
+		if sent > 0 {
+			scheduler.logger.Infof("Sent %d queued notifications", sent)
+		}
+	}
+
+	if scheduler.deletions != nil {
+		deleted := scheduler.deleteUsers(now)
+		if deleted > 0 {
+			scheduler.logger.Infof("Deleted %d users", deleted)
+		}
+	}
+
+	if scheduler.trash != nil {
+		purged, err := scheduler.trash.PurgeTrash(now.Add(-scheduler.retention), scheduler.deleteBlob)
+		if err != nil {
+			scheduler.logger.Errorf("Failed to purge the trash: %s", err.Error())
+		}
+
+		if purged > 0 {
+			scheduler.logger.Infof("Purged %d items from the trash", purged)
+		}
+	}
+
+	return fired
+}
+
+// deleteUsers -> moves the users due at now to the trash, returns how many were deleted
+func (scheduler *Scheduler) deleteUsers(now time.Time) int {
+	ids, err := scheduler.deletions.DueUserDeletions(now)
+	if err != nil {
+		scheduler.logger.Errorf("Failed to get the users due for deletion: %s", err.Error())
+		return 0
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		err := scheduler.deletions.DeleteUser(id, now)
+		if err == storage.ErrUserNotFound {
+			// Signed in meanwhile, or deleted by another replica
+			continue
+		}
+
+		if err != nil {
+			scheduler.logger.Errorf("Failed to delete user %s: %s", id, err.Error())
+			continue
+		}
+		deleted++
+	}
+
+	return deleted
+}
+
+// deleteBlob -> removes the content of purged documents, called by the trash once nothing references it
+func (scheduler *Scheduler) deleteBlob(hash string) error {
+	if scheduler.blobStore == nil {
+		return nil
+	}
+
+	err := scheduler.blobStore.Delete(hash)
+	if err == blob.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func reminderNotification(reminder model.Reminder) notifier.Notification {
+	return notifier.Notification{
+		UserID:   reminder.UserID,
+		To:       reminder.User.Email,
+		Template: notifier.TemplateReminder,
+		Data: map[string]interface{}{
+			"Message":  reminder.Message,
+			"DueAt":    reminder.DueAt,
+			"JobTitle": reminder.Application.JobTitle,
+			"Company":  reminder.Application.Company,
+		},
+	}
+}
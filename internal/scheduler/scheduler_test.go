@@ -0,0 +1,202 @@
+// +build !integration
+
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
+	"github.com/amaraliou/trackr-core/internal/storage/mock"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+type recordingNotifier struct {
+	notifications []notifier.Notification
+	err           error
+}
+
+func (notifier *recordingNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	if notifier.err != nil {
+		return notifier.err
+	}
+	notifier.notifications = append(notifier.notifications, notification)
+	return nil
+}
+
+func newLogger() logger.Logger {
+	logger, err := logger.NewZapLogger(logger.Config{
+		EnableConsole: false,
+		EnableFile:    false,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return logger
+}
+
+func TestRun(t *testing.T) {
+
+	userID := uuid.NewV4()
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{
+			{
+				Message:     "Follow up with the recruiter",
+				Application: model.Application{JobTitle: "Software Engineer", Company: "GoCardless"},
+				User:        model.User{Email: "jane@example.com"},
+				UserID:      userID,
+			},
+		},
+	}
+	recorder := &recordingNotifier{}
+
+	scheduler := New(store, recorder, newLogger(), time.Minute)
+	fired := scheduler.Run(context.Background(), time.Now())
+
+	assert.Equal(t, fired, 1)
+	assert.Equal(t, len(recorder.notifications), 1)
+	assert.Equal(t, recorder.notifications[0].To, "jane@example.com")
+	assert.Equal(t, recorder.notifications[0].Template, notifier.TemplateReminder)
+	assert.Equal(t, recorder.notifications[0].Data["Message"], "Follow up with the recruiter")
+}
+
+func TestRun_NotifierError(t *testing.T) {
+
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{{Message: "Follow up"}},
+	}
+	recorder := &recordingNotifier{err: errors.New("SMTP unavailable")}
+
+	scheduler := New(store, recorder, newLogger(), time.Minute)
+	fired := scheduler.Run(context.Background(), time.Now())
+
+	assert.Equal(t, fired, 0)
+}
+
+// recordingDeletions keeps the users deleted, due is the only one due for deletion
+type recordingDeletions struct {
+	due     string
+	deleted []string
+}
+
+func (deletions *recordingDeletions) DueUserDeletions(now time.Time) ([]string, error) {
+	return []string{deletions.due, uuid.NewV4().String()}, nil
+}
+
+func (deletions *recordingDeletions) DeleteUser(id string, now time.Time) error {
+	if id != deletions.due {
+		// Signed in since
+		return storage.ErrUserNotFound
+	}
+
+	deletions.deleted = append(deletions.deleted, id)
+	return nil
+}
+
+func TestRun_DeletesUsers(t *testing.T) {
+
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{},
+	}
+	deletions := &recordingDeletions{due: uuid.NewV4().String()}
+
+	scheduler := New(store, &recordingNotifier{}, newLogger(), time.Minute, WithUserDeletions(deletions))
+	scheduler.Run(context.Background(), time.Now())
+
+	assert.Equal(t, deletions.deleted, []string{deletions.due})
+}
+
+// recordingTrash keeps the cutoffs the trash was purged with
+type recordingTrash struct {
+	cutoffs []time.Time
+	orphans []string
+}
+
+func (trash *recordingTrash) PurgeTrash(before time.Time, remove func(string) error) (int, error) {
+	trash.cutoffs = append(trash.cutoffs, before)
+	for _, hash := range trash.orphans {
+		remove(hash)
+	}
+	return 1, nil
+}
+
+func TestRun_PurgesTrash(t *testing.T) {
+
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{},
+	}
+	trash := &recordingTrash{}
+
+	scheduler := New(store, &recordingNotifier{}, newLogger(), time.Minute, WithTrashRetention(trash, nil, 24*time.Hour))
+	now := time.Now()
+	scheduler.Run(context.Background(), now)
+
+	assert.Equal(t, len(trash.cutoffs), 1)
+	assert.Equal(t, trash.cutoffs[0], now.Add(-24*time.Hour))
+}
+
+func TestRun_PurgesTrashContent(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "documents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	blobStore, err := blob.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("%PDF-1.4")
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	err = blobStore.Put(hash, bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{},
+	}
+	trash := &recordingTrash{orphans: []string{hash}}
+
+	scheduler := New(store, &recordingNotifier{}, newLogger(), time.Minute, WithTrashRetention(trash, blobStore, 24*time.Hour))
+	scheduler.Run(context.Background(), time.Now())
+
+	exists, err := blobStore.Exists(hash)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exists, false)
+}
+
+func TestStartStop(t *testing.T) {
+
+	store := &mock.Repository{
+		ReturnObject: &[]model.Reminder{{Message: "Follow up"}},
+	}
+	recorder := &recordingNotifier{}
+
+	scheduler := New(store, recorder, newLogger(), 10*time.Millisecond)
+	scheduler.Start()
+	time.Sleep(50 * time.Millisecond)
+	scheduler.Stop()
+
+	count := len(recorder.notifications)
+	time.Sleep(30 * time.Millisecond)
+
+	assert.Equal(t, count > 0, true)
+	assert.Equal(t, len(recorder.notifications), count)
+}
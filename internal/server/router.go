@@ -3,20 +3,26 @@ package server
 import (
 	"github.com/amaraliou/trackr-core/internal/handler"
 	trackrMiddleware "github.com/amaraliou/trackr-core/internal/middleware"
+	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
 )
 
-// NewRouter ...
-func (server *Server) NewRouter(handler *handler.Handler) error {
+// NewRouter -> sessions is used to check the tokens of authenticated requests
+func (server *Server) NewRouter(handler *handler.Handler, sessions trackrMiddleware.SessionStore) error {
 	router := chi.NewRouter()
+	setAuth := trackrMiddleware.SetAuth(sessions)
+	requireAdmin := trackrMiddleware.RequireRole(model.RoleAdmin)
+	requireStaff := trackrMiddleware.RequireRole(model.RoleAdmin, model.RoleSupport)
+	sessionOnly := trackrMiddleware.SessionOnly
 
 	// Cors
 	cors := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"WWW-Authenticate"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	})
@@ -27,14 +33,99 @@ func (server *Server) NewRouter(handler *handler.Handler) error {
 	router.Use(trackrMiddleware.SetJSON)
 	router.Use(middleware.Heartbeat("/ping"))
 
+	router.Get("/.well-known/jwks.json", handler.JWKS)
+
 	router.Route("/api/v1", func(r chi.Router) {
 		r.Post("/auth/login", handler.Login)
+		r.Get("/auth/verify", handler.VerifyEmail)
+		r.Post("/auth/verify/resend", handler.ResendVerification)
+		r.Post("/auth/forgot-password", handler.ForgotPassword)
+		r.Post("/auth/reset-password", handler.ResetPassword)
+		r.Post("/auth/refresh", handler.RefreshToken)
+		r.With(setAuth).Post("/auth/logout", handler.Logout)
+		r.With(setAuth, sessionOnly).Post("/auth/logout-all", handler.LogoutAll)
+		r.Post("/auth/mfa/verify", handler.VerifyMFA)
+		r.Get("/auth/oidc/{provider}", handler.OIDCAuthorize)
+		r.Post("/auth/oidc/{provider}/callback", handler.OIDCCallback)
+		r.With(setAuth, sessionOnly).Post("/auth/mfa/totp", handler.SetupTOTP)
+		r.With(setAuth, sessionOnly).Post("/auth/mfa/totp/confirm", handler.ConfirmTOTP)
+		r.With(setAuth, sessionOnly).Delete("/auth/mfa/totp", handler.DisableTOTP)
+		r.With(setAuth, sessionOnly).Post("/auth/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
 
 		r.Post("/users", handler.CreateUser)
-		r.Get("/users", handler.GetAllUsers)
-		r.With(trackrMiddleware.SetAuth).Get("/users/{id}", handler.GetUser)
-		r.With(trackrMiddleware.SetAuth).Put("/users/{id}", handler.UpdateUser)
-		r.With(trackrMiddleware.SetAuth).Delete("/users/{id}", handler.DeleteUser)
+		r.With(setAuth, requireAdmin).Get("/users", handler.GetAllUsers)
+		r.With(setAuth).Get("/users/{id}", handler.GetUser)
+		r.With(setAuth, sessionOnly).Put("/users/{id}", handler.UpdateUser)
+		r.With(setAuth, sessionOnly).Patch("/users/{id}", handler.UpdateUser)
+		r.With(setAuth, sessionOnly).Post("/users/{id}/password", handler.ChangePassword)
+		r.With(setAuth, sessionOnly).Delete("/users/{id}", handler.DeleteUser)
+		r.With(setAuth, sessionOnly).Get("/users/{id}/export", handler.ExportUser)
+
+		r.With(setAuth, sessionOnly).Post("/api-keys", handler.CreateAPIKey)
+		r.With(setAuth, sessionOnly).Get("/api-keys", handler.GetAllAPIKeys)
+		r.With(setAuth, sessionOnly).Delete("/api-keys/{id}", handler.RevokeAPIKey)
+
+		r.With(setAuth, requireAdmin).Post("/admin/users/{id}/disable", handler.DisableUser)
+		r.With(setAuth, requireAdmin).Post("/admin/users/{id}/enable", handler.EnableUser)
+		r.With(setAuth, requireAdmin).Post("/admin/users/{id}/restore", handler.RestoreUser)
+		r.With(setAuth, requireAdmin).Get("/admin/trash", handler.GetDeletedUsers)
+		r.With(setAuth, requireStaff).Post("/admin/users/{id}/impersonate", handler.ImpersonateUser)
+
+		r.With(setAuth).Post("/applications", handler.CreateApplication)
+		r.With(setAuth).Get("/applications", handler.GetAllApplications)
+		r.With(setAuth).Get("/applications/{id}", handler.GetApplication)
+		r.With(setAuth).Put("/applications/{id}", handler.UpdateApplication)
+		r.With(setAuth).Patch("/applications/{id}", handler.PatchApplication)
+		r.With(setAuth).Post("/applications/{id}/status", handler.ChangeApplicationStatus)
+		r.With(setAuth).Get("/applications/{id}/timeline", handler.GetApplicationTimeline)
+		r.With(setAuth).Post("/applications/{id}/interviews", handler.CreateInterview)
+		r.With(setAuth).Get("/applications/{id}/interviews", handler.GetAllInterviews)
+		r.With(setAuth).Get("/applications/{id}/interviews/{interviewID}", handler.GetInterview)
+		r.With(setAuth).Put("/applications/{id}/interviews/{interviewID}", handler.UpdateInterview)
+		r.With(setAuth).Patch("/applications/{id}/interviews/{interviewID}", handler.UpdateInterview)
+		r.With(setAuth).Delete("/applications/{id}/interviews/{interviewID}", handler.DeleteInterview)
+		r.With(setAuth).Post("/applications/{id}/interviews/{interviewID}/restore", handler.RestoreInterview)
+		r.With(setAuth).Get("/applications/{id}/documents", handler.GetApplicationDocuments)
+		r.With(setAuth).Put("/applications/{id}/documents/{documentID}", handler.LinkDocument)
+		r.With(setAuth).Delete("/applications/{id}/documents/{documentID}", handler.UnlinkDocument)
+		r.With(setAuth).Get("/applications/{id}/contacts", handler.GetApplicationContacts)
+		r.With(setAuth).Put("/applications/{id}/contacts/{contactID}", handler.LinkContact)
+		r.With(setAuth).Delete("/applications/{id}/contacts/{contactID}", handler.UnlinkContact)
+		r.With(setAuth).Post("/applications/{id}/reminders", handler.CreateReminder)
+		r.With(setAuth).Get("/applications/{id}/reminders", handler.GetAllReminders)
+		r.With(setAuth).Post("/applications/{id}/reminders/{reminderID}/snooze", handler.SnoozeReminder)
+		r.With(setAuth).Post("/applications/{id}/reminders/{reminderID}/dismiss", handler.DismissReminder)
+		r.With(setAuth).Get("/reminders", handler.GetActiveReminders)
+
+		r.With(setAuth).Post("/companies", handler.CreateCompany)
+		r.With(setAuth).Get("/companies", handler.GetAllCompanies)
+		r.With(setAuth).Get("/companies/{id}", handler.GetCompany)
+		r.With(setAuth).Put("/companies/{id}", handler.UpdateCompany)
+		r.With(setAuth).Patch("/companies/{id}", handler.UpdateCompany)
+		r.With(setAuth).Delete("/companies/{id}", handler.DeleteCompany)
+		r.With(setAuth).Post("/companies/{id}/restore", handler.RestoreCompany)
+		r.With(setAuth).Delete("/applications/{id}", handler.DeleteApplication)
+		r.With(setAuth).Post("/applications/{id}/restore", handler.RestoreApplication)
+
+		r.With(setAuth).Post("/documents", handler.CreateDocument)
+		r.With(setAuth).Get("/documents", handler.GetAllDocuments)
+		r.With(setAuth).Get("/documents/{id}", handler.GetDocument)
+		r.With(setAuth).Get("/documents/{id}/content", handler.DownloadDocument)
+		r.With(setAuth).Get("/documents/{id}/versions", handler.GetDocumentVersions)
+		r.With(setAuth).Delete("/documents/{id}", handler.DeleteDocument)
+		r.With(setAuth).Post("/documents/{id}/restore", handler.RestoreDocument)
+
+		r.With(setAuth).Post("/contacts", handler.CreateContact)
+		r.With(setAuth).Get("/contacts", handler.GetAllContacts)
+		r.With(setAuth).Get("/contacts/{id}", handler.GetContact)
+		r.With(setAuth).Put("/contacts/{id}", handler.UpdateContact)
+		r.With(setAuth).Patch("/contacts/{id}", handler.UpdateContact)
+		r.With(setAuth).Delete("/contacts/{id}", handler.DeleteContact)
+		r.With(setAuth).Post("/contacts/{id}/restore", handler.RestoreContact)
+		r.With(setAuth).Post("/contacts/{id}/interactions", handler.CreateContactInteraction)
+		r.With(setAuth).Get("/contacts/{id}/interactions", handler.GetContactInteractions)
+
+		r.With(setAuth).Get("/trash", handler.GetTrash)
 	})
 
 	server.Router = router
@@ -1,16 +1,30 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/auth/keys"
+	"github.com/amaraliou/trackr-core/internal/auth/oidc"
+	"github.com/amaraliou/trackr-core/internal/auth/passwords"
 	"github.com/amaraliou/trackr-core/internal/handler"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/notifier"
+	"github.com/amaraliou/trackr-core/internal/scheduler"
+	"github.com/amaraliou/trackr-core/internal/storage/blob"
 	"github.com/amaraliou/trackr-core/internal/storage/postgres"
 	"github.com/amaraliou/trackr-core/pkg/logger"
 	"github.com/go-chi/chi"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Server ...
@@ -19,6 +33,9 @@ type Server struct {
 	Handler *handler.Handler
 	Router  *chi.Mux
 	Server  *http.Server
+
+	Scheduler *scheduler.Scheduler
+	Keys      *keys.Manager
 }
 
 // NewServer ...
@@ -64,12 +81,113 @@ func NewServer() (*Server, error) {
 		return nil, err
 	}
 
+	// Initialize document storage
+	documentsPath := os.Getenv("DOCUMENTS_PATH")
+	if documentsPath == "" {
+		documentsPath = "documents"
+	}
+
+	blobStore, err := blob.NewLocalStore(documentsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDocumentSize, _ := strconv.ParseInt(os.Getenv("DOCUMENTS_MAX_SIZE"), 10, 64)
+
+	// API_SECRET keys emailed tokens and sealed TOTP secrets, it's read once here
+	err = auth.UseSecret(os.Getenv("API_SECRET"))
+	if err != nil {
+		return nil, fmt.Errorf("API_SECRET must be at least %d characters", auth.MinSecretLength)
+	}
+
+	// Initialize signing keys, replicas share them through KEYS_PATH
+	keyManager, err := newKeyManager(server.Logger)
+	if err != nil {
+		return nil, err
+	}
+	keyManager.Start(keys.DefaultInterval)
+	auth.UseKeys(keyManager)
+	server.Keys = keyManager
+
+	// TOKEN_SOURCES is a comma separated list of header, cookie and query
+	leeway, _ := time.ParseDuration(os.Getenv("TOKEN_LEEWAY"))
+	sources := []auth.TokenSource{}
+	for _, source := range strings.Split(os.Getenv("TOKEN_SOURCES"), ",") {
+		if strings.TrimSpace(source) != "" {
+			sources = append(sources, auth.TokenSource(strings.TrimSpace(source)))
+		}
+	}
+
+	auth.Configure(auth.Config{
+		Issuer:   os.Getenv("TOKEN_ISSUER"),
+		Audience: os.Getenv("TOKEN_AUDIENCE"),
+		Leeway:   leeway,
+		Sources:  sources,
+	})
+
+	// Initialize password hashing and policy, existing hashes are upgraded as users sign in
+	hasher, err := newPasswordHasher()
+	if err != nil {
+		return nil, err
+	}
+	passwords.Use(hasher)
+
+	policy, err := newPasswordPolicy()
+	if err != nil {
+		return nil, err
+	}
+	passwords.UsePolicy(policy)
+
+	// Initialize notifier
+	notifier, err := newNotifier(pgRepo, server.Logger)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize handler
-	handler := handler.New(pgRepo, server.Logger)
+	options := []handler.Option{
+		handler.WithBlobStore(blobStore, maxDocumentSize),
+		handler.WithNotifier(notifier, os.Getenv("APP_URL")),
+		handler.WithKeys(keyManager),
+	}
+
+	providers, err := newOIDCProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(providers) > 0 {
+		options = append(options, handler.WithOIDCProviders(providers...))
+	}
+
+	if os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true" {
+		options = append(options, handler.WithRequireVerifiedEmail())
+	}
+
+	// ACCOUNT_DELETION_GRACE_PERIOD is a duration, accounts are moved to the trash by the scheduler once it passes
+	gracePeriod, _ := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"))
+	options = append(options, handler.WithDeletionGracePeriod(gracePeriod))
+
+	// TRASH_RETENTION is a duration, deleted items are purged by the scheduler once it passes
+	retention, _ := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if retention <= 0 {
+		retention = model.DefaultTrashRetention
+	}
+	options = append(options, handler.WithTrashRetention(retention))
+
+	handler := handler.New(pgRepo, server.Logger, options...)
 	server.Handler = handler
 
 	// Initialize router
-	server.NewRouter(handler)
+	server.NewRouter(handler, pgRepo)
+
+	// Initialize reminder scheduler, replicas coordinate through a Postgres advisory lock
+	interval, _ := time.ParseDuration(os.Getenv("REMINDERS_INTERVAL"))
+	server.Scheduler = scheduler.New(pgRepo, notifier, server.Logger, interval,
+		scheduler.WithUserDeletions(pgRepo),
+		scheduler.WithTrashRetention(pgRepo, blobStore, retention),
+	)
+	server.Scheduler.Start()
 
 	// Initialize server
 	server.Server = &http.Server{
@@ -80,6 +198,163 @@ func NewServer() (*Server, error) {
 	return &server, nil
 }
 
+// newKeyManager -> KEYS_ALGORITHM selects RS256 (default) or EdDSA, KEYS_ROTATION and KEYS_OVERLAP are durations
+func newKeyManager(logger logger.Logger) (*keys.Manager, error) {
+
+	keysPath := os.Getenv("KEYS_PATH")
+	if keysPath == "" {
+		keysPath = "keys"
+	}
+
+	store, err := keys.NewDirStore(keysPath)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := os.Getenv("KEYS_ALGORITHM")
+	if algorithm == "" {
+		algorithm = keys.RS256
+	}
+
+	rotation, _ := time.ParseDuration(os.Getenv("KEYS_ROTATION"))
+	overlap, _ := time.ParseDuration(os.Getenv("KEYS_OVERLAP"))
+	if overlap > 0 && overlap <= auth.AccessTokenTTL {
+		return nil, errors.New("KEYS_OVERLAP must be longer than the access token lifetime")
+	}
+
+	return keys.NewManager(store, algorithm, rotation, overlap, logger)
+}
+
+// newOIDCProviders -> OIDC_PROVIDERS is a comma separated list of names, each configured by OIDC_<NAME>_DISCOVERY_URL,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_SCOPES (space separated) and OIDC_<NAME>_REDIRECT_URL.
+// Redirects go to APP_URL/auth/oidc/<name>/callback by default.
+func newOIDCProviders() ([]*oidc.Provider, error) {
+
+	providers := []*oidc.Provider{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		redirectURL := os.Getenv(prefix + "REDIRECT_URL")
+		if redirectURL == "" {
+			redirectURL = strings.TrimSuffix(os.Getenv("APP_URL"), "/") + "/auth/oidc/" + name + "/callback"
+		}
+
+		provider, err := oidc.NewProvider(oidc.Config{
+			Name:         name,
+			DiscoveryURL: os.Getenv(prefix + "DISCOVERY_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+			RedirectURL:  redirectURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// newPasswordHasher -> PASSWORD_HASHER selects bcrypt (default, cost BCRYPT_COST) or argon2id,
+// tuned by ARGON2_MEMORY in KiB, ARGON2_ITERATIONS and ARGON2_PARALLELISM
+func newPasswordHasher() (passwords.Hasher, error) {
+
+	switch os.Getenv("PASSWORD_HASHER") {
+	case "", "bcrypt":
+		hasher := passwords.DefaultBcrypt
+		if cost, _ := strconv.Atoi(os.Getenv("BCRYPT_COST")); cost > 0 {
+			hasher.Cost = cost
+		}
+
+		if hasher.Cost < bcrypt.MinCost || hasher.Cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return hasher, nil
+
+	case "argon2id":
+		hasher := passwords.DefaultArgon2id
+		if memory, _ := strconv.ParseUint(os.Getenv("ARGON2_MEMORY"), 10, 32); memory > 0 {
+			hasher.Memory = uint32(memory)
+		}
+
+		if iterations, _ := strconv.ParseUint(os.Getenv("ARGON2_ITERATIONS"), 10, 32); iterations > 0 {
+			hasher.Iterations = uint32(iterations)
+		}
+
+		if parallelism, _ := strconv.ParseUint(os.Getenv("ARGON2_PARALLELISM"), 10, 8); parallelism > 0 {
+			hasher.Parallelism = uint8(parallelism)
+		}
+		return hasher, nil
+
+	default:
+		return nil, errors.New("PASSWORD_HASHER must be bcrypt or argon2id")
+	}
+}
+
+// newPasswordPolicy -> PASSWORD_MIN_LENGTH overrides the default, BREACHED_PASSWORDS_FILE is a file of SHA-1 hashes
+// of breached passwords, see passwords.LoadBreachList
+func newPasswordPolicy() (passwords.Policy, error) {
+
+	policy := passwords.DefaultPolicy
+	if minLength, _ := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); minLength > 0 {
+		policy.MinLength = minLength
+	}
+
+	breachedFile := os.Getenv("BREACHED_PASSWORDS_FILE")
+	if breachedFile != "" {
+		list, err := passwords.LoadBreachList(breachedFile)
+		if err != nil {
+			return passwords.Policy{}, err
+		}
+		policy.Breached = list
+	}
+
+	return policy, nil
+}
+
+// newNotifier -> NOTIFIER selects smtp, file (.eml files in MAIL_PATH) or, by default, the log
+func newNotifier(queue notifier.Queue, logger logger.Logger) (notifier.Notifier, error) {
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Trackr <no-reply@trackr.local>"
+	}
+
+	switch os.Getenv("NOTIFIER") {
+	case "smtp":
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		sender := notifier.NewSMTPSender(notifier.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+		return notifier.NewEmailNotifier(sender, queue, logger), nil
+
+	case "file":
+		mailPath := os.Getenv("MAIL_PATH")
+		if mailPath == "" {
+			mailPath = "mail"
+		}
+
+		sender, err := notifier.NewFileSender(mailPath, from)
+		if err != nil {
+			return nil, err
+		}
+		return notifier.NewEmailNotifier(sender, queue, logger), nil
+
+	default:
+		return notifier.NewLogNotifier(logger), nil
+	}
+}
+
 // ListenAndServe ...
 func (server *Server) ListenAndServe() {
 	var err error
@@ -0,0 +1,17 @@
+package blob
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound ...
+var ErrNotFound = errors.New("Blob not found")
+
+// Store -> content addressed storage for document files, keys are SHA-256 hex digests
+type Store interface {
+	Put(key string, reader io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	Exists(key string) (bool, error)
+}
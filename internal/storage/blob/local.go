@@ -0,0 +1,114 @@
+package blob
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LocalStore -> Store writing blobs to the local filesystem
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore -> creates root if it doesn't exist
+func NewLocalStore(root string) (*LocalStore, error) {
+	err := os.MkdirAll(root, 0750)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{
+		root: root,
+	}, nil
+}
+
+// path -> blobs are spread over sub directories named after the first two characters of the key
+func (store *LocalStore) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", errors.New("Invalid blob key")
+	}
+	return filepath.Join(store.root, key[:2], key), nil
+}
+
+// Put -> writes to a temporary file first so readers never see partial blobs
+func (store *LocalStore) Put(key string, reader io.Reader) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0750)
+	if err != nil {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, reader)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// Get ...
+func (store *LocalStore) Get(key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return file, err
+}
+
+// Delete -> deleting a missing blob is not an error
+func (store *LocalStore) Delete(key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Exists ...
+func (store *LocalStore) Exists(key string) (bool, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
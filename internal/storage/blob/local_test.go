@@ -0,0 +1,70 @@
+// +build !integration
+
+package blob
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+const testKey = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func TestLocalStore(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "trackr-blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exists, err := store.Exists(testKey)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exists, false)
+
+	err = store.Put(testKey, bytes.NewBufferString("foo"))
+	assert.Equal(t, err, nil)
+
+	reader, err := store.Get(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(content), "foo")
+
+	err = store.Delete(testKey)
+	assert.Equal(t, err, nil)
+
+	_, err = store.Get(testKey)
+	assert.Equal(t, err, ErrNotFound)
+
+	err = store.Delete(testKey)
+	assert.Equal(t, err, nil)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "trackr-blobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	store, err := NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Put("../../etc/passwd", bytes.NewBufferString("foo"))
+	assert.Equal(t, err.Error(), "Invalid blob key")
+}
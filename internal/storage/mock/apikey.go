@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateAPIKey ...
+func (repo *Repository) CreateAPIKey(key model.APIKey) (*model.APIKey, error) {
+
+	if repo.IsError {
+		return &model.APIKey{}, errors.New(repo.ErrorMessage)
+	}
+
+	return &key, nil
+}
+
+// AllAPIKeys ...
+func (repo *Repository) AllAPIKeys(userID string) (*[]model.APIKey, error) {
+
+	returnObject := repo.returnObject("AllAPIKeys").(*model.APIKey)
+
+	if repo.IsError {
+		return &[]model.APIKey{}, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &[]model.APIKey{}, nil
+	}
+
+	return &[]model.APIKey{*returnObject}, nil
+}
+
+// RevokeAPIKey ...
+func (repo *Repository) RevokeAPIKey(id, userID string) error {
+
+	returnObject := repo.returnObject("RevokeAPIKey").(*model.APIKey)
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return storage.ErrAPIKeyNotFound
+	}
+
+	now := time.Now()
+	returnObject.RevokedAt = &now
+	return nil
+}
+
+// AuthenticateAPIKey ...
+func (repo *Repository) AuthenticateAPIKey(prefix, hash string) (*model.APIKey, error) {
+
+	returnObject := repo.returnObject("AuthenticateAPIKey").(*model.APIKey)
+
+	if repo.IsError {
+		return &model.APIKey{}, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.Prefix != prefix || returnObject.Hash != hash {
+		return &model.APIKey{}, storage.ErrTokenInvalid
+	}
+
+	return returnObject, nil
+}
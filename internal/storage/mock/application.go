@@ -4,12 +4,13 @@ import (
 	"errors"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 )
 
 // CreateApplication ...
 func (repo *Repository) CreateApplication(application model.Application) (*model.Application, error) {
 
-	returnObject := repo.ReturnObject.(*model.Application)
+	returnObject := repo.returnObject("CreateApplication").(*model.Application)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
@@ -18,50 +19,102 @@ func (repo *Repository) CreateApplication(application model.Application) (*model
 	return returnObject, nil
 }
 
-// GetApplication ...
-func (repo *Repository) GetApplication(id string) (*model.Application, error) {
+// GetApplication -> applications not owned by userID are reported as not found
+func (repo *Repository) GetApplication(id, userID string) (*model.Application, error) {
 
-	returnObject := repo.ReturnObject.(*model.Application)
+	returnObject := repo.returnObject("GetApplication").(*model.Application)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if returnObject.UserID.String() != userID {
+		return &model.Application{}, storage.ErrApplicationNotFound
+	}
+
 	return returnObject, nil
 }
 
-// UpdateApplication ...
-func (repo *Repository) UpdateApplication(application model.Application, id string) (*model.Application, error) {
+// UpdateApplication -> applications not owned by userID are reported as not found
+func (repo *Repository) UpdateApplication(application model.Application, id, userID string) (*model.Application, error) {
 
-	returnObject := repo.ReturnObject.(*model.Application)
+	returnObject := repo.returnObject("UpdateApplication").(*model.Application)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if returnObject.UserID.String() != userID {
+		return &model.Application{}, storage.ErrApplicationNotFound
+	}
+
 	return returnObject, nil
 }
 
-// DeleteApplication ...
-func (repo *Repository) DeleteApplication(id string) (int64, error) {
+// ReplaceApplication -> applications not owned by userID are reported as not found
+func (repo *Repository) ReplaceApplication(application model.Application, id, userID string) (*model.Application, error) {
 
-	returnObject := repo.ReturnObject.(int64)
+	returnObject := repo.returnObject("ReplaceApplication").(*model.Application)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if returnObject.UserID.String() != userID {
+		return &model.Application{}, storage.ErrApplicationNotFound
+	}
+
 	return returnObject, nil
 }
 
-// AllApplications ...
-func (repo *Repository) AllApplications() (*[]model.Application, error) {
+// DeleteApplication -> zero rows affected means nothing matched the scope
+func (repo *Repository) DeleteApplication(id, userID string) (int64, error) {
 
-	returnObject := repo.ReturnObject.(*[]model.Application)
+	returnObject := repo.returnObject("DeleteApplication").(int64)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if returnObject == 0 {
+		return 0, storage.ErrApplicationNotFound
+	}
+
 	return returnObject, nil
 }
+
+// RestoreApplication -> applications not owned by userID are reported as not found
+func (repo *Repository) RestoreApplication(id, userID string) (*model.Application, error) {
+
+	returnObject := repo.returnObject("RestoreApplication").(*model.Application)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Application{}, storage.ErrApplicationNotFound
+	}
+
+	returnObject.DeletedAt = nil
+	return returnObject, nil
+}
+
+// AllApplications -> only returns the applications owned by userID
+func (repo *Repository) AllApplications(userID string) (*[]model.Application, error) {
+
+	returnObject := repo.returnObject("AllApplications").(*[]model.Application)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	applications := []model.Application{}
+	for _, application := range *returnObject {
+		if application.UserID.String() == userID {
+			applications = append(applications, application)
+		}
+	}
+
+	return &applications, nil
+}
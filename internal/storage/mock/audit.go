@@ -0,0 +1,18 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// CreateAuditEvent -> keeps the event in AuditEvents
+func (repo *Repository) CreateAuditEvent(event model.AuditEvent) (*model.AuditEvent, error) {
+
+	if repo.IsError {
+		return &model.AuditEvent{}, errors.New(repo.ErrorMessage)
+	}
+
+	repo.AuditEvents = append(repo.AuditEvents, event)
+	return &event, nil
+}
@@ -0,0 +1,104 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateCompany ...
+func (repo *Repository) CreateCompany(company model.Company) (*model.Company, error) {
+
+	returnObject := repo.returnObject("CreateCompany").(*model.Company)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetCompany -> companies not owned by userID are reported as not found
+func (repo *Repository) GetCompany(id, userID string) (*model.Company, error) {
+
+	returnObject := repo.returnObject("GetCompany").(*model.Company)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Company{}, storage.ErrCompanyNotFound
+	}
+
+	return returnObject, nil
+}
+
+// UpdateCompany -> companies not owned by userID are reported as not found
+func (repo *Repository) UpdateCompany(company model.Company, id, userID string) (*model.Company, error) {
+
+	returnObject := repo.returnObject("UpdateCompany").(*model.Company)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Company{}, storage.ErrCompanyNotFound
+	}
+
+	return returnObject, nil
+}
+
+// DeleteCompany -> zero rows affected means nothing matched the scope
+func (repo *Repository) DeleteCompany(id, userID string) (int64, error) {
+
+	returnObject := repo.returnObject("DeleteCompany").(int64)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject == 0 {
+		return 0, storage.ErrCompanyNotFound
+	}
+
+	return returnObject, nil
+}
+
+// RestoreCompany -> companies not owned by userID are reported as not found
+func (repo *Repository) RestoreCompany(id, userID string) (*model.Company, error) {
+
+	returnObject := repo.returnObject("RestoreCompany").(*model.Company)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Company{}, storage.ErrCompanyNotFound
+	}
+
+	returnObject.DeletedAt = nil
+	return returnObject, nil
+}
+
+// AllCompanies -> only returns the companies owned by userID
+func (repo *Repository) AllCompanies(userID string) (*[]model.Company, error) {
+
+	returnObject := repo.returnObject("AllCompanies").(*[]model.Company)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	companies := []model.Company{}
+	for _, company := range *returnObject {
+		if company.UserID.String() == userID {
+			companies = append(companies, company)
+		}
+	}
+
+	return &companies, nil
+}
@@ -0,0 +1,184 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateContact ...
+func (repo *Repository) CreateContact(contact model.Contact) (*model.Contact, error) {
+
+	returnObject := repo.returnObject("CreateContact").(*model.Contact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetContact -> contacts not owned by userID are reported as not found
+func (repo *Repository) GetContact(id, userID string) (*model.Contact, error) {
+
+	returnObject := repo.returnObject("GetContact").(*model.Contact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Contact{}, storage.ErrContactNotFound
+	}
+
+	return returnObject, nil
+}
+
+// UpdateContact -> contacts not owned by userID are reported as not found
+func (repo *Repository) UpdateContact(contact model.Contact, id, userID string) (*model.Contact, error) {
+
+	returnObject := repo.returnObject("UpdateContact").(*model.Contact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Contact{}, storage.ErrContactNotFound
+	}
+
+	return returnObject, nil
+}
+
+// DeleteContact -> zero rows affected means nothing matched the scope
+func (repo *Repository) DeleteContact(id, userID string) (int64, error) {
+
+	returnObject := repo.returnObject("DeleteContact").(int64)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject == 0 {
+		return 0, storage.ErrContactNotFound
+	}
+
+	return returnObject, nil
+}
+
+// RestoreContact -> contacts not owned by userID are reported as not found
+func (repo *Repository) RestoreContact(id, userID string) (*model.Contact, error) {
+
+	returnObject := repo.returnObject("RestoreContact").(*model.Contact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Contact{}, storage.ErrContactNotFound
+	}
+
+	returnObject.DeletedAt = nil
+	return returnObject, nil
+}
+
+// AllContacts -> only returns the contacts owned by userID
+func (repo *Repository) AllContacts(userID string) (*[]model.Contact, error) {
+
+	returnObject := repo.returnObject("AllContacts").(*[]model.Contact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	contacts := []model.Contact{}
+	for _, contact := range *returnObject {
+		if contact.UserID.String() == userID {
+			contacts = append(contacts, contact)
+		}
+	}
+
+	return &contacts, nil
+}
+
+// LinkContact -> contacts not owned by userID are reported as not found
+func (repo *Repository) LinkContact(link model.ApplicationContact, userID string) (*model.ApplicationContact, error) {
+
+	returnObject := repo.returnObject("LinkContact").(*model.ApplicationContact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.Contact.UserID.String() != userID {
+		return &model.ApplicationContact{}, storage.ErrContactNotFound
+	}
+
+	return returnObject, nil
+}
+
+// UnlinkContact ...
+func (repo *Repository) UnlinkContact(contactID, applicationID, userID string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// ApplicationContacts -> only returns the contacts owned by userID
+func (repo *Repository) ApplicationContacts(applicationID, userID string) (*[]model.ApplicationContact, error) {
+
+	returnObject := repo.returnObject("ApplicationContacts").(*[]model.ApplicationContact)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	links := []model.ApplicationContact{}
+	for _, link := range *returnObject {
+		if link.Contact.UserID.String() == userID {
+			links = append(links, link)
+		}
+	}
+
+	return &links, nil
+}
+
+// CreateContactInteraction -> interactions with contacts not owned by the user are reported as not found
+func (repo *Repository) CreateContactInteraction(interaction model.ContactInteraction) (*model.ContactInteraction, error) {
+
+	returnObject := repo.returnObject("CreateContactInteraction").(*model.ContactInteraction)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID != interaction.UserID {
+		return &model.ContactInteraction{}, storage.ErrContactNotFound
+	}
+
+	return returnObject, nil
+}
+
+// AllContactInteractions -> only returns the interactions owned by userID
+func (repo *Repository) AllContactInteractions(contactID, userID string) (*[]model.ContactInteraction, error) {
+
+	returnObject := repo.returnObject("AllContactInteractions").(*[]model.ContactInteraction)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	interactions := []model.ContactInteraction{}
+	for _, interaction := range *returnObject {
+		if interaction.UserID.String() == userID {
+			interactions = append(interactions, interaction)
+		}
+	}
+
+	return &interactions, nil
+}
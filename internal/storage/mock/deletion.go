@@ -0,0 +1,105 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// ScheduleUserDeletion -> users other than userID are forbidden
+func (repo *Repository) ScheduleUserDeletion(id string, dueAt time.Time, userID string) (*model.User, error) {
+
+	returnObject := repo.returnObject("ScheduleUserDeletion").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if id != userID {
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	now := time.Now()
+	returnObject.DeletionDueAt = &dueAt
+	returnObject.SessionsRevokedAt = &now
+	return returnObject, nil
+}
+
+// CancelUserDeletion ...
+func (repo *Repository) CancelUserDeletion(id string) (*model.User, error) {
+
+	returnObject := repo.returnObject("CancelUserDeletion").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	returnObject.DeletionDueAt = nil
+	return returnObject, nil
+}
+
+// DueUserDeletions ...
+func (repo *Repository) DueUserDeletions(now time.Time) ([]string, error) {
+
+	returnObject := repo.returnObject("DueUserDeletions").([]string)
+
+	if repo.IsError {
+		return []string{}, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// DeleteUser ...
+func (repo *Repository) DeleteUser(id string, now time.Time) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// DeletedUsers ...
+func (repo *Repository) DeletedUsers() (*[]model.TrashItem, error) {
+
+	returnObject := repo.returnObject("DeletedUsers").(*[]model.TrashItem)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// RestoreUser ...
+func (repo *Repository) RestoreUser(id string) (*model.User, error) {
+
+	returnObject := repo.returnObject("RestoreUser").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	returnObject.DeletedAt = nil
+	returnObject.DeletionDueAt = nil
+	return returnObject, nil
+}
+
+// ExportUser -> users other than userID are forbidden
+func (repo *Repository) ExportUser(id, userID string) (*model.UserExport, error) {
+
+	returnObject := repo.returnObject("ExportUser").(*model.UserExport)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if id != userID {
+		return &model.UserExport{}, storage.ErrForbidden
+	}
+
+	return returnObject, nil
+}
@@ -0,0 +1,150 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateDocument -> an ErrorMessage of ErrDocumentExists returns the sentinel along with the object
+func (repo *Repository) CreateDocument(document model.Document) (*model.Document, error) {
+
+	returnObject := repo.returnObject("CreateDocument").(*model.Document)
+
+	if repo.IsError && repo.ErrorMessage == storage.ErrDocumentExists.Error() {
+		return returnObject, storage.ErrDocumentExists
+	}
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetDocument -> documents not owned by userID are reported as not found
+func (repo *Repository) GetDocument(id, userID string) (*model.Document, error) {
+
+	returnObject := repo.returnObject("GetDocument").(*model.Document)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Document{}, storage.ErrDocumentNotFound
+	}
+
+	return returnObject, nil
+}
+
+// DeleteDocument -> zero rows affected means nothing matched the scope
+func (repo *Repository) DeleteDocument(id, userID string) (int64, error) {
+
+	returnObject := repo.returnObject("DeleteDocument").(int64)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject == 0 {
+		return 0, storage.ErrDocumentNotFound
+	}
+
+	return returnObject, nil
+}
+
+// AllDocuments -> only returns the documents owned by userID
+func (repo *Repository) AllDocuments(userID string) (*[]model.Document, error) {
+
+	returnObject := repo.returnObject("AllDocuments").(*[]model.Document)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	documents := []model.Document{}
+	for _, document := range *returnObject {
+		if document.UserID.String() == userID {
+			documents = append(documents, document)
+		}
+	}
+
+	return &documents, nil
+}
+
+// DocumentVersions -> only returns the documents owned by userID
+func (repo *Repository) DocumentVersions(id, userID string) (*[]model.Document, error) {
+
+	returnObject := repo.returnObject("DocumentVersions").(*[]model.Document)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	documents := []model.Document{}
+	for _, document := range *returnObject {
+		if document.UserID.String() == userID {
+			documents = append(documents, document)
+		}
+	}
+
+	return &documents, nil
+}
+
+// RestoreDocument -> documents not owned by userID are reported as not found
+func (repo *Repository) RestoreDocument(id, userID string) (*model.Document, error) {
+
+	returnObject := repo.returnObject("RestoreDocument").(*model.Document)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Document{}, storage.ErrDocumentNotFound
+	}
+
+	returnObject.DeletedAt = nil
+	return returnObject, nil
+}
+
+// LinkDocument ...
+func (repo *Repository) LinkDocument(documentID, applicationID, userID string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// UnlinkDocument ...
+func (repo *Repository) UnlinkDocument(documentID, applicationID, userID string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// ApplicationDocuments -> only returns the documents owned by userID
+func (repo *Repository) ApplicationDocuments(applicationID, userID string) (*[]model.Document, error) {
+
+	returnObject := repo.returnObject("ApplicationDocuments").(*[]model.Document)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	documents := []model.Document{}
+	for _, document := range *returnObject {
+		if document.UserID.String() == userID {
+			documents = append(documents, document)
+		}
+	}
+
+	return &documents, nil
+}
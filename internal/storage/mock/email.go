@@ -0,0 +1,38 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// EnqueueEmail -> keeps the email in Emails
+func (repo *Repository) EnqueueEmail(email model.Email) (*model.Email, error) {
+
+	if repo.IsError {
+		return &model.Email{}, errors.New(repo.ErrorMessage)
+	}
+
+	repo.Emails = append(repo.Emails, email)
+	return &email, nil
+}
+
+// DeliverQueuedEmails -> delivers every email of the return object
+func (repo *Repository) DeliverQueuedEmails(now time.Time, deliver func(model.Email) error) (int, error) {
+
+	returnObject := repo.returnObject("DeliverQueuedEmails").(*[]model.Email)
+
+	if repo.IsError {
+		return 0, errors.New(repo.ErrorMessage)
+	}
+
+	delivered := 0
+	for _, email := range *returnObject {
+		if deliver(email) == nil {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
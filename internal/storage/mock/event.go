@@ -0,0 +1,26 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// GetApplicationTimeline -> only returns the events made by userID
+func (repo *Repository) GetApplicationTimeline(id, userID string) (*[]model.ApplicationEvent, error) {
+
+	returnObject := repo.returnObject("GetApplicationTimeline").(*[]model.ApplicationEvent)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	events := []model.ApplicationEvent{}
+	for _, event := range *returnObject {
+		if event.UserID.String() == userID {
+			events = append(events, event)
+		}
+	}
+
+	return &events, nil
+}
@@ -0,0 +1,41 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// CreateOIDCState ...
+func (repo *Repository) CreateOIDCState(state model.OIDCState) (*model.OIDCState, error) {
+
+	if repo.IsError {
+		return &model.OIDCState{}, errors.New(repo.ErrorMessage)
+	}
+
+	return &state, nil
+}
+
+// ConsumeOIDCState ...
+func (repo *Repository) ConsumeOIDCState(hash, provider string) (*model.OIDCState, error) {
+
+	returnObject := repo.returnObject("ConsumeOIDCState").(*model.OIDCState)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// SignInWithIdentity ...
+func (repo *Repository) SignInWithIdentity(identity model.Identity, profile model.User) (*model.User, error) {
+
+	returnObject := repo.returnObject("SignInWithIdentity").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
@@ -0,0 +1,104 @@
+package mock
+
+import (
+	"errors"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateInterview ...
+func (repo *Repository) CreateInterview(interview model.Interview) (*model.Interview, error) {
+
+	returnObject := repo.returnObject("CreateInterview").(*model.Interview)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetInterview -> interviews not owned by userID are reported as not found
+func (repo *Repository) GetInterview(id, applicationID, userID string) (*model.Interview, error) {
+
+	returnObject := repo.returnObject("GetInterview").(*model.Interview)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Interview{}, storage.ErrInterviewNotFound
+	}
+
+	return returnObject, nil
+}
+
+// UpdateInterview -> interviews not owned by userID are reported as not found
+func (repo *Repository) UpdateInterview(interview model.Interview, id, applicationID, userID string) (*model.Interview, error) {
+
+	returnObject := repo.returnObject("UpdateInterview").(*model.Interview)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Interview{}, storage.ErrInterviewNotFound
+	}
+
+	return returnObject, nil
+}
+
+// DeleteInterview -> zero rows affected means nothing matched the scope
+func (repo *Repository) DeleteInterview(id, applicationID, userID string) (int64, error) {
+
+	returnObject := repo.returnObject("DeleteInterview").(int64)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject == 0 {
+		return 0, storage.ErrInterviewNotFound
+	}
+
+	return returnObject, nil
+}
+
+// RestoreInterview -> interviews not owned by userID are reported as not found
+func (repo *Repository) RestoreInterview(id, applicationID, userID string) (*model.Interview, error) {
+
+	returnObject := repo.returnObject("RestoreInterview").(*model.Interview)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Interview{}, storage.ErrInterviewNotFound
+	}
+
+	returnObject.DeletedAt = nil
+	return returnObject, nil
+}
+
+// AllInterviews -> only returns the interviews owned by userID
+func (repo *Repository) AllInterviews(applicationID, userID string) (*[]model.Interview, error) {
+
+	returnObject := repo.returnObject("AllInterviews").(*[]model.Interview)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	interviews := []model.Interview{}
+	for _, interview := range *returnObject {
+		if interview.UserID.String() == userID {
+			interviews = append(interviews, interview)
+		}
+	}
+
+	return &interviews, nil
+}
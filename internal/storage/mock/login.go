@@ -0,0 +1,40 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// LoginLockedUntil ...
+func (repo *Repository) LoginLockedUntil(keys []string) (time.Time, error) {
+
+	returnObject, _ := repo.returnObject("LoginLockedUntil").(time.Time)
+
+	if repo.IsError {
+		return time.Time{}, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// RecordLoginFailure ...
+func (repo *Repository) RecordLoginFailure(key string, policy model.LockoutPolicy) (*model.LoginThrottle, error) {
+
+	if repo.IsError {
+		return &model.LoginThrottle{}, errors.New(repo.ErrorMessage)
+	}
+
+	return &model.LoginThrottle{Key: key, Failures: 1, LastFailureAt: time.Now()}, nil
+}
+
+// ResetLoginFailures ...
+func (repo *Repository) ResetLoginFailures(key string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// SetTOTPSecret ...
+func (repo *Repository) SetTOTPSecret(userID, secret string) (*model.User, error) {
+
+	returnObject := repo.returnObject("SetTOTPSecret").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	returnObject.TOTPSecret = secret
+	return returnObject, nil
+}
+
+// EnableTOTP ...
+func (repo *Repository) EnableTOTP(userID string, step int64, recoveryCodes []string) (*model.User, error) {
+
+	returnObject := repo.returnObject("EnableTOTP").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	now := time.Now()
+	returnObject.TOTPEnabledAt = &now
+	returnObject.TOTPLastStep = step
+	return returnObject, nil
+}
+
+// DisableTOTP ...
+func (repo *Repository) DisableTOTP(userID string) (*model.User, error) {
+
+	returnObject := repo.returnObject("DisableTOTP").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	returnObject.TOTPSecret = ""
+	returnObject.TOTPEnabledAt = nil
+	returnObject.TOTPLastStep = 0
+	return returnObject, nil
+}
+
+// ReplaceRecoveryCodes ...
+func (repo *Repository) ReplaceRecoveryCodes(userID string, recoveryCodes []string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// UseTOTPStep ...
+func (repo *Repository) UseTOTPStep(userID string, step int64) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode ...
+func (repo *Repository) UseRecoveryCode(userID, hash string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// GetMFAChallenge ...
+func (repo *Repository) GetMFAChallenge(hash string) (*model.User, error) {
+
+	returnObject := repo.returnObject("GetMFAChallenge").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// FailMFAChallenge ...
+func (repo *Repository) FailMFAChallenge(hash string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// CompleteMFAChallenge ...
+func (repo *Repository) CompleteMFAChallenge(hash string) (*model.User, error) {
+
+	returnObject := repo.returnObject("CompleteMFAChallenge").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
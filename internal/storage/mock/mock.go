@@ -1,8 +1,21 @@
 package mock
 
+import "github.com/amaraliou/trackr-core/internal/model"
+
 // Repository ...
 type Repository struct {
-	ReturnObject interface{}
-	IsError      bool
-	ErrorMessage string
+	ReturnObject  interface{}
+	ReturnObjects map[string]interface{} // Per method overrides of ReturnObject, keyed by method name
+	IsError       bool
+	ErrorMessage  string
+	AuditEvents   []model.AuditEvent // Events given to CreateAuditEvent
+	Emails        []model.Email      // Emails given to EnqueueEmail
+}
+
+// returnObject -> object the given method returns
+func (repo *Repository) returnObject(method string) interface{} {
+	if object, ok := repo.ReturnObjects[method]; ok {
+		return object
+	}
+	return repo.ReturnObject
 }
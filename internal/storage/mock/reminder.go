@@ -0,0 +1,122 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateReminder ...
+func (repo *Repository) CreateReminder(reminder model.Reminder) (*model.Reminder, error) {
+
+	returnObject := repo.returnObject("CreateReminder").(*model.Reminder)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetReminder -> reminders not owned by userID are reported as not found
+func (repo *Repository) GetReminder(id, applicationID, userID string) (*model.Reminder, error) {
+
+	returnObject := repo.returnObject("GetReminder").(*model.Reminder)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Reminder{}, storage.ErrReminderNotFound
+	}
+
+	return returnObject, nil
+}
+
+// AllReminders -> only returns the reminders owned by userID
+func (repo *Repository) AllReminders(applicationID, userID string) (*[]model.Reminder, error) {
+
+	return repo.ownedReminders("AllReminders", userID)
+}
+
+// ActiveReminders -> only returns the reminders owned by userID
+func (repo *Repository) ActiveReminders(userID string) (*[]model.Reminder, error) {
+
+	return repo.ownedReminders("ActiveReminders", userID)
+}
+
+func (repo *Repository) ownedReminders(method, userID string) (*[]model.Reminder, error) {
+
+	returnObject := repo.returnObject(method).(*[]model.Reminder)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	reminders := []model.Reminder{}
+	for _, reminder := range *returnObject {
+		if reminder.UserID.String() == userID {
+			reminders = append(reminders, reminder)
+		}
+	}
+
+	return &reminders, nil
+}
+
+// SnoozeReminder -> reminders not owned by userID are reported as not found
+func (repo *Repository) SnoozeReminder(id, applicationID, userID string, until time.Time) (*model.Reminder, error) {
+
+	returnObject := repo.returnObject("SnoozeReminder").(*model.Reminder)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Reminder{}, storage.ErrReminderNotFound
+	}
+
+	returnObject.DueAt = until
+	returnObject.DismissedAt = nil
+	return returnObject, nil
+}
+
+// DismissReminder -> reminders not owned by userID are reported as not found
+func (repo *Repository) DismissReminder(id, applicationID, userID string) (*model.Reminder, error) {
+
+	returnObject := repo.returnObject("DismissReminder").(*model.Reminder)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if returnObject.UserID.String() != userID {
+		return &model.Reminder{}, storage.ErrReminderNotFound
+	}
+
+	now := time.Now()
+	returnObject.DismissedAt = &now
+	return returnObject, nil
+}
+
+// FireDueReminders -> fires every reminder of the return object
+func (repo *Repository) FireDueReminders(now time.Time, fire func(model.Reminder) error) (int, error) {
+
+	returnObject := repo.returnObject("FireDueReminders").(*[]model.Reminder)
+
+	if repo.IsError {
+		return 0, errors.New(repo.ErrorMessage)
+	}
+
+	fired := 0
+	for _, reminder := range *returnObject {
+		if fire(reminder) == nil {
+			fired++
+		}
+	}
+
+	return fired, nil
+}
@@ -0,0 +1,83 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+)
+
+// CreateRefreshToken ...
+func (repo *Repository) CreateRefreshToken(token model.RefreshToken) (*model.RefreshToken, error) {
+
+	if repo.IsError {
+		return &model.RefreshToken{}, errors.New(repo.ErrorMessage)
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken ...
+func (repo *Repository) RotateRefreshToken(hash string, next model.RefreshToken) (*model.RefreshToken, error) {
+
+	returnObject := repo.returnObject("RotateRefreshToken").(*model.RefreshToken)
+
+	if repo.IsError && repo.ErrorMessage == storage.ErrTokenReused.Error() {
+		return &model.RefreshToken{}, storage.ErrTokenReused
+	}
+
+	if repo.IsError && repo.ErrorMessage == storage.ErrTokenInvalid.Error() {
+		return &model.RefreshToken{}, storage.ErrTokenInvalid
+	}
+
+	if repo.IsError {
+		return &model.RefreshToken{}, errors.New(repo.ErrorMessage)
+	}
+
+	next.FamilyID = returnObject.FamilyID
+	next.UserID = returnObject.UserID
+	return &next, nil
+}
+
+// RevokeRefreshToken ...
+func (repo *Repository) RevokeRefreshToken(hash, userID string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions ...
+func (repo *Repository) RevokeUserSessions(userID string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// DenyToken ...
+func (repo *Repository) DenyToken(tokenID string, expiresAt time.Time) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
+// TokenDenied ...
+func (repo *Repository) TokenDenied(tokenID string) (bool, error) {
+
+	returnObject, _ := repo.returnObject("TokenDenied").(bool)
+
+	if repo.IsError {
+		return false, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
@@ -0,0 +1,70 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// CreateUserToken ...
+func (repo *Repository) CreateUserToken(token model.UserToken) (*model.UserToken, error) {
+
+	if repo.IsError {
+		return &model.UserToken{}, errors.New(repo.ErrorMessage)
+	}
+
+	return &token, nil
+}
+
+// CountUserTokens ...
+func (repo *Repository) CountUserTokens(userID string, purpose model.TokenPurpose, since time.Time) (int, error) {
+
+	returnObject, _ := repo.returnObject("CountUserTokens").(int)
+
+	if repo.IsError {
+		return 0, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// GetTokenUser ...
+func (repo *Repository) GetTokenUser(hash string, purpose model.TokenPurpose) (*model.User, error) {
+
+	returnObject := repo.returnObject("GetTokenUser").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// VerifyUserEmail ...
+func (repo *Repository) VerifyUserEmail(hash string) (*model.User, error) {
+
+	returnObject := repo.returnObject("VerifyUserEmail").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	returnObject.IsVerified = true
+	return returnObject, nil
+}
+
+// ResetUserPassword ...
+func (repo *Repository) ResetUserPassword(hash, password string) (*model.User, error) {
+
+	returnObject := repo.returnObject("ResetUserPassword").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	now := time.Now()
+	returnObject.Password = password
+	returnObject.SessionsRevokedAt = &now
+	return returnObject, nil
+}
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// Trash ...
+func (repo *Repository) Trash(userID string) (*[]model.TrashItem, error) {
+
+	returnObject := repo.returnObject("Trash").(*[]model.TrashItem)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// PurgeTrash -> purges as many items as the return object, without orphaned content
+func (repo *Repository) PurgeTrash(before time.Time, remove func(string) error) (int, error) {
+
+	returnObject := repo.returnObject("PurgeTrash").(int)
+
+	if repo.IsError {
+		return 0, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
@@ -2,14 +2,16 @@ package mock
 
 import (
 	"errors"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 )
 
 // CreateUser ...
 func (repo *Repository) CreateUser(user model.User) (*model.User, error) {
 
-	returnObject := repo.ReturnObject.(*model.User)
+	returnObject := repo.returnObject("CreateUser").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
@@ -18,10 +20,26 @@ func (repo *Repository) CreateUser(user model.User) (*model.User, error) {
 	return returnObject, nil
 }
 
-// GetUser ...
-func (repo *Repository) GetUser(id string) (*model.User, error) {
+// GetUser -> users other than userID are forbidden
+func (repo *Repository) GetUser(id, userID string) (*model.User, error) {
 
-	returnObject := repo.ReturnObject.(*model.User)
+	returnObject := repo.returnObject("GetUser").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	if id != userID {
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	return returnObject, nil
+}
+
+// FindUser ...
+func (repo *Repository) FindUser(id string) (*model.User, error) {
+
+	returnObject := repo.returnObject("FindUser").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
@@ -30,50 +48,100 @@ func (repo *Repository) GetUser(id string) (*model.User, error) {
 	return returnObject, nil
 }
 
-// GetUserByEmail ...
+// GetUserByEmail -> users with another email are reported as not found
 func (repo *Repository) GetUserByEmail(email string) (*model.User, error) {
 
-	returnObject := repo.ReturnObject.(*model.User)
+	returnObject := repo.returnObject("GetUserByEmail").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if returnObject.Email != email {
+		return &model.User{}, storage.ErrUserNotFound
+	}
+
 	return returnObject, nil
 }
 
-// UpdateUser ...
-func (repo *Repository) UpdateUser(user model.User, id string) (*model.User, error) {
+// UpdateUser -> users other than userID are forbidden
+func (repo *Repository) UpdateUser(user model.User, id, userID string) (*model.User, error) {
 
-	returnObject := repo.ReturnObject.(*model.User)
+	returnObject := repo.returnObject("UpdateUser").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if id != userID {
+		return &model.User{}, storage.ErrForbidden
+	}
+
 	return returnObject, nil
 }
 
-// DeleteUser ...
-func (repo *Repository) DeleteUser(id string) (int64, error) {
+// ChangeUserPassword -> users other than userID are forbidden
+func (repo *Repository) ChangeUserPassword(id, password, userID string) (*model.User, error) {
 
-	returnObject := repo.ReturnObject.(int64)
+	returnObject := repo.returnObject("ChangeUserPassword").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	if id != userID {
+		return &model.User{}, storage.ErrForbidden
+	}
+
 	return returnObject, nil
 }
 
+// RehashUserPassword ...
+func (repo *Repository) RehashUserPassword(id, hash, rehash string) error {
+
+	if repo.IsError {
+		return errors.New(repo.ErrorMessage)
+	}
+
+	return nil
+}
+
 // AllUsers ...
 func (repo *Repository) AllUsers() (*[]model.User, error) {
 
-	returnObject := repo.ReturnObject.(*[]model.User)
+	returnObject := repo.returnObject("AllUsers").(*[]model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	return returnObject, nil
+}
+
+// DisableUser ...
+func (repo *Repository) DisableUser(id string) (*model.User, error) {
+
+	returnObject := repo.returnObject("DisableUser").(*model.User)
+
+	if repo.IsError {
+		return returnObject, errors.New(repo.ErrorMessage)
+	}
+
+	now := time.Now()
+	returnObject.DisabledAt = &now
+	returnObject.SessionsRevokedAt = &now
+	return returnObject, nil
+}
+
+// EnableUser ...
+func (repo *Repository) EnableUser(id string) (*model.User, error) {
+
+	returnObject := repo.returnObject("EnableUser").(*model.User)
 
 	if repo.IsError {
 		return returnObject, errors.New(repo.ErrorMessage)
 	}
 
+	returnObject.DisabledAt = nil
 	return returnObject, nil
 }
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// lastUsedPrecision -> last_used_at is written at most once per this duration, not on every request
+const lastUsedPrecision = time.Minute
+
+// CreateAPIKey ...
+func (repo *Repository) CreateAPIKey(key model.APIKey) (*model.APIKey, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+
+	err := db.Create(&key).Error
+	if err != nil {
+		logger.Infof("Failed to create API key in Postgres")
+		return &model.APIKey{}, err
+	}
+
+	return &key, nil
+}
+
+// AllAPIKeys -> API keys of the user that weren't revoked, expired ones included
+func (repo *Repository) AllAPIKeys(userID string) (*[]model.APIKey, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	keys := []model.APIKey{}
+
+	err := db.Model(&model.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at desc").Find(&keys).Error
+	if err != nil {
+		logger.Infof("Failed to get all API keys from Postgres")
+		return &[]model.APIKey{}, err
+	}
+
+	return &keys, nil
+}
+
+// RevokeAPIKey ...
+func (repo *Repository) RevokeAPIKey(id, userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	db = db.Model(&model.APIKey{}).Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).UpdateColumn("revoked_at", time.Now())
+	if db.Error != nil {
+		logger.Infof("Failed to revoke the API key in Postgres")
+		return db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		logger.Infof("API key not found in Postgres")
+		return storage.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey -> active API key with the given prefix whose secret has the given hash, its last use is recorded
+func (repo *Repository) AuthenticateAPIKey(prefix, hash string) (*model.APIKey, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	key := model.APIKey{}
+	now := time.Now()
+
+	err := db.Model(&model.APIKey{}).
+		Where("prefix = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", prefix, now).
+		Take(&key).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("API key not found in Postgres")
+		return &model.APIKey{}, storage.ErrTokenInvalid
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the API key from Postgres")
+		return &model.APIKey{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 {
+		return &model.APIKey{}, storage.ErrTokenInvalid
+	}
+
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(now.Add(-lastUsedPrecision)) {
+		err = db.Model(&key).UpdateColumn("last_used_at", now).Error
+		if err != nil {
+			logger.Infof("Failed to record the use of the API key in Postgres")
+			return &model.APIKey{}, err
+		}
+	}
+
+	return &key, nil
+}
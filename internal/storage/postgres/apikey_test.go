@@ -0,0 +1,85 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestAuthenticateAPIKey(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	created, err := pgRepo.CreateAPIKey(model.APIKey{Name: "Import script", Prefix: prefix, Hash: hash, Scopes: "read", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	key, err := pgRepo.AuthenticateAPIKey(prefix, hash)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, key.ID, created.ID)
+	assert.NotEqual(t, key.LastUsedAt, nil)
+
+	_, err = pgRepo.AuthenticateAPIKey(prefix, auth.HashToken("wrong"))
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	err = pgRepo.RevokeAPIKey(created.ID.String(), user.ID.String())
+	assert.Equal(t, err, nil)
+
+	_, err = pgRepo.AuthenticateAPIKey(prefix, hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	err = pgRepo.RevokeAPIKey(created.ID.String(), user.ID.String())
+	assert.Equal(t, err, storage.ErrAPIKeyNotFound)
+}
+
+func TestAuthenticateAPIKey_Expired(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	expiresAt := time.Now().Add(-time.Minute)
+	_, err = pgRepo.CreateAPIKey(model.APIKey{Name: "Import script", Prefix: prefix, Hash: hash, Scopes: "read", ExpiresAt: &expiresAt, UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.AuthenticateAPIKey(prefix, hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	keys, err := pgRepo.AllAPIKeys(user.ID.String())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*keys), 1)
+}
@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 	"github.com/jinzhu/gorm"
 )
 
@@ -18,13 +20,31 @@ func (repo *Repository) CreateApplication(application model.Application) (*model
 		return &model.Application{}, errors.New("Invalid Application ID")
 	}
 
-	_, err := repo.GetUser(application.UserID.String())
+	_, err := repo.FindUser(application.UserID.String())
 	if err != nil {
 		logger.Infof("Failed to create application in Postgres: Application not found")
 		return &model.Application{}, errors.New("User doesn't exist, can't create application")
 	}
 
-	err = db.Create(&application).Error
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := linkCompany(tx, &application, application.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Create(&application).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&model.ApplicationEvent{
+			ApplicationID: application.ID,
+			UserID:        application.UserID,
+			Type:          model.EventCreated,
+			FromStatus:    application.Status,
+			ToStatus:      application.Status,
+		}).Error
+	})
 	if err != nil {
 		logger.Warnf("Failed to create application in Postgres: %s", err.Error())
 		return &model.Application{}, err
@@ -33,14 +53,14 @@ func (repo *Repository) CreateApplication(application model.Application) (*model
 	return &application, nil
 }
 
-// AllApplications ...
-func (repo *Repository) AllApplications() (*[]model.Application, error) {
+// AllApplications -> retrieves the applications of the given user
+func (repo *Repository) AllApplications(userID string) (*[]model.Application, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 	applications := []model.Application{}
 
-	err := db.Model(&model.Application{}).Limit(100).Find(&applications).Error
+	err := db.Model(&model.Application{}).Where("user_id = ?", userID).Limit(100).Find(&applications).Error
 	if err != nil {
 		logger.Warnf("Failed to retrieve applications in Postgres: %s", err.Error())
 		return &[]model.Application{}, err
@@ -49,22 +69,17 @@ func (repo *Repository) AllApplications() (*[]model.Application, error) {
 	return &applications, nil
 }
 
-// AllUserApplications ...
-func (repo *Repository) AllUserApplications(ApplicationID string) (*[]model.Application, error) {
-	return &[]model.Application{}, nil
-}
-
 // GetApplication ...
-func (repo *Repository) GetApplication(id string) (*model.Application, error) {
+func (repo *Repository) GetApplication(id, userID string) (*model.Application, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 	application := model.Application{}
 
-	err := db.Model(&model.Application{}).Where("id = ?", id).Take(&application).Error
+	err := db.Model(&model.Application{}).Where("id = ? AND user_id = ?", id, userID).Take(&application).Error
 	if gorm.IsRecordNotFoundError(err) {
 		logger.Infof("Application not found in Postgres")
-		return &model.Application{}, errors.New("Application not found")
+		return &model.Application{}, storage.ErrApplicationNotFound
 	}
 
 	if err != nil {
@@ -75,37 +90,157 @@ func (repo *Repository) GetApplication(id string) (*model.Application, error) {
 	return &application, nil
 }
 
-// UpdateApplication ...
-func (repo *Repository) UpdateApplication(application model.Application, id string) (*model.Application, error) {
+// UpdateApplication -> applies the given fields and records what changed in the timeline
+func (repo *Repository) UpdateApplication(application model.Application, id, userID string) (*model.Application, error) {
+	return repo.updateApplication(application, id, userID, false)
+}
+
+// ReplaceApplication -> like UpdateApplication, but fields left empty are cleared
+func (repo *Repository) ReplaceApplication(application model.Application, id, userID string) (*model.Application, error) {
+	return repo.updateApplication(application, id, userID, true)
+}
+
+func (repo *Repository) updateApplication(application model.Application, id, userID string, replace bool) (*model.Application, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 
-	err := db.Model(model.Application{}).Updates(&application).Error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current := model.Application{}
+		err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ? AND user_id = ?", id, userID).Take(&current).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrApplicationNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		companyRenamed := application.Company != "" && !strings.EqualFold(strings.TrimSpace(application.Company), current.Company)
+		if replace || application.CompanyID != nil || companyRenamed {
+			err = linkCompany(tx, &application, current.UserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// A struct skips zero values, so a replacement names every column
+		var updates interface{} = &application
+		changes := model.DiffApplication(current, application)
+		if replace {
+			updates = replacedApplicationColumns(application)
+			changes = model.DiffApplicationReplace(current, application)
+		}
+
+		err = tx.Model(&model.Application{}).Where("id = ? AND user_id = ?", id, userID).Updates(updates).Error
+		if err != nil {
+			return err
+		}
+
+		actorID := current.UserID
+		if application.StatusChangedBy != nil {
+			actorID = *application.StatusChangedBy
+		}
+
+		if application.Status != model.StatusWishlist && application.Status != current.Status {
+			err = tx.Create(&model.ApplicationEvent{
+				ApplicationID: current.ID,
+				UserID:        actorID,
+				Type:          model.EventStatusChanged,
+				FromStatus:    current.Status,
+				ToStatus:      application.Status,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(changes) > 0 {
+			err = tx.Create(&model.ApplicationEvent{
+				ApplicationID: current.ID,
+				UserID:        actorID,
+				Type:          model.EventUpdated,
+				FromStatus:    current.Status,
+				ToStatus:      current.Status,
+				Changes:       changes,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err == storage.ErrApplicationNotFound || err == storage.ErrCompanyNotFound {
+		logger.Infof("%s in Postgres", err.Error())
+		return &model.Application{}, err
+	}
+
 	if err != nil {
 		logger.Infof("Failed to update the application in Postgres")
 		return &model.Application{}, err
 	}
 
-	return repo.GetApplication(id)
+	return repo.GetApplication(id, userID)
+}
+
+// replacedApplicationColumns -> every column users can set, the status only when it changed
+func replacedApplicationColumns(application model.Application) map[string]interface{} {
+	columns := map[string]interface{}{
+		"job_title":   application.JobTitle,
+		"company":     application.Company,
+		"company_id":  application.CompanyID,
+		"description": application.Description,
+		"job_posting": application.JobPosting,
+		"location":    application.Location,
+		"type":        application.Type,
+	}
+
+	if application.StatusChangedAt != nil {
+		columns["status"] = application.Status
+		columns["status_changed_at"] = application.StatusChangedAt
+		columns["status_changed_by"] = application.StatusChangedBy
+	}
+
+	return columns
 }
 
-// DeleteApplication ...
-func (repo *Repository) DeleteApplication(id string) (int64, error) {
+// DeleteApplication -> moves the application to the trash, its timeline, interviews and links are kept for a restore
+func (repo *Repository) DeleteApplication(id, userID string) (int64, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 
-	db = db.Unscoped().Model(&model.Application{}).Where("id = ?", id).Take(&model.Application{}).Delete(&model.Application{})
-	if gorm.IsRecordNotFoundError(db.Error) {
-		logger.Infof("Failed to get the application from Postgres")
-		return 0, errors.New("Application not found")
-	}
-
+	db = db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Application{})
 	if db.Error != nil {
 		logger.Infof("Failed to delete the application from Postgres")
 		return 0, db.Error
 	}
 
+	if db.RowsAffected == 0 {
+		logger.Infof("Failed to get the application from Postgres")
+		return 0, storage.ErrApplicationNotFound
+	}
+
 	return db.RowsAffected, nil
 }
+
+// RestoreApplication -> takes the application out of the trash
+func (repo *Repository) RestoreApplication(id, userID string) (*model.Application, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Unscoped().Model(&model.Application{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to restore the application in Postgres")
+		return &model.Application{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Application not found in the trash in Postgres")
+		return &model.Application{}, storage.ErrApplicationNotFound
+	}
+
+	return repo.GetApplication(id, userID)
+}
@@ -7,6 +7,8 @@ import (
 	"testing"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
 	"gopkg.in/go-playground/assert.v1"
 )
 
@@ -22,12 +24,13 @@ func TestAllApplications(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	retrievedApplications, err := pgRepo.AllApplications()
+	retrievedApplications, err := pgRepo.AllApplications((*applications)[0].UserID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	assert.Equal(t, len(*applications), len(*retrievedApplications))
+	assert.Equal(t, len(*retrievedApplications), 1)
+	assert.Equal(t, (*retrievedApplications)[0].Company, (*applications)[0].Company)
 }
 
 func TestGetApplication(t *testing.T) {
@@ -42,7 +45,7 @@ func TestGetApplication(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	retrievedApplication, err := pgRepo.GetApplication(application.ID.String())
+	retrievedApplication, err := pgRepo.GetApplication(application.ID.String(), application.UserID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -93,7 +96,7 @@ func TestUpdateApplication(t *testing.T) {
 		Location: "London, UK",
 	}
 
-	updatedApplication, err := pgRepo.UpdateApplication(applicationUpdate, application.ID.String())
+	updatedApplication, err := pgRepo.UpdateApplication(applicationUpdate, application.ID.String(), application.UserID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -101,6 +104,47 @@ func TestUpdateApplication(t *testing.T) {
 	assert.Equal(t, updatedApplication.Location, applicationUpdate.Location)
 }
 
+func TestReplaceApplication(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	_, err = pgRepo.UpdateApplication(model.Application{Location: "London, UK", Description: "Backend team"}, application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	replacedApplication, err := pgRepo.ReplaceApplication(model.Application{
+		JobTitle: "Software Engineer",
+		Company:  "GoCardless",
+		Location: "London, UK",
+		UserID:   application.UserID,
+	}, application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, replacedApplication.JobTitle, "Software Engineer")
+	assert.Equal(t, replacedApplication.Description, "")
+	assert.Equal(t, replacedApplication.CompanyID != nil, true)
+
+	events, err := pgRepo.GetApplicationTimeline(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes := (*events)[len(*events)-1].Changes
+	assert.Equal(t, changes["description"], model.FieldChange{From: "Backend team", To: ""})
+}
+
 func TestDeleteApplication(t *testing.T) {
 
 	err := refreshEverything()
@@ -113,10 +157,101 @@ func TestDeleteApplication(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	isDeleted, err := pgRepo.DeleteApplication(application.ID.String())
+	isDeleted, err := pgRepo.DeleteApplication(application.ID.String(), application.UserID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	assert.Equal(t, isDeleted, int64(1))
 }
+
+func TestForeignApplication(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	otherUserID := uuid.NewV4().String()
+
+	_, err = pgRepo.GetApplication(application.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	_, err = pgRepo.UpdateApplication(model.Application{Location: "London, UK"}, application.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	_, err = pgRepo.DeleteApplication(application.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+}
+
+func TestUpdateApplicationStatus(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	applicationUpdate := model.Application{}
+	applicationUpdate.ChangeStatus(model.StatusApplied, application.UserID)
+
+	updatedApplication, err := pgRepo.UpdateApplication(applicationUpdate, application.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, updatedApplication.Status, model.StatusApplied)
+	assert.Equal(t, *updatedApplication.StatusChangedBy, application.UserID)
+}
+
+func TestGetApplicationTimeline(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := pgRepo.CreateApplication(model.Application{
+		JobTitle: "Software Engineer Intern",
+		Company:  "GoCardless",
+		UserID:   user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	applicationUpdate := model.Application{Location: "London, UK"}
+	applicationUpdate.ChangeStatus(model.StatusApplied, user.ID)
+
+	_, err = pgRepo.UpdateApplication(applicationUpdate, application.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	events, err := pgRepo.GetApplicationTimeline(application.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, len(*events), 3)
+	assert.Equal(t, (*events)[0].Type, model.EventCreated)
+	assert.Equal(t, (*events)[1].ToStatus, model.StatusApplied)
+	assert.Equal(t, (*events)[2].Changes["location"].To, "London, UK")
+
+	_, err = pgRepo.GetApplicationTimeline(application.ID.String(), uuid.NewV4().String())
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// CreateAuditEvent ...
+func (repo *Repository) CreateAuditEvent(event model.AuditEvent) (*model.AuditEvent, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Create(&event).Error
+	if err != nil {
+		logger.Warnf("Failed to create audit event in Postgres: %s", err.Error())
+		return &model.AuditEvent{}, err
+	}
+
+	return &event, nil
+}
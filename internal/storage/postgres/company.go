@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// CreateCompany -> fails with storage.ErrCompanyExists if the user has a company with the same name
+func (repo *Repository) CreateCompany(company model.Company) (*model.Company, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	company.Name = strings.TrimSpace(company.Name)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := findCompanyByName(tx, company.Name, company.UserID)
+		if err == nil {
+			return storage.ErrCompanyExists
+		}
+
+		if err != storage.ErrCompanyNotFound {
+			return err
+		}
+
+		return tx.Create(&company).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to create company in Postgres")
+		return &model.Company{}, err
+	}
+
+	return &company, nil
+}
+
+// AllCompanies -> retrieves the companies of the given user
+func (repo *Repository) AllCompanies(userID string) (*[]model.Company, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	companies := []model.Company{}
+
+	err := db.Model(&model.Company{}).Where("user_id = ?", userID).Order("lower(name) asc").Limit(100).Find(&companies).Error
+	if err != nil {
+		logger.Infof("Failed to get all companies from Postgres")
+		return &[]model.Company{}, err
+	}
+
+	return &companies, nil
+}
+
+// GetCompany ...
+func (repo *Repository) GetCompany(id, userID string) (*model.Company, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	company, err := findCompany(db, id, userID)
+	if err == storage.ErrCompanyNotFound {
+		logger.Infof("Company not found in Postgres")
+		return &model.Company{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the company from Postgres")
+		return &model.Company{}, err
+	}
+
+	return company, nil
+}
+
+// UpdateCompany -> renaming a company also renames it on its applications and contacts
+func (repo *Repository) UpdateCompany(company model.Company, id, userID string) (*model.Company, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	company.Name = strings.TrimSpace(company.Name)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := findCompany(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if company.Name != "" && company.Name != current.Name {
+			existing, err := findCompanyByName(tx, company.Name, current.UserID)
+			if err == nil && existing.ID != current.ID {
+				return storage.ErrCompanyExists
+			}
+
+			if err != nil && err != storage.ErrCompanyNotFound {
+				return err
+			}
+
+			// Items in the trash are kept in sync too, they may be restored
+			err = tx.Unscoped().Model(&model.Application{}).Where("company_id = ?", id).Update("company", company.Name).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Unscoped().Model(&model.Contact{}).Where("company_id = ?", id).Update("company", company.Name).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.Company{}).Where("id = ? AND user_id = ?", id, userID).Updates(&company).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to update the company in Postgres")
+		return &model.Company{}, err
+	}
+
+	return repo.GetCompany(id, userID)
+}
+
+// DeleteCompany -> moves the company to the trash, its applications and contacts stay linked for its restore
+func (repo *Repository) DeleteCompany(id, userID string) (int64, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Company{})
+	if result.Error != nil {
+		logger.Infof("Failed to delete the company from Postgres")
+		return 0, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Company not found in Postgres")
+		return 0, storage.ErrCompanyNotFound
+	}
+
+	return result.RowsAffected, nil
+}
+
+// RestoreCompany -> takes the company out of the trash, fails with storage.ErrCompanyExists if the user
+// created a company with the same name meanwhile
+func (repo *Repository) RestoreCompany(id, userID string) (*model.Company, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		company := model.Company{}
+		err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).Take(&company).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrCompanyNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = findCompanyByName(tx, company.Name, company.UserID)
+		if err == nil {
+			return storage.ErrCompanyExists
+		}
+
+		if err != storage.ErrCompanyNotFound {
+			return err
+		}
+
+		return tx.Unscoped().Model(&model.Company{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+	})
+	if err == storage.ErrCompanyNotFound {
+		logger.Infof("Company not found in the trash in Postgres")
+		return &model.Company{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to restore the company in Postgres")
+		return &model.Company{}, err
+	}
+
+	return repo.GetCompany(id, userID)
+}
+
+func findCompany(db *gorm.DB, id, userID string) (*model.Company, error) {
+
+	company := model.Company{}
+
+	err := db.Model(&model.Company{}).Where("id = ? AND user_id = ?", id, userID).Take(&company).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return &model.Company{}, storage.ErrCompanyNotFound
+	}
+
+	if err != nil {
+		return &model.Company{}, err
+	}
+
+	return &company, nil
+}
+
+func findCompanyByName(db *gorm.DB, name string, userID uuid.UUID) (*model.Company, error) {
+
+	company := model.Company{}
+
+	err := db.Model(&model.Company{}).Where("user_id = ? AND lower(name) = lower(?)", userID, strings.TrimSpace(name)).Take(&company).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return &model.Company{}, storage.ErrCompanyNotFound
+	}
+
+	if err != nil {
+		return &model.Company{}, err
+	}
+
+	return &company, nil
+}
+
+// findOrCreateCompany -> used to link applications given by company name
+func findOrCreateCompany(db *gorm.DB, name string, userID uuid.UUID) (*model.Company, error) {
+
+	company, err := findCompanyByName(db, name, userID)
+	if err != storage.ErrCompanyNotFound {
+		return company, err
+	}
+
+	company = &model.Company{
+		Name:   strings.TrimSpace(name),
+		UserID: userID,
+	}
+
+	err = db.Create(company).Error
+	if err != nil {
+		return &model.Company{}, err
+	}
+
+	return company, nil
+}
+
+// linkCompany -> resolves the company of an application from its CompanyID or its name
+func linkCompany(db *gorm.DB, application *model.Application, userID uuid.UUID) error {
+
+	if application.CompanyID != nil {
+		company, err := findCompany(db, application.CompanyID.String(), userID.String())
+		if err != nil {
+			return err
+		}
+		application.Company = company.Name
+		return nil
+	}
+
+	if strings.TrimSpace(application.Company) == "" {
+		return nil
+	}
+
+	company, err := findOrCreateCompany(db, application.Company, userID)
+	if err != nil {
+		return err
+	}
+	application.Company = company.Name
+	application.CompanyID = &company.ID
+	return nil
+}
@@ -0,0 +1,179 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateCompany(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	createdCompany, err := pgRepo.CreateCompany(model.Company{Name: "GoCardless", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, createdCompany.Name, "GoCardless")
+
+	_, err = pgRepo.CreateCompany(model.Company{Name: " gocardless ", UserID: user.ID})
+	assert.Equal(t, err, storage.ErrCompanyExists)
+}
+
+func TestApplicationCompanyLink(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	first, err := pgRepo.CreateApplication(model.Application{JobTitle: "Software Engineer Intern", Company: "GoCardless", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	second, err := pgRepo.CreateApplication(model.Application{JobTitle: "Software Engineer", Company: "gocardless", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, *first.CompanyID, *second.CompanyID)
+	assert.Equal(t, second.Company, "GoCardless")
+
+	companies, err := pgRepo.AllCompanies(user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, len(*companies), 1)
+
+	_, err = pgRepo.UpdateCompany(model.Company{Name: "GoCardless Ltd"}, first.CompanyID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	renamed, err := pgRepo.GetApplication(second.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, renamed.Company, "GoCardless Ltd")
+
+	foreignCompanyID := uuid.NewV4()
+	_, err = pgRepo.CreateApplication(model.Application{JobTitle: "Software Engineer", CompanyID: &foreignCompanyID, UserID: user.ID})
+	assert.Equal(t, err, storage.ErrCompanyNotFound)
+}
+
+func TestDeleteCompany(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := pgRepo.CreateApplication(model.Application{JobTitle: "Software Engineer Intern", Company: "GoCardless", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	isDeleted, err := pgRepo.DeleteCompany(application.CompanyID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, isDeleted, int64(1))
+
+	_, err = pgRepo.GetCompany(application.CompanyID.String(), user.ID.String())
+	assert.Equal(t, err, storage.ErrCompanyNotFound)
+
+	// The application keeps the link for the restore
+	linked, err := pgRepo.GetApplication(application.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, linked.Company, "GoCardless")
+	assert.Equal(t, *linked.CompanyID, *application.CompanyID)
+
+	// The name is free again while the company is in the trash
+	recreated, err := pgRepo.CreateCompany(model.Company{Name: "gocardless", UserID: user.ID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.RestoreCompany(application.CompanyID.String(), user.ID.String())
+	assert.Equal(t, err, storage.ErrCompanyExists)
+
+	_, err = pgRepo.DeleteCompany(recreated.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	restored, err := pgRepo.RestoreCompany(application.CompanyID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, restored.Name, "GoCardless")
+
+	_, err = pgRepo.RestoreCompany(application.CompanyID.String(), user.ID.String())
+	assert.Equal(t, err, storage.ErrCompanyNotFound)
+}
+
+func TestBackfillCompanies(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// seedApplications writes the free-text company directly, like rows created before companies existed
+	applications, err := seedApplications()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = backfillCompanies(pgRepo.postgres.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, application := range *applications {
+		backfilled, err := pgRepo.GetApplication(application.ID.String(), application.UserID.String())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		company, err := pgRepo.GetCompany(backfilled.CompanyID.String(), application.UserID.String())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		assert.Equal(t, company.Name, application.Company)
+	}
+}
@@ -0,0 +1,312 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateContact ...
+func (repo *Repository) CreateContact(contact model.Contact) (*model.Contact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	contact.Name = strings.TrimSpace(contact.Name)
+	contact.LastContactedAt = nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := linkContactCompany(tx, &contact, contact.UserID.String())
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&contact).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to create contact in Postgres")
+		return &model.Contact{}, err
+	}
+
+	return &contact, nil
+}
+
+// AllContacts -> retrieves the contacts of the given user
+func (repo *Repository) AllContacts(userID string) (*[]model.Contact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	contacts := []model.Contact{}
+
+	err := db.Model(&model.Contact{}).Where("user_id = ?", userID).Order("lower(name) asc").Limit(100).Find(&contacts).Error
+	if err != nil {
+		logger.Infof("Failed to get all contacts from Postgres")
+		return &[]model.Contact{}, err
+	}
+
+	return &contacts, nil
+}
+
+// GetContact ...
+func (repo *Repository) GetContact(id, userID string) (*model.Contact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	contact, err := findContact(db, id, userID)
+	if err == storage.ErrContactNotFound {
+		logger.Infof("Contact not found in Postgres")
+		return &model.Contact{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the contact from Postgres")
+		return &model.Contact{}, err
+	}
+
+	return contact, nil
+}
+
+// UpdateContact -> last_contacted_at is only moved by logged interactions
+func (repo *Repository) UpdateContact(contact model.Contact, id, userID string) (*model.Contact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	contact.Name = strings.TrimSpace(contact.Name)
+	contact.LastContactedAt = nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := findContact(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		err = linkContactCompany(tx, &contact, userID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Contact{}).Where("id = ? AND user_id = ?", id, userID).Updates(&contact).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to update the contact in Postgres")
+		return &model.Contact{}, err
+	}
+
+	return repo.GetContact(id, userID)
+}
+
+// DeleteContact -> moves the contact to the trash, its application links and interactions are kept for a restore
+func (repo *Repository) DeleteContact(id, userID string) (int64, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	db = db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Contact{})
+	if db.Error != nil {
+		logger.Infof("Failed to delete the contact from Postgres")
+		return 0, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		logger.Infof("Contact not found in Postgres")
+		return 0, storage.ErrContactNotFound
+	}
+
+	return db.RowsAffected, nil
+}
+
+// RestoreContact -> takes the contact out of the trash
+func (repo *Repository) RestoreContact(id, userID string) (*model.Contact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Unscoped().Model(&model.Contact{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to restore the contact in Postgres")
+		return &model.Contact{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Contact not found in the trash in Postgres")
+		return &model.Contact{}, storage.ErrContactNotFound
+	}
+
+	return repo.GetContact(id, userID)
+}
+
+// LinkContact -> linking an already linked contact changes its relationship
+func (repo *Repository) LinkContact(link model.ApplicationContact, userID string) (*model.ApplicationContact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := findContact(db, link.ContactID.String(), userID)
+	if err != nil {
+		return &model.ApplicationContact{}, err
+	}
+
+	_, err = repo.GetApplication(link.ApplicationID.String(), userID)
+	if err != nil {
+		return &model.ApplicationContact{}, err
+	}
+
+	err = db.Exec(`
+		INSERT INTO application_contacts (application_id, contact_id, relationship, created_at)
+		VALUES (?, ?, ?, now())
+		ON CONFLICT (application_id, contact_id) DO UPDATE SET relationship = EXCLUDED.relationship`,
+		link.ApplicationID, link.ContactID, link.Relationship).Error
+	if err != nil {
+		logger.Infof("Failed to link the contact in Postgres")
+		return &model.ApplicationContact{}, err
+	}
+
+	linked := model.ApplicationContact{}
+	err = db.Preload("Contact").Where("application_id = ? AND contact_id = ?", link.ApplicationID, link.ContactID).Take(&linked).Error
+	if err != nil {
+		logger.Infof("Failed to get the contact link from Postgres")
+		return &model.ApplicationContact{}, err
+	}
+
+	return &linked, nil
+}
+
+// UnlinkContact ...
+func (repo *Repository) UnlinkContact(contactID, applicationID, userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := findContact(db, contactID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = db.Where("contact_id = ? AND application_id = ?", contactID, applicationID).Delete(&model.ApplicationContact{}).Error
+	if err != nil {
+		logger.Infof("Failed to unlink the contact in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// ApplicationContacts -> contacts of the application with their relationship
+func (repo *Repository) ApplicationContacts(applicationID, userID string) (*[]model.ApplicationContact, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	links := []model.ApplicationContact{}
+
+	_, err := repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return &[]model.ApplicationContact{}, err
+	}
+
+	// Links to contacts in the trash are kept for a restore but hidden
+	err = db.Preload("Contact").
+		Where("application_id = ? AND contact_id IN (SELECT id FROM contacts WHERE deleted_at IS NULL)", applicationID).
+		Order("created_at asc").Find(&links).Error
+	if err != nil {
+		logger.Infof("Failed to get the application contacts from Postgres")
+		return &[]model.ApplicationContact{}, err
+	}
+
+	return &links, nil
+}
+
+// CreateContactInteraction -> moves last_contacted_at of the contact forward, never back
+func (repo *Repository) CreateContactInteraction(interaction model.ContactInteraction) (*model.ContactInteraction, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	userID := interaction.UserID.String()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := findContact(tx, interaction.ContactID.String(), userID)
+		if err != nil {
+			return err
+		}
+
+		if interaction.ApplicationID != nil {
+			err = tx.Model(&model.Application{}).Where("id = ? AND user_id = ?", interaction.ApplicationID, userID).Take(&model.Application{}).Error
+			if gorm.IsRecordNotFoundError(err) {
+				return storage.ErrApplicationNotFound
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Create(&interaction).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.Contact{}).Where("id = ?", interaction.ContactID).
+			Update("last_contacted_at", gorm.Expr("GREATEST(last_contacted_at, ?)", interaction.OccurredAt)).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to create contact interaction in Postgres")
+		return &model.ContactInteraction{}, err
+	}
+
+	return &interaction, nil
+}
+
+// AllContactInteractions -> interactions with the contact, latest first
+func (repo *Repository) AllContactInteractions(contactID, userID string) (*[]model.ContactInteraction, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	interactions := []model.ContactInteraction{}
+
+	_, err := repo.GetContact(contactID, userID)
+	if err != nil {
+		return &[]model.ContactInteraction{}, err
+	}
+
+	err = db.Model(&model.ContactInteraction{}).Where("contact_id = ? AND user_id = ?", contactID, userID).Order("occurred_at desc").Find(&interactions).Error
+	if err != nil {
+		logger.Infof("Failed to get the contact interactions from Postgres")
+		return &[]model.ContactInteraction{}, err
+	}
+
+	return &interactions, nil
+}
+
+func findContact(db *gorm.DB, id, userID string) (*model.Contact, error) {
+
+	contact := model.Contact{}
+
+	err := db.Model(&model.Contact{}).Where("id = ? AND user_id = ?", id, userID).Take(&contact).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return &model.Contact{}, storage.ErrContactNotFound
+	}
+
+	if err != nil {
+		return &model.Contact{}, err
+	}
+
+	return &contact, nil
+}
+
+// linkContactCompany -> takes the company name from CompanyID when it is set
+func linkContactCompany(db *gorm.DB, contact *model.Contact, userID string) error {
+
+	if contact.CompanyID == nil {
+		return nil
+	}
+
+	company, err := findCompany(db, contact.CompanyID.String(), userID)
+	if err != nil {
+		return err
+	}
+	contact.Company = company.Name
+	return nil
+}
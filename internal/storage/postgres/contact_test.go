@@ -0,0 +1,127 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestContactInteractions(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	contact, err := pgRepo.CreateContact(model.Contact{
+		Name:   "Jane Doe",
+		Role:   "Recruiter",
+		UserID: application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	latest := time.Date(2020, time.September, 2, 9, 0, 0, 0, time.UTC)
+	for _, occurredAt := range []time.Time{latest, latest.Add(-24 * time.Hour)} {
+		_, err = pgRepo.CreateContactInteraction(model.ContactInteraction{
+			Channel:       model.ChannelEmail,
+			OccurredAt:    occurredAt,
+			ContactID:     contact.ID,
+			ApplicationID: &application.ID,
+			UserID:        application.UserID,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	contact, err = pgRepo.GetContact(contact.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	interactions, err := pgRepo.AllContactInteractions(contact.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, contact.LastContactedAt.Equal(latest), true)
+	assert.Equal(t, len(*interactions), 2)
+}
+
+func TestLinkContact(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	contact, err := pgRepo.CreateContact(model.Contact{
+		Name:   "Jane Doe",
+		UserID: application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	link := model.ApplicationContact{
+		ApplicationID: application.ID,
+		ContactID:     contact.ID,
+		Relationship:  model.RelationshipRecruiter,
+	}
+
+	_, err = pgRepo.LinkContact(link, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	link.Relationship = model.RelationshipHiringManager
+	linked, err := pgRepo.LinkContact(link, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	links, err := pgRepo.ApplicationContacts(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, linked.Relationship, model.RelationshipHiringManager)
+	assert.Equal(t, linked.Contact.Name, "Jane Doe")
+	assert.Equal(t, len(*links), 1)
+
+	_, err = pgRepo.LinkContact(link, uuid.NewV4().String())
+	assert.Equal(t, err, storage.ErrContactNotFound)
+
+	isDeleted, err := pgRepo.DeleteContact(contact.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	links, err = pgRepo.ApplicationContacts(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, isDeleted, int64(1))
+	assert.Equal(t, len(*links), 0)
+}
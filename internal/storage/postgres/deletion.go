@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// dueDeletionsBatch -> users deleted at most per run, the rest wait for the next one
+const dueDeletionsBatch = 100
+
+// ScheduleUserDeletion -> the user is deleted once dueAt passes, every session of theirs ends meanwhile
+func (repo *Repository) ScheduleUserDeletion(id string, dueAt time.Time, userID string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	if id != userID {
+		logger.Infof("User can't reach another user in Postgres")
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"deletion_due_at":     dueAt,
+			"sessions_revoked_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		return tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", now).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to schedule the deletion of the user in Postgres")
+		return &model.User{}, err
+	}
+
+	return repo.FindUser(id)
+}
+
+// CancelUserDeletion ...
+func (repo *Repository) CancelUserDeletion(id string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.User{}).Where("id = ?", id).UpdateColumn("deletion_due_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to cancel the deletion of the user in Postgres")
+		return &model.User{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, storage.ErrUserNotFound
+	}
+
+	return repo.FindUser(id)
+}
+
+// DueUserDeletions -> IDs of the users whose deletion is due at now, oldest first
+func (repo *Repository) DueUserDeletions(now time.Time) ([]string, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	ids := []string{}
+
+	err := db.Model(&model.User{}).Where("deletion_due_at <= ?", now).Order("deletion_due_at asc").Limit(dueDeletionsBatch).Pluck("id", &ids).Error
+	if err != nil {
+		logger.Infof("Failed to get the users due for deletion from Postgres")
+		return []string{}, err
+	}
+
+	return ids, nil
+}
+
+// DeleteUser -> moves the user to the trash if their deletion is still due at now. They can't sign in
+// anymore, and are purged along with everything they own once the trash retention passes.
+func (repo *Repository) DeleteUser(id string, now time.Time) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Waits for other replicas deleting users or purging the trash
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?)", trashLockKey).Error
+		if err != nil {
+			return err
+		}
+
+		user := model.User{}
+
+		// Locked so a login can't cancel the deletion meanwhile
+		err = tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ? AND deletion_due_at <= ?", id, now).Take(&user).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrUserNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"deleted_at":          now,
+			"sessions_revoked_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&model.AuditEvent{Action: model.AuditUserDeleted, SubjectID: &user.ID, Reason: "Deletion requested by the user"}).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found or not due for deletion in Postgres")
+		return err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to delete the user in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// DeletedUsers -> users in the trash, most recently deleted first
+func (repo *Repository) DeletedUsers() (*[]model.TrashItem, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	items := []model.TrashItem{}
+
+	err := db.Raw(`
+		SELECT ?::text AS kind, id, email AS title, deleted_at
+		FROM users WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT 100`,
+		model.TrashUser).Scan(&items).Error
+	if err != nil {
+		logger.Infof("Failed to get the deleted users from Postgres")
+		return &[]model.TrashItem{}, err
+	}
+
+	return &items, nil
+}
+
+// RestoreUser -> takes the user out of the trash, their deletion is canceled and they can sign in again
+func (repo *Repository) RestoreUser(id string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Unscoped().Model(&model.User{}).Where("id = ? AND deleted_at IS NOT NULL", id).UpdateColumns(map[string]interface{}{
+		"deleted_at":      nil,
+		"deletion_due_at": nil,
+	})
+	if result.Error != nil {
+		logger.Infof("Failed to restore the user in Postgres")
+		return &model.User{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("User not found in the trash in Postgres")
+		return &model.User{}, storage.ErrUserNotFound
+	}
+
+	return repo.FindUser(id)
+}
+
+// purgeUser -> deletes the user along with everything they own. The audit trail is kept without
+// anything personal in it. Returns the hashes of the document content no other user has.
+func purgeUser(tx *gorm.DB, id string) ([]string, error) {
+
+	user := model.User{}
+	err := tx.Unscoped().Where("id = ?", id).Take(&user).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	hashes := []string{}
+	err = tx.Unscoped().Model(&model.Document{}).Where("user_id = ?", id).Pluck("DISTINCT sha256", &hashes).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	// Join tables first, they reference rows of the user from both sides
+	statements := []string{
+		"DELETE FROM application_documents WHERE document_id IN (SELECT id FROM documents WHERE user_id = ?) OR application_id IN (SELECT id FROM applications WHERE user_id = ?)",
+		"DELETE FROM application_contacts WHERE contact_id IN (SELECT id FROM contacts WHERE user_id = ?) OR application_id IN (SELECT id FROM applications WHERE user_id = ?)",
+		"DELETE FROM application_events WHERE user_id = ? OR application_id IN (SELECT id FROM applications WHERE user_id = ?)",
+	}
+	for _, statement := range statements {
+		err = tx.Exec(statement, id, id).Error
+		if err != nil {
+			return []string{}, err
+		}
+	}
+
+	owned := []interface{}{
+		&model.ContactInteraction{}, &model.Contact{}, &model.Reminder{}, &model.Interview{}, &model.Document{},
+		&model.Application{}, &model.Company{}, &model.UserToken{}, &model.RefreshToken{}, &model.RecoveryCode{},
+		&model.APIKey{}, &model.Identity{},
+	}
+	for _, value := range owned {
+		err = tx.Unscoped().Where("user_id = ?", id).Delete(value).Error
+		if err != nil {
+			return []string{}, err
+		}
+	}
+
+	// Queued emails hold personal data too, like the messages of reminders
+	err = tx.Unscoped().Where(`"to" = ?`, user.Email).Delete(&model.Email{}).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	err = tx.Unscoped().Where("id = ?", id).Delete(&model.User{}).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	err = tx.Create(&model.AuditEvent{Action: model.AuditUserPurged, SubjectID: &user.ID}).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	return orphanHashes(tx, hashes)
+}
+
+// ExportUser -> reads everything the user owns in one transaction, so the export is consistent
+func (repo *Repository) ExportUser(id, userID string) (*model.UserExport, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	if id != userID {
+		logger.Infof("User can't reach another user in Postgres")
+		return &model.UserExport{}, storage.ErrForbidden
+	}
+
+	export := model.UserExport{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", id).Take(&export.User).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrUserNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Items in the trash are still stored, so they're exported too
+		owned := []interface{}{
+			&export.Companies, &export.Applications, &export.Interviews, &export.Reminders,
+			&export.Documents, &export.Contacts, &export.Interactions, &export.Identities,
+		}
+		for _, values := range owned {
+			err = tx.Unscoped().Where("user_id = ?", id).Order("created_at asc").Find(values).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Where("application_id IN (SELECT id FROM applications WHERE user_id = ?)", id).Order("created_at asc").Find(&export.Events).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Preload("Contact").
+			Where("application_id IN (SELECT id FROM applications WHERE user_id = ?)", id).
+			Order("created_at asc").Find(&export.ApplicationContacts).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.UserExport{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to export the user from Postgres")
+		return &model.UserExport{}, err
+	}
+
+	return &export, nil
+}
@@ -0,0 +1,289 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestScheduleUserDeletion(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dueAt := time.Now().Add(model.DefaultDeletionGracePeriod)
+	scheduled, err := pgRepo.ScheduleUserDeletion(user.ID.String(), dueAt, user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, scheduled.DeletionScheduled(), true)
+	assert.Equal(t, scheduled.SessionValid(time.Now().Add(-time.Minute)), false)
+
+	// Not due yet
+	ids, err := pgRepo.DueUserDeletions(time.Now())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ids), 0)
+
+	err = pgRepo.DeleteUser(user.ID.String(), time.Now())
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	ids, err = pgRepo.DueUserDeletions(dueAt)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, ids, []string{user.ID.String()})
+
+	canceled, err := pgRepo.CancelUserDeletion(user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, canceled.DeletionScheduled(), false)
+
+	ids, err = pgRepo.DueUserDeletions(dueAt)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(ids), 0)
+}
+
+func TestDeleteUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	_, err = pgRepo.CreateReminder(model.Reminder{
+		DueAt:         time.Now().Add(-time.Minute),
+		Message:       "Follow up",
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.ScheduleUserDeletion(userID, time.Now().Add(-time.Minute), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.DeleteUser(userID, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.FindUser(userID)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	// Deleting twice finds nothing due
+	err = pgRepo.DeleteUser(userID, time.Now())
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	// Reminders of users in the trash don't fire
+	fired, err := pgRepo.FireDueReminders(time.Now(), func(model.Reminder) error { return nil })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fired, 0)
+
+	items, err := pgRepo.DeletedUsers()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*items), 1)
+	assert.Equal(t, (*items)[0].Kind, model.TrashUser)
+	assert.Equal(t, (*items)[0].ID, application.UserID)
+
+	restored, err := pgRepo.RestoreUser(userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, restored.DeletionScheduled(), false)
+
+	_, err = pgRepo.RestoreUser(userID)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	// Everything they own comes back with them
+	_, err = pgRepo.GetApplication(application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+}
+
+func TestPurgeUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	applications, err := seedApplications()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application := (*applications)[0]
+	other := (*applications)[1]
+	userID := application.UserID.String()
+
+	contact, err := pgRepo.CreateContact(model.Contact{Name: "Jane Recruiter", UserID: application.UserID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.LinkContact(model.ApplicationContact{
+		ApplicationID: application.ID,
+		ContactID:     contact.ID,
+		Relationship:  model.RelationshipRecruiter,
+	}, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The same file uploaded by both users, and one only the purged user has
+	shared := strings.Repeat("a", 64)
+	own := strings.Repeat("b", 64)
+	for _, document := range []model.Document{
+		{Name: "Resume", Kind: model.DocumentResume, FileName: "resume.pdf", SHA256: shared, UserID: application.UserID},
+		{Name: "Cover Letter", Kind: model.DocumentCoverLetter, FileName: "letter.pdf", SHA256: own, UserID: application.UserID},
+		{Name: "Resume", Kind: model.DocumentResume, FileName: "resume.pdf", SHA256: shared, UserID: other.UserID},
+	} {
+		_, err := pgRepo.CreateDocument(document)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	documents, err := pgRepo.AllDocuments(userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.LinkDocument((*documents)[0].ID.String(), application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.ScheduleUserDeletion(userID, time.Now().Add(-time.Minute), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.DeleteUser(userID, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Only purged once the trash retention passes
+	orphans := []string{}
+	purged, err := pgRepo.PurgeTrash(time.Now().Add(-time.Hour), recordRemoved(&orphans))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, 0)
+
+	purged, err = pgRepo.PurgeTrash(time.Now().Add(time.Minute), recordRemoved(&orphans))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, purged, 1)
+	assert.Equal(t, orphans, []string{own})
+
+	_, err = pgRepo.FindUser(userID)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	db := pgRepo.postgres.DB
+	remaining := []struct {
+		table string
+		where string
+		arg   interface{}
+	}{
+		{table: "applications", where: "user_id = ?", arg: userID},
+		{table: "contacts", where: "user_id = ?", arg: userID},
+		{table: "documents", where: "user_id = ?", arg: userID},
+		{table: "application_contacts", where: "contact_id = ?", arg: contact.ID},
+		{table: "application_documents", where: "application_id = ?", arg: application.ID},
+	}
+	for _, rows := range remaining {
+		count := 0
+		err = db.Table(rows.table).Where(rows.where, rows.arg).Count(&count).Error
+		assert.Equal(t, err, nil)
+		assert.Equal(t, count, 0)
+	}
+
+	// The other user keeps everything
+	_, err = pgRepo.GetApplication(other.ID.String(), other.UserID.String())
+	assert.Equal(t, err, nil)
+
+	count := 0
+	err = db.Model(&model.Document{}).Where("sha256 = ?", shared).Count(&count).Error
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, 1)
+
+	events := []model.AuditEvent{}
+	err = db.Where("subject_id = ? AND action IN (?)", userID, []model.AuditAction{model.AuditUserDeleted, model.AuditUserPurged}).Find(&events).Error
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(events), 2)
+}
+
+func TestExportUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	applications, err := seedApplications()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application := (*applications)[0]
+	userID := application.UserID.String()
+
+	contact, err := pgRepo.CreateContact(model.Contact{Name: "Jane Recruiter", UserID: application.UserID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.LinkContact(model.ApplicationContact{
+		ApplicationID: application.ID,
+		ContactID:     contact.ID,
+		Relationship:  model.RelationshipRecruiter,
+	}, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	export, err := pgRepo.ExportUser(userID, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, export.User.ID, application.UserID)
+	assert.Equal(t, len(export.Applications), 1)
+	assert.Equal(t, export.Applications[0].ID, application.ID)
+	assert.Equal(t, len(export.Contacts), 1)
+	assert.Equal(t, len(export.ApplicationContacts), 1)
+	assert.Equal(t, export.ApplicationContacts[0].Contact.Name, "Jane Recruiter")
+
+	_, err = pgRepo.ExportUser("00000000-0000-0000-0000-000000000000", "00000000-0000-0000-0000-000000000000")
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	// Nobody exports someone else
+	_, err = pgRepo.ExportUser(userID, (*applications)[1].UserID.String())
+	assert.Equal(t, err, storage.ErrForbidden)
+}
@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateDocument -> uploads with the name of an existing document become its next version.
+// If the latest version has the same content it is returned with storage.ErrDocumentExists.
+func (repo *Repository) CreateDocument(document model.Document) (*model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	document.Name = strings.TrimSpace(document.Name)
+
+	latest := model.Document{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Waits for a trash purge removing content, see removeContent
+		err := tx.Exec("SELECT pg_advisory_xact_lock_shared(?)", contentLockKey).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ? AND lower(name) = lower(?)", document.UserID, document.Name).Order("version desc").Take(&latest).Error
+		if err != nil && !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+
+		if err == nil && latest.SHA256 == document.SHA256 {
+			return storage.ErrDocumentExists
+		}
+
+		// Versions in the trash keep their number, so restoring one can't collide with a newer upload
+		var lastVersion struct{ Version int }
+		err = tx.Unscoped().Model(&model.Document{}).Select("COALESCE(MAX(version), 0) AS version").
+			Where("user_id = ? AND lower(name) = lower(?)", document.UserID, document.Name).Scan(&lastVersion).Error
+		if err != nil {
+			return err
+		}
+
+		document.Version = lastVersion.Version + 1
+		if latest.Name != "" {
+			document.Name = latest.Name
+		}
+
+		return tx.Create(&document).Error
+	})
+	if err == storage.ErrDocumentExists {
+		return &latest, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to create document in Postgres")
+		return &model.Document{}, err
+	}
+
+	return &document, nil
+}
+
+// AllDocuments -> documents of the user, newest version first
+func (repo *Repository) AllDocuments(userID string) (*[]model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	documents := []model.Document{}
+
+	err := db.Model(&model.Document{}).Where("user_id = ?", userID).Order("lower(name) asc, version desc").Limit(100).Find(&documents).Error
+	if err != nil {
+		logger.Infof("Failed to get all documents from Postgres")
+		return &[]model.Document{}, err
+	}
+
+	return &documents, nil
+}
+
+// GetDocument ...
+func (repo *Repository) GetDocument(id, userID string) (*model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	document := model.Document{}
+
+	err := db.Model(&model.Document{}).Where("id = ? AND user_id = ?", id, userID).Take(&document).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("Document not found in Postgres")
+		return &model.Document{}, storage.ErrDocumentNotFound
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the document from Postgres")
+		return &model.Document{}, err
+	}
+
+	return &document, nil
+}
+
+// DocumentVersions -> every version of the document, newest first
+func (repo *Repository) DocumentVersions(id, userID string) (*[]model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	documents := []model.Document{}
+
+	document, err := repo.GetDocument(id, userID)
+	if err != nil {
+		return &[]model.Document{}, err
+	}
+
+	err = db.Model(&model.Document{}).Where("user_id = ? AND lower(name) = lower(?)", userID, document.Name).Order("version desc").Find(&documents).Error
+	if err != nil {
+		logger.Infof("Failed to get the document versions from Postgres")
+		return &[]model.Document{}, err
+	}
+
+	return &documents, nil
+}
+
+// DeleteDocument -> moves the document to the trash, its application links and content stay for its restore
+func (repo *Repository) DeleteDocument(id, userID string) (int64, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	db = db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Document{})
+	if db.Error != nil {
+		logger.Infof("Failed to delete the document from Postgres")
+		return 0, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		logger.Infof("Document not found in Postgres")
+		return 0, storage.ErrDocumentNotFound
+	}
+
+	return db.RowsAffected, nil
+}
+
+// RestoreDocument -> takes the document out of the trash
+func (repo *Repository) RestoreDocument(id, userID string) (*model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Unscoped().Model(&model.Document{}).Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to restore the document in Postgres")
+		return &model.Document{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Document not found in the trash in Postgres")
+		return &model.Document{}, storage.ErrDocumentNotFound
+	}
+
+	return repo.GetDocument(id, userID)
+}
+
+// LinkDocument -> records that the document was used for the application, linking twice is a no-op
+func (repo *Repository) LinkDocument(documentID, applicationID, userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetDocument(documentID, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("INSERT INTO application_documents (document_id, application_id) VALUES (?, ?) ON CONFLICT DO NOTHING", documentID, applicationID).Error
+	if err != nil {
+		logger.Infof("Failed to link the document in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// UnlinkDocument ...
+func (repo *Repository) UnlinkDocument(documentID, applicationID, userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetDocument(documentID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec("DELETE FROM application_documents WHERE document_id = ? AND application_id = ?", documentID, applicationID).Error
+	if err != nil {
+		logger.Infof("Failed to unlink the document in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// ApplicationDocuments -> documents used for the application
+func (repo *Repository) ApplicationDocuments(applicationID, userID string) (*[]model.Document, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	documents := []model.Document{}
+
+	_, err := repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return &[]model.Document{}, err
+	}
+
+	err = db.Model(&model.Document{}).
+		Joins("JOIN application_documents ON application_documents.document_id = documents.id").
+		Where("application_documents.application_id = ? AND documents.user_id = ?", applicationID, userID).
+		Order("lower(documents.name) asc, documents.version desc").
+		Find(&documents).Error
+	if err != nil {
+		logger.Infof("Failed to get the application documents from Postgres")
+		return &[]model.Document{}, err
+	}
+
+	return &documents, nil
+}
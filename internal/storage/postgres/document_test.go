@@ -0,0 +1,155 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateDocumentVersions(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	document := model.Document{
+		Name:        "Resume",
+		Kind:        model.DocumentResume,
+		FileName:    "resume.pdf",
+		ContentType: "application/pdf",
+		SHA256:      strings.Repeat("a", 64),
+		UserID:      user.ID,
+	}
+
+	first, err := pgRepo.CreateDocument(document)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	document.Name = "resume"
+	document.SHA256 = strings.Repeat("b", 64)
+	second, err := pgRepo.CreateDocument(document)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	same, err := pgRepo.CreateDocument(document)
+	assert.Equal(t, err, storage.ErrDocumentExists)
+	assert.Equal(t, same.ID, second.ID)
+
+	versions, err := pgRepo.DocumentVersions(first.ID.String(), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, first.Version, 1)
+	assert.Equal(t, second.Version, 2)
+	assert.Equal(t, second.Name, "Resume")
+	assert.Equal(t, len(*versions), 2)
+	assert.Equal(t, (*versions)[0].ID, second.ID)
+}
+
+func TestLinkDocument(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	document, err := pgRepo.CreateDocument(model.Document{
+		Name:   "Cover Letter",
+		Kind:   model.DocumentCoverLetter,
+		SHA256: strings.Repeat("c", 64),
+		UserID: application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	for i := 0; i < 2; i++ {
+		err = pgRepo.LinkDocument(document.ID.String(), application.ID.String(), userID)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	documents, err := pgRepo.ApplicationDocuments(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, len(*documents), 1)
+
+	err = pgRepo.UnlinkDocument(document.ID.String(), application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	documents, err = pgRepo.ApplicationDocuments(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, len(*documents), 0)
+}
+
+func TestForeignDocument(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	document, err := pgRepo.CreateDocument(model.Document{
+		Name:   "Resume",
+		Kind:   model.DocumentResume,
+		SHA256: strings.Repeat("d", 64),
+		UserID: application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	otherUserID := uuid.NewV4().String()
+
+	_, err = pgRepo.GetDocument(document.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrDocumentNotFound)
+
+	err = pgRepo.LinkDocument(document.ID.String(), application.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrDocumentNotFound)
+
+	_, err = pgRepo.DeleteDocument(document.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrDocumentNotFound)
+
+	isDeleted, err := pgRepo.DeleteDocument(document.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, isDeleted, int64(1))
+
+	_, err = pgRepo.RestoreDocument(document.ID.String(), otherUserID)
+	assert.Equal(t, err, storage.ErrDocumentNotFound)
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/jinzhu/gorm"
+)
+
+// emailLockKey -> advisory lock held while delivering queued emails
+const emailLockKey int64 = 7314582020
+
+// queuedEmailsBatch -> emails delivered per call of DeliverQueuedEmails
+const queuedEmailsBatch = 100
+
+// EnqueueEmail -> the email is delivered by DeliverQueuedEmails once next_attempt_at is reached
+func (repo *Repository) EnqueueEmail(email model.Email) (*model.Email, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+
+	err := db.Create(&email).Error
+	if err != nil {
+		logger.Infof("Failed to enqueue email in Postgres")
+		return &model.Email{}, err
+	}
+
+	return &email, nil
+}
+
+// DeliverQueuedEmails -> calls deliver for each email due at now.
+// Failed deliveries are retried with a backoff until model.MaxEmailAttempts is reached.
+// Calls made while another connection holds the lock deliver nothing.
+func (repo *Repository) DeliverQueuedEmails(now time.Time, deliver func(model.Email) error) (int, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	delivered := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var lock struct{ Locked bool }
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(?) AS locked", emailLockKey).Scan(&lock).Error
+		if err != nil || !lock.Locked {
+			return err
+		}
+
+		emails := []model.Email{}
+		err = tx.Where("sent_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).Order("next_attempt_at asc").Limit(queuedEmailsBatch).Find(&emails).Error
+		if err != nil {
+			return err
+		}
+
+		for _, email := range emails {
+			updates := map[string]interface{}{}
+
+			err = deliver(email)
+			if err == nil {
+				updates["sent_at"] = now
+				delivered++
+			} else {
+				email.Attempts++
+				updates["attempts"] = email.Attempts
+				updates["last_error"] = err.Error()
+				updates["next_attempt_at"] = now.Add(email.Backoff())
+				if email.Attempts >= model.MaxEmailAttempts {
+					logger.Warnf("Giving up on email %s: %s", email.ID.String(), err.Error())
+					updates["failed_at"] = now
+				}
+			}
+
+			err = tx.Model(&model.Email{}).Where("id = ?", email.ID).Updates(updates).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Infof("Failed to deliver queued emails from Postgres")
+		return delivered, err
+	}
+
+	return delivered, nil
+}
@@ -0,0 +1,66 @@
+// +build integration
+
+package postgres
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestDeliverQueuedEmails(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	email, err := pgRepo.EnqueueEmail(model.Email{
+		To:            "jane@example.com",
+		Subject:       "Reminder",
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	delivered, err := pgRepo.DeliverQueuedEmails(now, func(email model.Email) error {
+		return errors.New("Connection refused")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, delivered, 0)
+
+	// Not due again until the backoff passed
+	delivered, err = pgRepo.DeliverQueuedEmails(now, func(email model.Email) error {
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, delivered, 0)
+
+	delivered, err = pgRepo.DeliverQueuedEmails(now.Add(time.Hour), func(email model.Email) error {
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sent := model.Email{}
+	err = pgRepo.postgres.DB.Where("id = ?", email.ID).Take(&sent).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, delivered, 1)
+	assert.Equal(t, sent.Attempts, 1)
+	assert.Equal(t, sent.LastError, "Connection refused")
+	assert.NotEqual(t, sent.SentAt, nil)
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+// GetApplicationTimeline -> events of the application, oldest first
+func (repo *Repository) GetApplicationTimeline(id, userID string) (*[]model.ApplicationEvent, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	events := []model.ApplicationEvent{}
+
+	_, err := repo.GetApplication(id, userID)
+	if err != nil {
+		return &[]model.ApplicationEvent{}, err
+	}
+
+	err = db.Model(&model.ApplicationEvent{}).Where("application_id = ?", id).Order("created_at asc").Find(&events).Error
+	if err != nil {
+		logger.Infof("Failed to get the application timeline from Postgres")
+		return &[]model.ApplicationEvent{}, err
+	}
+
+	return &events, nil
+}
@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateOIDCState -> stores a sign in started with an identity provider, expired ones are pruned
+func (repo *Repository) CreateOIDCState(state model.OIDCState) (*model.OIDCState, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	state.UsedAt = nil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("expires_at <= ?", time.Now()).Delete(&model.OIDCState{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&state).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to create OIDC state in Postgres")
+		return &model.OIDCState{}, err
+	}
+
+	return &state, nil
+}
+
+// ConsumeOIDCState -> uses the state with the given hash, which has to be for provider
+func (repo *Repository) ConsumeOIDCState(hash, provider string) (*model.OIDCState, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	state := model.OIDCState{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("hash = ? AND provider = ? AND used_at IS NULL AND expires_at > ?", hash, provider, time.Now()).
+			Take(&state).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrTokenInvalid
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&state).UpdateColumn("used_at", time.Now()).Error
+	})
+	if err == storage.ErrTokenInvalid {
+		logger.Infof("OIDC state not found in Postgres")
+		return &model.OIDCState{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to use the OIDC state in Postgres")
+		return &model.OIDCState{}, err
+	}
+
+	return &state, nil
+}
+
+// SignInWithIdentity -> user the identity is linked to. Unknown identities are linked to the user with
+// the same email when the provider verified it, otherwise a user without password is created from profile.
+// profile.IsVerified tells whether the provider verified the email.
+func (repo *Repository) SignInWithIdentity(identity model.Identity, profile model.User) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	user := model.User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		linked := model.Identity{}
+		err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).Take(&linked).Error
+		if err == nil {
+			err = tx.Where("id = ?", linked.UserID).Take(&user).Error
+			if gorm.IsRecordNotFoundError(err) {
+				// The user deleted their account, it's in the trash until purged
+				return storage.ErrUserNotFound
+			}
+			return err
+		}
+
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+
+		// Users in the trash keep their email until they're purged
+		err = tx.Unscoped().Set("gorm:query_option", "FOR UPDATE").Where("email = ?", profile.Email).Take(&user).Error
+		switch {
+		case err == nil && user.DeletedAt != nil:
+			return storage.ErrIdentityConflict
+
+		case gorm.IsRecordNotFoundError(err):
+			user = model.User{
+				Email:      profile.Email,
+				IsVerified: profile.IsVerified,
+				FirstName:  profile.FirstName,
+				LastName:   profile.LastName,
+				Role:       model.RoleUser,
+			}
+			err = tx.Create(&user).Error
+			if err != nil {
+				return err
+			}
+
+		case err != nil:
+			return err
+
+		case !profile.IsVerified:
+			return storage.ErrIdentityConflict
+
+		case !user.IsVerified:
+			// Whoever registered the email never proved owning it, unlike the provider's user,
+			// so their password and sessions are dropped rather than handed the account
+			now := time.Now()
+			err = tx.Model(&user).UpdateColumns(map[string]interface{}{
+				"password":            "",
+				"is_verified":         true,
+				"sessions_revoked_at": now,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).UpdateColumn("revoked_at", now).Error
+			if err != nil {
+				return err
+			}
+
+			user.Password = ""
+			user.IsVerified = true
+			user.SessionsRevokedAt = &now
+		}
+
+		identity.UserID = user.ID
+		return tx.Create(&identity).Error
+	})
+	if err == storage.ErrIdentityConflict {
+		logger.Infof("Identity email used by another user in Postgres")
+		return &model.User{}, err
+	}
+
+	if err == storage.ErrUserNotFound {
+		logger.Infof("Identity user not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to sign in with the identity in Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
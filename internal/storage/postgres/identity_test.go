@@ -0,0 +1,136 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestSignInWithIdentity(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// An unverified email can't take over the existing user
+	_, err = pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "google", Subject: "1"},
+		model.User{Email: user.Email, IsVerified: false},
+	)
+	assert.Equal(t, err, storage.ErrIdentityConflict)
+
+	// A verified one is linked to it, dropping the password nobody proved owning the email with
+	linked, err := pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "google", Subject: "1", Email: user.Email},
+		model.User{Email: user.Email, IsVerified: true},
+	)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, linked.ID, user.ID)
+	assert.Equal(t, linked.HasPassword(), false)
+	assert.Equal(t, linked.IsVerified, true)
+
+	// The identity is found again even once the email changed at the provider
+	again, err := pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "google", Subject: "1", Email: "other@gmail.com"},
+		model.User{Email: "other@gmail.com", IsVerified: true},
+	)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, again.ID, user.ID)
+
+	created, err := pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "github", Subject: "2", Email: "janedoe@gmail.com"},
+		model.User{Email: "janedoe@gmail.com", IsVerified: false, FirstName: "Jane"},
+	)
+	assert.Equal(t, err, nil)
+	assert.NotEqual(t, created.ID, user.ID)
+	assert.Equal(t, created.HasPassword(), false)
+	assert.Equal(t, created.Role, model.RoleUser)
+	assert.Equal(t, created.FirstName, "Jane")
+}
+
+func TestSignInWithIdentity_DeletedUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "google", Subject: "1", Email: user.Email},
+		model.User{Email: user.Email, IsVerified: true},
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.ScheduleUserDeletion(user.ID.String(), time.Now().Add(-time.Minute), user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.DeleteUser(user.ID.String(), time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Users in the trash can't sign in, nor can their email be taken until they're purged
+	_, err = pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "google", Subject: "1", Email: user.Email},
+		model.User{Email: user.Email, IsVerified: true},
+	)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	_, err = pgRepo.SignInWithIdentity(
+		model.Identity{Provider: "github", Subject: "2", Email: user.Email},
+		model.User{Email: user.Email, IsVerified: true},
+	)
+	assert.Equal(t, err, storage.ErrIdentityConflict)
+}
+
+func TestConsumeOIDCState(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hash := auth.HashToken("state")
+	_, err = pgRepo.CreateOIDCState(model.OIDCState{
+		Provider:  "google",
+		Hash:      hash,
+		Nonce:     "nonce",
+		Verifier:  "verifier",
+		ExpiresAt: time.Now().Add(model.OIDCStateTTL),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.ConsumeOIDCState(hash, "github")
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	state, err := pgRepo.ConsumeOIDCState(hash, "google")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, state.Verifier, "verifier")
+
+	_, err = pgRepo.ConsumeOIDCState(hash, "google")
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
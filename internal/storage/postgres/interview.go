@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateInterview -> interviews without a round get the next one of the application
+func (repo *Repository) CreateInterview(interview model.Interview) (*model.Interview, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetApplication(interview.ApplicationID.String(), interview.UserID.String())
+	if err != nil {
+		return &model.Interview{}, err
+	}
+
+	if interview.Round == 0 {
+		// Rounds in the trash count too, they may be restored
+		var lastRound struct{ Round int }
+		err = db.Unscoped().Model(&model.Interview{}).Select("COALESCE(MAX(round), 0) AS round").Where("application_id = ?", interview.ApplicationID).Scan(&lastRound).Error
+		if err != nil {
+			logger.Infof("Failed to get the last interview round from Postgres")
+			return &model.Interview{}, err
+		}
+		interview.Round = lastRound.Round + 1
+	}
+
+	err = db.Create(&interview).Error
+	if err != nil {
+		logger.Infof("Failed to create interview in Postgres")
+		return &model.Interview{}, err
+	}
+
+	return &interview, nil
+}
+
+// AllInterviews -> interviews of the application, soonest first
+func (repo *Repository) AllInterviews(applicationID, userID string) (*[]model.Interview, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	interviews := []model.Interview{}
+
+	_, err := repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return &[]model.Interview{}, err
+	}
+
+	err = db.Model(&model.Interview{}).Where("application_id = ? AND user_id = ?", applicationID, userID).Order("scheduled_at asc").Find(&interviews).Error
+	if err != nil {
+		logger.Infof("Failed to get all interviews from Postgres")
+		return &[]model.Interview{}, err
+	}
+
+	return &interviews, nil
+}
+
+// GetInterview ...
+func (repo *Repository) GetInterview(id, applicationID, userID string) (*model.Interview, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	interview := model.Interview{}
+
+	err := db.Model(&model.Interview{}).Where("id = ? AND application_id = ? AND user_id = ?", id, applicationID, userID).Take(&interview).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("Interview not found in Postgres")
+		return &model.Interview{}, storage.ErrInterviewNotFound
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the interview from Postgres")
+		return &model.Interview{}, err
+	}
+
+	return &interview, nil
+}
+
+// UpdateInterview ...
+func (repo *Repository) UpdateInterview(interview model.Interview, id, applicationID, userID string) (*model.Interview, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetInterview(id, applicationID, userID)
+	if err != nil {
+		return &model.Interview{}, err
+	}
+
+	err = db.Model(&model.Interview{}).Where("id = ? AND application_id = ? AND user_id = ?", id, applicationID, userID).Updates(&interview).Error
+	if err != nil {
+		logger.Infof("Failed to update the interview in Postgres")
+		return &model.Interview{}, err
+	}
+
+	return repo.GetInterview(id, applicationID, userID)
+}
+
+// DeleteInterview -> moves the interview to the trash, see RestoreInterview
+func (repo *Repository) DeleteInterview(id, applicationID, userID string) (int64, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	db = db.Where("id = ? AND application_id = ? AND user_id = ?", id, applicationID, userID).Delete(&model.Interview{})
+	if db.Error != nil {
+		logger.Infof("Failed to delete the interview from Postgres")
+		return 0, db.Error
+	}
+
+	if db.RowsAffected == 0 {
+		logger.Infof("Interview not found in Postgres")
+		return 0, storage.ErrInterviewNotFound
+	}
+
+	return db.RowsAffected, nil
+}
+
+// RestoreInterview -> takes the interview out of the trash, the application has to be out of it too
+func (repo *Repository) RestoreInterview(id, applicationID, userID string) (*model.Interview, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return &model.Interview{}, err
+	}
+
+	result := db.Unscoped().Model(&model.Interview{}).Where("id = ? AND application_id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, applicationID, userID).UpdateColumn("deleted_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to restore the interview in Postgres")
+		return &model.Interview{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Interview not found in the trash in Postgres")
+		return &model.Interview{}, storage.ErrInterviewNotFound
+	}
+
+	return repo.GetInterview(id, applicationID, userID)
+}
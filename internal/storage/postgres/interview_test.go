@@ -0,0 +1,148 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestCreateInterview(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scheduledAt := time.Date(2020, time.September, 1, 14, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		_, err = pgRepo.CreateInterview(model.Interview{
+			Type:          model.InterviewPhone,
+			ScheduledAt:   scheduledAt.Add(time.Duration(i) * 24 * time.Hour),
+			TimeZone:      "America/New_York",
+			Interviewers:  model.StringList{"Jane Doe", "John Smith"},
+			ApplicationID: application.ID,
+			UserID:        application.UserID,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	interviews, err := pgRepo.AllInterviews(application.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	first := (*interviews)[0]
+	assert.Equal(t, len(*interviews), 2)
+	assert.Equal(t, first.Round, 1)
+	assert.Equal(t, (*interviews)[1].Round, 2)
+	assert.Equal(t, first.ScheduledAt.Location().String(), "America/New_York")
+	assert.Equal(t, first.ScheduledAt.Equal(scheduledAt), true)
+	assert.Equal(t, len(first.Interviewers), 2)
+}
+
+func TestInterviewTimeZone(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	applicationID := application.ID.String()
+	userID := application.UserID.String()
+	scheduledAt := time.Date(2020, time.September, 1, 14, 0, 0, 0, time.UTC)
+
+	createdInterview, err := pgRepo.CreateInterview(model.Interview{
+		Type:          model.InterviewPhone,
+		ScheduledAt:   scheduledAt,
+		TimeZone:      "Asia/Tokyo",
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, createdInterview.ScheduledAt.Location().String(), "Asia/Tokyo")
+	assert.Equal(t, createdInterview.ScheduledAt.Hour(), 23)
+
+	interview, err := pgRepo.GetInterview(createdInterview.ID.String(), applicationID, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, interview.ScheduledAt.Location().String(), "Asia/Tokyo")
+	assert.Equal(t, interview.ScheduledAt.Equal(scheduledAt), true)
+
+	updatedInterview, err := pgRepo.UpdateInterview(model.Interview{TimeZone: "Europe/Paris"}, interview.ID.String(), applicationID, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, updatedInterview.ScheduledAt.Location().String(), "Europe/Paris")
+	assert.Equal(t, updatedInterview.ScheduledAt.Equal(scheduledAt), true)
+}
+
+func TestForeignInterview(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	interview, err := pgRepo.CreateInterview(model.Interview{
+		Type:          model.InterviewOnsite,
+		ScheduledAt:   time.Now(),
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	otherUserID := uuid.NewV4()
+
+	_, err = pgRepo.CreateInterview(model.Interview{
+		Type:          model.InterviewOnsite,
+		ScheduledAt:   time.Now(),
+		ApplicationID: application.ID,
+		UserID:        otherUserID,
+	})
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	_, err = pgRepo.GetInterview(interview.ID.String(), application.ID.String(), otherUserID.String())
+	assert.Equal(t, err, storage.ErrInterviewNotFound)
+
+	_, err = pgRepo.DeleteInterview(interview.ID.String(), application.ID.String(), otherUserID.String())
+	assert.Equal(t, err, storage.ErrInterviewNotFound)
+
+	isDeleted, err := pgRepo.DeleteInterview(interview.ID.String(), application.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, isDeleted, int64(1))
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/jinzhu/gorm"
+)
+
+// loginThrottleRetention -> throttles without failures for this long are pruned, longer than any lockout window
+const loginThrottleRetention = 24 * time.Hour
+
+// LoginLockedUntil -> end of the longest lockout among the keys, zero when none is locked
+func (repo *Repository) LoginLockedUntil(keys []string) (time.Time, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	throttles := []model.LoginThrottle{}
+	err := db.Where("key IN (?) AND locked_until > ?", keys, time.Now()).Find(&throttles).Error
+	if err != nil {
+		logger.Infof("Failed to get login throttles from Postgres")
+		return time.Time{}, err
+	}
+
+	lockedUntil := time.Time{}
+	for _, throttle := range throttles {
+		if throttle.LockedUntil.After(lockedUntil) {
+			lockedUntil = *throttle.LockedUntil
+		}
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure -> counts a failed login against key and locks it out as the policy says
+func (repo *Repository) RecordLoginFailure(key string, policy model.LockoutPolicy) (*model.LoginThrottle, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	throttle := model.LoginThrottle{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", now.Add(-loginThrottleRetention), now).Delete(&model.LoginThrottle{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO login_throttles (key, failures, last_failure_at) VALUES (?, 0, ?) ON CONFLICT (key) DO NOTHING`, key, now).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Set("gorm:query_option", "FOR UPDATE").Where("key = ?", key).Take(&throttle).Error
+		if err != nil {
+			return err
+		}
+
+		if now.Sub(throttle.LastFailureAt) > policy.Window {
+			throttle.Failures = 0
+		}
+
+		throttle.Failures++
+		throttle.LastFailureAt = now
+		throttle.LockedUntil = nil
+		if lockout := policy.Lockout(throttle.Failures); lockout > 0 {
+			lockedUntil := now.Add(lockout)
+			throttle.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&model.LoginThrottle{}).Where("key = ?", key).UpdateColumns(map[string]interface{}{
+			"failures":        throttle.Failures,
+			"last_failure_at": throttle.LastFailureAt,
+			"locked_until":    throttle.LockedUntil,
+		}).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to record the login failure in Postgres")
+		return &model.LoginThrottle{}, err
+	}
+
+	return &throttle, nil
+}
+
+// ResetLoginFailures -> forgets the failures of key, after a successful login
+func (repo *Repository) ResetLoginFailures(key string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Where("key = ?", key).Delete(&model.LoginThrottle{}).Error
+	if err != nil {
+		logger.Infof("Failed to reset the login failures in Postgres")
+		return err
+	}
+
+	return nil
+}
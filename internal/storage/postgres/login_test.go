@@ -0,0 +1,58 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	policy := model.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: 3 * time.Minute, Window: time.Hour}
+	key := "account:random@gmail.com"
+
+	for i := 1; i < policy.Threshold; i++ {
+		throttle, err := pgRepo.RecordLoginFailure(key, policy)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, throttle.Failures, i)
+		assert.Equal(t, throttle.LockedUntil, (*time.Time)(nil))
+	}
+
+	lockedUntil, err := pgRepo.LoginLockedUntil([]string{key, "ip:127.0.0.1"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lockedUntil.IsZero(), true)
+
+	// The lockout doubles with each further failure, up to the policy's max
+	throttle, err := pgRepo.RecordLoginFailure(key, policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, throttle.LockedUntil.Sub(throttle.LastFailureAt), time.Minute)
+
+	throttle, err = pgRepo.RecordLoginFailure(key, policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, throttle.LockedUntil.Sub(throttle.LastFailureAt), 2*time.Minute)
+
+	throttle, err = pgRepo.RecordLoginFailure(key, policy)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, throttle.LockedUntil.Sub(throttle.LastFailureAt), 3*time.Minute)
+
+	lockedUntil, err = pgRepo.LoginLockedUntil([]string{key, "ip:127.0.0.1"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lockedUntil.After(time.Now()), true)
+
+	err = pgRepo.ResetLoginFailures(key)
+	assert.Equal(t, err, nil)
+
+	lockedUntil, err = pgRepo.LoginLockedUntil([]string{key})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, lockedUntil.IsZero(), true)
+}
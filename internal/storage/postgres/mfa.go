@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// SetTOTPSecret -> stores the sealed secret of an enrolment, two-factor authentication is enabled once a code is confirmed
+func (repo *Repository) SetTOTPSecret(userID, secret string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.User{}).Where("id = ? AND totp_enabled_at IS NULL", userID).UpdateColumn("totp_secret", secret)
+	if result.Error != nil {
+		logger.Infof("Failed to set the TOTP secret in Postgres")
+		return &model.User{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("User without two-factor authentication not found in Postgres")
+		return &model.User{}, storage.ErrUserNotFound
+	}
+
+	return repo.FindUser(userID)
+}
+
+// EnableTOTP -> enables two-factor authentication with the code of the given period used, replacing the recovery codes
+func (repo *Repository) EnableTOTP(userID string, step int64, recoveryCodes []string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ? AND totp_secret <> '' AND totp_enabled_at IS NULL", userID).UpdateColumns(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		return replaceRecoveryCodes(tx, userID, recoveryCodes)
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User enrolling in two-factor authentication not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to enable two-factor authentication in Postgres")
+		return &model.User{}, err
+	}
+
+	return repo.FindUser(userID)
+}
+
+// DisableTOTP -> removes the secret and recovery codes of the user
+func (repo *Repository) DisableTOTP(userID string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ?", userID).UpdateColumns(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to disable two-factor authentication in Postgres")
+		return &model.User{}, err
+	}
+
+	return repo.FindUser(userID)
+}
+
+// ReplaceRecoveryCodes -> the previous codes of the user stop working, used or not
+func (repo *Repository) ReplaceRecoveryCodes(userID string, recoveryCodes []string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, recoveryCodes)
+	})
+	if err != nil {
+		logger.Infof("Failed to replace the recovery codes in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// UseTOTPStep -> records the period of an accepted code, periods at or before the last one used are rejected
+func (repo *Repository) UseTOTPStep(userID string, step int64) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.User{}).Where("id = ? AND totp_last_step < ?", userID, step).UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		logger.Infof("Failed to record the TOTP code in Postgres")
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("TOTP code already used")
+		return storage.ErrTokenInvalid
+	}
+
+	return nil
+}
+
+// UseRecoveryCode -> uses the unused recovery code of the user with the given hash
+func (repo *Repository) UseRecoveryCode(userID, hash string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.RecoveryCode{}).Where("user_id = ? AND hash = ? AND used_at IS NULL", userID, hash).UpdateColumn("used_at", time.Now())
+	if result.Error != nil {
+		logger.Infof("Failed to use the recovery code in Postgres")
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Recovery code not found in Postgres")
+		return storage.ErrTokenInvalid
+	}
+
+	return nil
+}
+
+// GetMFAChallenge -> user of the outstanding MFA challenge with the given hash
+func (repo *Repository) GetMFAChallenge(hash string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	token := model.UserToken{}
+	err := db.Where("hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, model.TokenMFAChallenge, time.Now()).Take(&token).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("MFA challenge not found in Postgres")
+		return &model.User{}, storage.ErrTokenInvalid
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the MFA challenge from Postgres")
+		return &model.User{}, err
+	}
+
+	return repo.FindUser(token.UserID.String())
+}
+
+// FailMFAChallenge -> counts a wrong code against the challenge, which is used up after model.MFAChallengeAttempts
+func (repo *Repository) FailMFAChallenge(hash string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Model(&model.UserToken{}).Where("hash = ? AND purpose = ? AND used_at IS NULL", hash, model.TokenMFAChallenge).UpdateColumns(map[string]interface{}{
+		"attempts": gorm.Expr("attempts + 1"),
+		"used_at":  gorm.Expr("CASE WHEN attempts + 1 >= ? THEN ? ELSE NULL END", model.MFAChallengeAttempts, time.Now()),
+	}).Error
+	if err != nil {
+		logger.Infof("Failed to count the MFA attempt in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// CompleteMFAChallenge -> uses the MFA challenge with the given hash once a code was accepted
+func (repo *Repository) CompleteMFAChallenge(hash string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	user := model.User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, hash, model.TokenMFAChallenge)
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", token.UserID).Take(&user).Error
+	})
+	if err == storage.ErrTokenInvalid {
+		logger.Infof("MFA challenge not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to complete the MFA challenge in Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string, recoveryCodes []string) error {
+
+	err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.FromString(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryCodes {
+		err = tx.Create(&model.RecoveryCode{Hash: hash, UserID: id}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestEnableTOTP(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := user.ID.String()
+
+	// Enabling needs a secret set up first
+	_, err = pgRepo.EnableTOTP(userID, 1, nil)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	_, err = pgRepo.SetTOTPSecret(userID, "sealed")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enabled, err := pgRepo.EnableTOTP(userID, 10, []string{auth.HashRecoveryCode("abcde-fghij")})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, enabled.MFAEnabled(), true)
+	assert.Equal(t, enabled.TOTPLastStep, int64(10))
+
+	// Codes can't be replayed
+	assert.Equal(t, pgRepo.UseTOTPStep(userID, 10), storage.ErrTokenInvalid)
+	assert.Equal(t, pgRepo.UseTOTPStep(userID, 11), nil)
+
+	// Recovery codes are single-use
+	assert.Equal(t, pgRepo.UseRecoveryCode(userID, auth.HashRecoveryCode("abcde-fghij")), nil)
+	assert.Equal(t, pgRepo.UseRecoveryCode(userID, auth.HashRecoveryCode("abcde-fghij")), storage.ErrTokenInvalid)
+
+	disabled, err := pgRepo.DisableTOTP(userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, disabled.MFAEnabled(), false)
+	assert.Equal(t, disabled.TOTPSecret, "")
+}
+
+func TestFailMFAChallenge(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hash := auth.HashToken("challenge")
+	_, err = pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenMFAChallenge,
+		Hash:      hash,
+		ExpiresAt: time.Now().Add(model.MFAChallengeTTL),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for i := 0; i < model.MFAChallengeAttempts-1; i++ {
+		err = pgRepo.FailMFAChallenge(hash)
+		assert.Equal(t, err, nil)
+	}
+
+	challenged, err := pgRepo.GetMFAChallenge(hash)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, challenged.ID, user.ID)
+
+	// The last attempt uses the challenge up
+	err = pgRepo.FailMFAChallenge(hash)
+	assert.Equal(t, err, nil)
+
+	_, err = pgRepo.GetMFAChallenge(hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	_, err = pgRepo.CompleteMFAChallenge(hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/jinzhu/gorm"
+)
+
+// migrate -> creates or updates the schema, safe to run on every start
+func migrate(db *gorm.DB) error {
+
+	err := db.AutoMigrate(
+		&model.User{},
+		&model.Company{},
+		&model.Application{},
+		&model.ApplicationEvent{},
+		&model.Interview{},
+		&model.Document{},
+		&model.Contact{},
+		&model.ApplicationContact{},
+		&model.ContactInteraction{},
+		&model.Reminder{},
+		&model.Email{},
+		&model.UserToken{},
+		&model.RefreshToken{},
+		&model.DeniedToken{},
+		&model.AuditEvent{},
+		&model.APIKey{},
+		&model.RecoveryCode{},
+		&model.Identity{},
+		&model.OIDCState{},
+		&model.LoginThrottle{},
+	).Error
+	if err != nil {
+		return err
+	}
+
+	// Company names are deduplicated ignoring case among the companies not in the trash, gorm tags
+	// can't express these indexes. The index on every company is replaced by it.
+	err = db.Exec(`DROP INDEX IF EXISTS idx_companies_user_id_lower_name`).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_companies_user_id_lower_name_live ON companies (user_id, lower(name)) WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		return err
+	}
+
+	// Versions are numbered per document name, ignoring case, documents in the trash keep theirs
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_documents_user_id_lower_name_version ON documents (user_id, lower(name), version)`).Error
+	if err != nil {
+		return err
+	}
+
+	return backfillCompanies(db)
+}
+
+// backfillCompanies -> creates companies from the free-text names of applications without one
+func backfillCompanies(db *gorm.DB) error {
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			INSERT INTO companies (created_at, updated_at, user_id, name)
+			SELECT now(), now(), user_id, min(trim(company))
+			FROM applications
+			WHERE company_id IS NULL AND trim(company) <> ''
+			GROUP BY user_id, lower(trim(company))
+			ON CONFLICT (user_id, lower(name)) WHERE deleted_at IS NULL DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(`
+			UPDATE applications
+			SET company_id = companies.id
+			FROM companies
+			WHERE applications.company_id IS NULL
+			AND companies.deleted_at IS NULL
+			AND companies.user_id = applications.user_id
+			AND lower(companies.name) = lower(trim(applications.company))`).Error
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/amaraliou/trackr-core/internal/model"
 	"github.com/amaraliou/trackr-core/pkg/logger"
 	"github.com/jinzhu/gorm"
 
@@ -44,9 +43,10 @@ func NewConnection(config *Config, logger logger.Logger) (*Connection, error) {
 		return nil, err
 	}
 
-	db.AutoMigrate(
-		&model.User{},
-	)
+	err = migrate(db)
+	if err != nil {
+		return nil, err
+	}
 
 	connection := Connection{
 		DB:     db,
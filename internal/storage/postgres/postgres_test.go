@@ -70,12 +70,12 @@ func refreshEverything() error {
 
 	db := pgRepo.postgres.DB
 
-	err := db.DropTableIfExists(&model.Application{}, &model.User{}).Error
+	err := db.DropTableIfExists(&model.LoginThrottle{}, &model.OIDCState{}, &model.Identity{}, &model.RecoveryCode{}, &model.APIKey{}, &model.AuditEvent{}, &model.DeniedToken{}, &model.RefreshToken{}, &model.UserToken{}, &model.Email{}, &model.Reminder{}, &model.ContactInteraction{}, &model.ApplicationContact{}, &model.Contact{}, "application_documents", &model.Document{}, &model.Interview{}, &model.ApplicationEvent{}, &model.Application{}, &model.Company{}, &model.User{}).Error
 	if err != nil {
 		return err
 	}
 
-	err = db.AutoMigrate(&model.User{}, &model.Application{}).Error
+	err = migrate(db)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// reminderLockKey -> advisory lock held while firing reminders, so only one replica fires them
+const reminderLockKey int64 = 7314582019
+
+// dueRemindersBatch -> reminders fired per call of FireDueReminders
+const dueRemindersBatch = 100
+
+// dueReminders -> reminders past their due date that didn't fire since and weren't dismissed, of applications and users out of the trash
+const dueReminders = "reminders.due_at <= ? AND reminders.dismissed_at IS NULL AND (reminders.fired_at IS NULL OR reminders.fired_at < reminders.due_at)" +
+	" AND reminders.application_id IN (SELECT id FROM applications WHERE deleted_at IS NULL)" +
+	" AND reminders.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)"
+
+// CreateReminder ...
+func (repo *Repository) CreateReminder(reminder model.Reminder) (*model.Reminder, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	_, err := repo.GetApplication(reminder.ApplicationID.String(), reminder.UserID.String())
+	if err != nil {
+		return &model.Reminder{}, err
+	}
+
+	reminder.FiredAt = nil
+	reminder.DismissedAt = nil
+
+	err = db.Create(&reminder).Error
+	if err != nil {
+		logger.Infof("Failed to create reminder in Postgres")
+		return &model.Reminder{}, err
+	}
+
+	return &reminder, nil
+}
+
+// AllReminders -> reminders of the application, soonest first
+func (repo *Repository) AllReminders(applicationID, userID string) (*[]model.Reminder, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	reminders := []model.Reminder{}
+
+	_, err := repo.GetApplication(applicationID, userID)
+	if err != nil {
+		return &[]model.Reminder{}, err
+	}
+
+	err = db.Model(&model.Reminder{}).Where("application_id = ? AND user_id = ?", applicationID, userID).Order("due_at asc").Find(&reminders).Error
+	if err != nil {
+		logger.Infof("Failed to get all reminders from Postgres")
+		return &[]model.Reminder{}, err
+	}
+
+	return &reminders, nil
+}
+
+// ActiveReminders -> reminders of the user that weren't dismissed, soonest first. Those of applications in the trash are left out.
+func (repo *Repository) ActiveReminders(userID string) (*[]model.Reminder, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	reminders := []model.Reminder{}
+
+	err := db.Model(&model.Reminder{}).
+		Where("user_id = ? AND dismissed_at IS NULL AND application_id IN (SELECT id FROM applications WHERE deleted_at IS NULL)", userID).
+		Order("due_at asc").Limit(100).Find(&reminders).Error
+	if err != nil {
+		logger.Infof("Failed to get active reminders from Postgres")
+		return &[]model.Reminder{}, err
+	}
+
+	return &reminders, nil
+}
+
+// GetReminder -> reminders of applications in the trash aren't found
+func (repo *Repository) GetReminder(id, applicationID, userID string) (*model.Reminder, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	reminder := model.Reminder{}
+
+	err := db.Model(&model.Reminder{}).Where("id = ? AND application_id = ? AND user_id = ? AND application_id IN (SELECT id FROM applications WHERE deleted_at IS NULL)", id, applicationID, userID).Take(&reminder).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("Reminder not found in Postgres")
+		return &model.Reminder{}, storage.ErrReminderNotFound
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the reminder from Postgres")
+		return &model.Reminder{}, err
+	}
+
+	return &reminder, nil
+}
+
+// SnoozeReminder -> moves the reminder to until, a dismissed reminder becomes active again
+func (repo *Repository) SnoozeReminder(id, applicationID, userID string, until time.Time) (*model.Reminder, error) {
+
+	return repo.updateReminder(id, applicationID, userID, map[string]interface{}{
+		"due_at":       until,
+		"dismissed_at": gorm.Expr("NULL"),
+	})
+}
+
+// DismissReminder -> dismissed reminders don't fire anymore
+func (repo *Repository) DismissReminder(id, applicationID, userID string) (*model.Reminder, error) {
+
+	return repo.updateReminder(id, applicationID, userID, map[string]interface{}{
+		"dismissed_at": time.Now(),
+	})
+}
+
+func (repo *Repository) updateReminder(id, applicationID, userID string, updates map[string]interface{}) (*model.Reminder, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.Reminder{}).Where("id = ? AND application_id = ? AND user_id = ? AND application_id IN (SELECT id FROM applications WHERE deleted_at IS NULL)", id, applicationID, userID).Updates(updates)
+	if result.Error != nil {
+		logger.Infof("Failed to update the reminder in Postgres")
+		return &model.Reminder{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("Reminder not found in Postgres")
+		return &model.Reminder{}, storage.ErrReminderNotFound
+	}
+
+	return repo.GetReminder(id, applicationID, userID)
+}
+
+// FireDueReminders -> calls fire for each due reminder, with their application and user loaded.
+// Reminders fire once, or are moved to their next occurrence, when fire succeeds and are retried on the next call otherwise.
+// Calls made while another connection holds the lock fire nothing.
+func (repo *Repository) FireDueReminders(now time.Time, fire func(model.Reminder) error) (int, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	fired := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var lock struct{ Locked bool }
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(?) AS locked", reminderLockKey).Scan(&lock).Error
+		if err != nil || !lock.Locked {
+			return err
+		}
+
+		reminders := []model.Reminder{}
+		err = tx.Preload("Application").Preload("User").Where(dueReminders, now).Order("due_at asc").Limit(dueRemindersBatch).Find(&reminders).Error
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			err = fire(reminder)
+			if err != nil {
+				logger.Warnf("Failed to fire reminder %s: %s", reminder.ID.String(), err.Error())
+				continue
+			}
+
+			updates := map[string]interface{}{"fired_at": now}
+			if next := reminder.Next(now); next != nil {
+				updates["due_at"] = *next
+			}
+
+			err = tx.Model(&model.Reminder{}).Where("id = ?", reminder.ID).Updates(updates).Error
+			if err != nil {
+				return err
+			}
+			fired++
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Infof("Failed to fire due reminders from Postgres")
+		return fired, err
+	}
+
+	return fired, nil
+}
@@ -0,0 +1,114 @@
+// +build integration
+
+package postgres
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestFireDueReminders(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	once, err := pgRepo.CreateReminder(model.Reminder{
+		DueAt:         now.Add(-time.Hour),
+		Message:       "Follow up",
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	weekly, err := pgRepo.CreateReminder(model.Reminder{
+		DueAt:         now.Add(-time.Hour),
+		Message:       "Check the job board",
+		Repeat:        model.RepeatWeekly,
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.CreateReminder(model.Reminder{
+		DueAt:         now.Add(time.Hour),
+		Message:       "Not due yet",
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	emails := []string{}
+	fired, err := pgRepo.FireDueReminders(now, func(reminder model.Reminder) error {
+		emails = append(emails, reminder.User.Email)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := pgRepo.FindUser(application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, fired, 2)
+	assert.Equal(t, emails[0], user.Email)
+
+	fired, err = pgRepo.FireDueReminders(now, func(reminder model.Reminder) error {
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, fired, 0)
+
+	weekly, err = pgRepo.GetReminder(weekly.ID.String(), application.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, weekly.DueAt.Equal(now.Add(-time.Hour).AddDate(0, 0, 7)), true)
+
+	// Snoozed reminders fire again once due
+	_, err = pgRepo.SnoozeReminder(once.ID.String(), application.ID.String(), application.UserID.String(), now.Add(time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fired, err = pgRepo.FireDueReminders(now.Add(2*time.Minute), func(reminder model.Reminder) error {
+		return errors.New("SMTP unavailable")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, fired, 0)
+
+	_, err = pgRepo.DismissReminder(once.ID.String(), application.ID.String(), application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reminders, err := pgRepo.ActiveReminders(application.UserID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, len(*reminders), 2)
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateRefreshToken -> stores the refresh token issued on login
+func (repo *Repository) CreateRefreshToken(token model.RefreshToken) (*model.RefreshToken, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	token.UsedAt = nil
+	token.RevokedAt = nil
+
+	err := db.Create(&token).Error
+	if err != nil {
+		logger.Infof("Failed to create refresh token in Postgres")
+		return &model.RefreshToken{}, err
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken -> uses the refresh token with the given hash and stores next in its family.
+// Using a token a second time revokes its whole family, since either the legitimate client or an attacker holds a copy.
+func (repo *Repository) RotateRefreshToken(hash string, next model.RefreshToken) (*model.RefreshToken, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	reused := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		token := model.RefreshToken{}
+
+		err := tx.Set("gorm:query_option", "FOR UPDATE").Where("hash = ?", hash).Take(&token).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrTokenInvalid
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if token.RevokedAt != nil || !token.ExpiresAt.After(now) {
+			return storage.ErrTokenInvalid
+		}
+
+		// Revoking has to be committed, so the transaction succeeds and the error is returned afterwards
+		if token.UsedAt != nil {
+			reused = true
+			return tx.Model(&model.RefreshToken{}).Where("family_id = ? AND revoked_at IS NULL", token.FamilyID).UpdateColumn("revoked_at", now).Error
+		}
+
+		err = tx.Model(&token).UpdateColumn("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		next.FamilyID = token.FamilyID
+		next.UserID = token.UserID
+		next.UsedAt = nil
+		next.RevokedAt = nil
+		return tx.Create(&next).Error
+	})
+	if err == storage.ErrTokenInvalid {
+		logger.Infof("Refresh token not found in Postgres")
+		return &model.RefreshToken{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to rotate the refresh token in Postgres")
+		return &model.RefreshToken{}, err
+	}
+
+	if reused {
+		logger.Warnf("Refresh token reused, revoked its family in Postgres")
+		return &model.RefreshToken{}, storage.ErrTokenReused
+	}
+
+	return &next, nil
+}
+
+// RevokeRefreshToken -> revokes the family of the user's refresh token with the given hash, unknown tokens are ignored
+func (repo *Repository) RevokeRefreshToken(hash, userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Model(&model.RefreshToken{}).
+		Where("family_id IN (SELECT family_id FROM refresh_tokens WHERE hash = ? AND user_id = ?) AND revoked_at IS NULL", hash, userID).
+		UpdateColumn("revoked_at", time.Now()).Error
+	if err != nil {
+		logger.Infof("Failed to revoke the refresh token in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// RevokeUserSessions -> revokes every refresh token of the user and rejects the access tokens issued until now
+func (repo *Repository) RevokeUserSessions(userID string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&model.User{}).Where("id = ?", userID).UpdateColumn("sessions_revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		return tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).UpdateColumn("revoked_at", now).Error
+	})
+	if err != nil {
+		logger.Infof("Failed to revoke the user sessions in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// DenyToken -> rejects the access token with the given jti until it expires, expired entries are pruned on the way
+func (repo *Repository) DenyToken(tokenID string, expiresAt time.Time) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Where("expires_at <= ?", time.Now()).Delete(&model.DeniedToken{}).Error
+	if err != nil {
+		logger.Infof("Failed to prune denied tokens in Postgres")
+		return err
+	}
+
+	err = db.Exec(`INSERT INTO denied_tokens (jti, expires_at) VALUES (?, ?) ON CONFLICT (jti) DO NOTHING`, tokenID, expiresAt).Error
+	if err != nil {
+		logger.Infof("Failed to deny the token in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// TokenDenied -> whether the access token with the given jti was revoked
+func (repo *Repository) TokenDenied(tokenID string) (bool, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	count := 0
+	err := db.Model(&model.DeniedToken{}).Where("jti = ? AND expires_at > ?", tokenID, time.Now()).Count(&count).Error
+	if err != nil {
+		logger.Infof("Failed to look up denied tokens in Postgres")
+		return false, err
+	}
+
+	return count > 0, nil
+}
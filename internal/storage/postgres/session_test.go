@@ -0,0 +1,190 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	uuid "github.com/satori/go.uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	first, err := pgRepo.CreateRefreshToken(model.RefreshToken{
+		Hash:      auth.HashToken("first"),
+		FamilyID:  uuid.NewV4(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	second, err := pgRepo.RotateRefreshToken(first.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("second"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, second.FamilyID, first.FamilyID)
+	assert.Equal(t, second.UserID, user.ID)
+
+	_, err = pgRepo.RotateRefreshToken(auth.HashToken("unknown"), model.RefreshToken{
+		Hash:      auth.HashToken("third"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	// Reusing the first token revokes the second one as well
+	_, err = pgRepo.RotateRefreshToken(first.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("third"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, err, storage.ErrTokenReused)
+
+	_, err = pgRepo.RotateRefreshToken(second.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("third"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := pgRepo.CreateRefreshToken(model.RefreshToken{
+		Hash:      auth.HashToken("token"),
+		FamilyID:  uuid.NewV4(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Tokens of another user are left alone
+	err = pgRepo.RevokeRefreshToken(token.Hash, uuid.NewV4().String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	next, err := pgRepo.RotateRefreshToken(token.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("next"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.RevokeRefreshToken(next.Hash, user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.RotateRefreshToken(next.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("last"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := pgRepo.CreateRefreshToken(model.RefreshToken{
+		Hash:      auth.HashToken("token"),
+		FamilyID:  uuid.NewV4(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.RevokeUserSessions(user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	revokedUser, err := pgRepo.FindUser(user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, revokedUser.SessionValid(time.Now().Add(-time.Minute)), false)
+
+	_, err = pgRepo.RotateRefreshToken(token.Hash, model.RefreshToken{
+		Hash:      auth.HashToken("next"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	err = pgRepo.RevokeUserSessions(uuid.NewV4().String())
+	assert.Equal(t, err, storage.ErrUserNotFound)
+}
+
+func TestDenyToken(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.DenyToken("expired", time.Now().Add(-time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.DenyToken("denied", time.Now().Add(time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Denying twice is fine
+	err = pgRepo.DenyToken("denied", time.Now().Add(time.Minute))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for tokenID, want := range map[string]bool{"expired": false, "denied": true, "other": false} {
+		denied, err := pgRepo.TokenDenied(tokenID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		assert.Equal(t, denied, want)
+	}
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"github.com/jinzhu/gorm"
+)
+
+// CreateUserToken ...
+func (repo *Repository) CreateUserToken(token model.UserToken) (*model.UserToken, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	token.UsedAt = nil
+
+	err := db.Create(&token).Error
+	if err != nil {
+		logger.Infof("Failed to create user token in Postgres")
+		return &model.UserToken{}, err
+	}
+
+	return &token, nil
+}
+
+// CountUserTokens -> tokens issued to the user for purpose since the given time, used for rate limiting
+func (repo *Repository) CountUserTokens(userID string, purpose model.TokenPurpose, since time.Time) (int, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	count := 0
+	err := db.Model(&model.UserToken{}).Where("user_id = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).Count(&count).Error
+	if err != nil {
+		logger.Infof("Failed to count user tokens in Postgres")
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetTokenUser -> user of the unused and unexpired token with the given hash, which stays unused
+func (repo *Repository) GetTokenUser(hash string, purpose model.TokenPurpose) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	user := model.User{}
+	err := db.Joins("JOIN user_tokens ON user_tokens.user_id = users.id").
+		Where("user_tokens.hash = ? AND user_tokens.purpose = ? AND user_tokens.used_at IS NULL AND user_tokens.expires_at > ?", hash, purpose, time.Now()).
+		Take(&user).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("User token not found in Postgres")
+		return &model.User{}, storage.ErrTokenInvalid
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the user of the token from Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+// VerifyUserEmail -> uses the verification token with the given hash and marks its user as verified
+func (repo *Repository) VerifyUserEmail(hash string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	user := model.User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, hash, model.TokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.User{}).Where("id = ?", token.UserID).UpdateColumn("is_verified", true).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", token.UserID).Take(&user).Error
+	})
+	if err == storage.ErrTokenInvalid {
+		logger.Infof("Verification token not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to verify the user in Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+// ResetUserPassword -> uses the reset token with the given hash to set the already hashed password of its user.
+// Tokens issued to the user before the reset are rejected from then on.
+func (repo *Repository) ResetUserPassword(hash, password string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	user := model.User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, hash, model.TokenResetPassword)
+		if err != nil {
+			return err
+		}
+
+		// UpdateColumns skips BeforeSave, which would hash the password again
+		err = tx.Model(&model.User{}).Where("id = ?", token.UserID).UpdateColumns(map[string]interface{}{
+			"password":            password,
+			"sessions_revoked_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", token.UserID).UpdateColumn("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", token.UserID).Take(&user).Error
+	})
+	if err == storage.ErrTokenInvalid {
+		logger.Infof("Reset token not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to reset the password in Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+// consumeUserToken -> marks the token as used along with the other outstanding tokens of its user and purpose
+func consumeUserToken(db *gorm.DB, hash string, purpose model.TokenPurpose) (*model.UserToken, error) {
+
+	now := time.Now()
+	token := model.UserToken{}
+
+	err := db.Set("gorm:query_option", "FOR UPDATE").
+		Where("hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+		Take(&token).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return &model.UserToken{}, storage.ErrTokenInvalid
+	}
+
+	if err != nil {
+		return &model.UserToken{}, err
+	}
+
+	err = db.Model(&model.UserToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, purpose).UpdateColumn("used_at", now).Error
+	if err != nil {
+		return &model.UserToken{}, err
+	}
+
+	token.UsedAt = &now
+	return &token, nil
+}
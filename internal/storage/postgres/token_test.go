@@ -0,0 +1,148 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/auth"
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestVerifyUserEmail(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	expired := model.UserToken{
+		Purpose:   model.TokenVerifyEmail,
+		Hash:      auth.HashToken("expired"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+		UserID:    user.ID,
+	}
+
+	valid := model.UserToken{
+		Purpose:   model.TokenVerifyEmail,
+		Hash:      auth.HashToken("valid"),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	}
+
+	for _, token := range []model.UserToken{expired, valid} {
+		_, err = pgRepo.CreateUserToken(token)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	issued, err := pgRepo.CountUserTokens(user.ID.String(), model.TokenVerifyEmail, time.Now().Add(-time.Hour))
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, issued, 2)
+
+	_, err = pgRepo.VerifyUserEmail(expired.Hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	verifiedUser, err := pgRepo.VerifyUserEmail(valid.Hash)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, verifiedUser.IsVerified, true)
+
+	_, err = pgRepo.VerifyUserEmail(valid.Hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
+
+func TestResetUserPassword(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token := model.UserToken{
+		Purpose:   model.TokenResetPassword,
+		Hash:      auth.HashToken("reset"),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	}
+
+	_, err = pgRepo.CreateUserToken(token)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Reset tokens don't verify emails
+	_, err = pgRepo.VerifyUserEmail(token.Hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	hashedPassword, err := model.Hash("new password")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resetUser, err := pgRepo.ResetUserPassword(token.Hash, string(hashedPassword))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, model.VerifyPassword(resetUser.Password, "new password"), nil)
+	assert.Equal(t, resetUser.SessionValid(time.Now().Add(-time.Minute)), false)
+
+	_, err = pgRepo.ResetUserPassword(token.Hash, string(hashedPassword))
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
+
+func TestGetTokenUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenResetPassword,
+		Hash:      auth.HashToken("valid"),
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.GetTokenUser(token.Hash, model.TokenVerifyEmail)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+
+	// Looking the user up leaves the token usable
+	tokenUser, err := pgRepo.GetTokenUser(token.Hash, model.TokenResetPassword)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, tokenUser.ID, user.ID)
+
+	_, err = pgRepo.ResetUserPassword(token.Hash, "hashed")
+	assert.Equal(t, err, nil)
+
+	_, err = pgRepo.GetTokenUser(token.Hash, model.TokenResetPassword)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/pkg/logger"
+	"github.com/jinzhu/gorm"
+)
+
+// trashBatch -> items of each kind purged at most per run, the rest wait for the next one
+const trashBatch = 100
+
+// trashLockKey -> advisory lock held while moving users to the trash or purging it, so only one replica does
+const trashLockKey int64 = 7314582021
+
+// contentLockKey -> advisory lock held while removing document content, shared by uploads so content
+// isn't removed while a new document referencing it is created
+const contentLockKey int64 = 7314582022
+
+// Trash -> applications, contacts, companies, documents and interviews the user deleted, most recently deleted first
+func (repo *Repository) Trash(userID string) (*[]model.TrashItem, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+	items := []model.TrashItem{}
+
+	err := db.Raw(`
+		SELECT ?::text AS kind, id, job_title || ' at ' || company AS title, NULL::uuid AS application_id, deleted_at
+		FROM applications WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?::text AS kind, id, name AS title, NULL::uuid AS application_id, deleted_at
+		FROM contacts WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?::text AS kind, id, name AS title, NULL::uuid AS application_id, deleted_at
+		FROM companies WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?::text AS kind, id, name || ' v' || version AS title, NULL::uuid AS application_id, deleted_at
+		FROM documents WHERE user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT ?::text AS kind, interviews.id, 'Round ' || interviews.round || ' at ' || applications.company AS title, interviews.application_id, interviews.deleted_at
+		FROM interviews JOIN applications ON applications.id = interviews.application_id
+		WHERE interviews.user_id = ? AND interviews.deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT 100`,
+		model.TrashApplication, userID, model.TrashContact, userID, model.TrashCompany, userID,
+		model.TrashDocument, userID, model.TrashInterview, userID).Scan(&items).Error
+	if err != nil {
+		logger.Infof("Failed to get the trash from Postgres")
+		return &[]model.TrashItem{}, err
+	}
+
+	return &items, nil
+}
+
+// PurgeTrash -> deletes for good the items and users deleted before the given time, with everything kept
+// for their restore. remove is called with the hash of each document content nothing references anymore,
+// content it fails to remove only takes space. Calls made while another connection holds the lock purge nothing.
+func (repo *Repository) PurgeTrash(before time.Time, remove func(string) error) (int, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	purged := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var lock struct{ Locked bool }
+		err := tx.Raw("SELECT pg_try_advisory_xact_lock(?) AS locked", trashLockKey).Scan(&lock).Error
+		if err != nil || !lock.Locked {
+			return err
+		}
+
+		orphans := []string{}
+		kinds := []struct {
+			value interface{}
+			purge func(*gorm.DB, []string) error
+		}{
+			{value: &model.Application{}, purge: purgeApplications},
+			{value: &model.Contact{}, purge: purgeContacts},
+			{value: &model.Company{}, purge: purgeCompanies},
+			{value: &model.Interview{}, purge: purgeInterviews},
+		}
+		for _, kind := range kinds {
+			ids := []string{}
+			err = tx.Unscoped().Model(kind.value).Where("deleted_at < ?", before).Limit(trashBatch).Pluck("id", &ids).Error
+			if err != nil {
+				return err
+			}
+
+			if len(ids) == 0 {
+				continue
+			}
+
+			err = kind.purge(tx, ids)
+			if err != nil {
+				return err
+			}
+			purged += len(ids)
+		}
+
+		documentIDs := []string{}
+		err = tx.Unscoped().Model(&model.Document{}).Where("deleted_at < ?", before).Limit(trashBatch).Pluck("id", &documentIDs).Error
+		if err != nil {
+			return err
+		}
+
+		if len(documentIDs) > 0 {
+			orphans, err = purgeDocuments(tx, documentIDs)
+			if err != nil {
+				return err
+			}
+			purged += len(documentIDs)
+		}
+
+		userIDs := []string{}
+		err = tx.Unscoped().Model(&model.User{}).Where("deleted_at < ?", before).Limit(trashBatch).Pluck("id", &userIDs).Error
+		if err != nil {
+			return err
+		}
+
+		for _, id := range userIDs {
+			hashes, err := purgeUser(tx, id)
+			if err != nil {
+				return err
+			}
+			orphans = append(orphans, hashes...)
+		}
+		purged += len(userIDs)
+
+		return removeContent(tx, orphans, remove, logger)
+	})
+	if err != nil {
+		logger.Infof("Failed to purge the trash from Postgres")
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// removeContent -> calls remove for the hashes still not referenced once uploads are locked out,
+// a document created since the purge started keeps its content
+func removeContent(tx *gorm.DB, hashes []string, remove func(string) error, logger logger.Logger) error {
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	err := tx.Exec("SELECT pg_advisory_xact_lock(?)", contentLockKey).Error
+	if err != nil {
+		return err
+	}
+
+	orphans, err := orphanHashes(tx, hashes)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range orphans {
+		err = remove(hash)
+		if err != nil {
+			logger.Warnf("Couldn't remove document content %s: %s", hash, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// purgeApplications -> deletes the applications together with their timeline, interviews and links
+func purgeApplications(tx *gorm.DB, ids []string) error {
+
+	for _, value := range []interface{}{&model.ApplicationEvent{}, &model.Interview{}, &model.Reminder{}, &model.ApplicationContact{}} {
+		err := tx.Unscoped().Where("application_id IN (?)", ids).Delete(value).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Exec("DELETE FROM application_documents WHERE application_id IN (?)", ids).Error
+	if err != nil {
+		return err
+	}
+
+	// Interactions belong to the contact, they only lose the reference
+	err = tx.Unscoped().Model(&model.ContactInteraction{}).Where("application_id IN (?)", ids).Update("application_id", gorm.Expr("NULL")).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Application{}).Error
+}
+
+// purgeContacts -> deletes the contacts together with their application links and interactions
+func purgeContacts(tx *gorm.DB, ids []string) error {
+
+	for _, value := range []interface{}{&model.ApplicationContact{}, &model.ContactInteraction{}} {
+		err := tx.Unscoped().Where("contact_id IN (?)", ids).Delete(value).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Contact{}).Error
+}
+
+// purgeCompanies -> deletes the companies, their applications and contacts keep the name but lose the link
+func purgeCompanies(tx *gorm.DB, ids []string) error {
+
+	for _, value := range []interface{}{&model.Application{}, &model.Contact{}} {
+		err := tx.Unscoped().Model(value).Where("company_id IN (?)", ids).Update("company_id", gorm.Expr("NULL")).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Company{}).Error
+}
+
+// purgeInterviews ...
+func purgeInterviews(tx *gorm.DB, ids []string) error {
+
+	return tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Interview{}).Error
+}
+
+// purgeDocuments -> deletes the documents together with their application links, returns the hashes
+// of the content no other document has
+func purgeDocuments(tx *gorm.DB, ids []string) ([]string, error) {
+
+	hashes := []string{}
+	err := tx.Unscoped().Model(&model.Document{}).Where("id IN (?)", ids).Pluck("DISTINCT sha256", &hashes).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	err = tx.Exec("DELETE FROM application_documents WHERE document_id IN (?)", ids).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	err = tx.Unscoped().Where("id IN (?)", ids).Delete(&model.Document{}).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	return orphanHashes(tx, hashes)
+}
+
+// orphanHashes -> the given hashes no document has anymore, documents in the trash included
+func orphanHashes(tx *gorm.DB, hashes []string) ([]string, error) {
+
+	orphans := []string{}
+	if len(hashes) == 0 {
+		return orphans, nil
+	}
+
+	inUse := []string{}
+	err := tx.Unscoped().Model(&model.Document{}).Where("sha256 IN (?)", hashes).Pluck("DISTINCT sha256", &inUse).Error
+	if err != nil {
+		return []string{}, err
+	}
+
+	used := map[string]bool{}
+	for _, hash := range inUse {
+		used[hash] = true
+	}
+
+	for _, hash := range hashes {
+		if !used[hash] {
+			orphans = append(orphans, hash)
+		}
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,268 @@
+// +build integration
+
+package postgres
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestTrash(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	contact, err := pgRepo.CreateContact(model.Contact{Name: "Jane Recruiter", UserID: application.UserID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.LinkContact(model.ApplicationContact{
+		ApplicationID: application.ID,
+		ContactID:     contact.ID,
+		Relationship:  model.RelationshipRecruiter,
+	}, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.DeleteContact(contact.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.DeleteApplication(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.GetApplication(application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	// Deleting twice finds nothing to delete
+	_, err = pgRepo.DeleteApplication(application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	items, err := pgRepo.Trash(userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, len(*items), 2)
+	assert.Equal(t, (*items)[0].Kind, model.TrashApplication)
+	assert.Equal(t, (*items)[0].ID, application.ID)
+	assert.Equal(t, (*items)[0].Title, "Software Engineer Intern at GoCardless")
+	assert.Equal(t, (*items)[1].Kind, model.TrashContact)
+	assert.Equal(t, (*items)[1].Title, "Jane Recruiter")
+
+	restored, err := pgRepo.RestoreApplication(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, restored.ID, application.ID)
+
+	_, err = pgRepo.RestoreApplication(application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+
+	// Links to contacts in the trash come back with them
+	links, err := pgRepo.ApplicationContacts(application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*links), 0)
+
+	_, err = pgRepo.RestoreContact(contact.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	links, err = pgRepo.ApplicationContacts(application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*links), 1)
+
+	items, err = pgRepo.Trash(userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*items), 0)
+}
+
+// recordRemoved -> keeps the hashes PurgeTrash removes in removed
+func recordRemoved(removed *[]string) func(string) error {
+	return func(hash string) error {
+		*removed = append(*removed, hash)
+		return nil
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	reminder, err := pgRepo.CreateReminder(model.Reminder{
+		DueAt:         time.Now().Add(-time.Minute),
+		Message:       "Follow up",
+		ApplicationID: application.ID,
+		UserID:        application.UserID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.DeleteApplication(application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Reminders of applications in the trash can't be reached either
+	_, err = pgRepo.GetReminder(reminder.ID.String(), application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrReminderNotFound)
+
+	_, err = pgRepo.SnoozeReminder(reminder.ID.String(), application.ID.String(), userID, time.Now().Add(time.Hour))
+	assert.Equal(t, err, storage.ErrReminderNotFound)
+
+	_, err = pgRepo.DismissReminder(reminder.ID.String(), application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrReminderNotFound)
+
+	// Reminders of applications in the trash don't fire
+	reminders, err := pgRepo.ActiveReminders(userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*reminders), 0)
+
+	fired, err := pgRepo.FireDueReminders(time.Now(), func(model.Reminder) error { return nil })
+	assert.Equal(t, err, nil)
+	assert.Equal(t, fired, 0)
+
+	// Deleted after the retention cutoff
+	purged, err := pgRepo.PurgeTrash(time.Now().Add(-time.Hour), recordRemoved(&[]string{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, 0)
+
+	purged, err = pgRepo.PurgeTrash(time.Now().Add(time.Minute), recordRemoved(&[]string{}))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, 1)
+
+	db := pgRepo.postgres.DB
+	for _, table := range []string{"applications", "reminders"} {
+		count := 0
+		err = db.Table(table).Where("user_id = ?", userID).Count(&count).Error
+		assert.Equal(t, err, nil)
+		assert.Equal(t, count, 0)
+	}
+
+	_, err = pgRepo.RestoreApplication(application.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrApplicationNotFound)
+}
+
+func TestTrashDocumentsAndInterviews(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application, err := seedOneApplication()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := application.UserID.String()
+	document, err := pgRepo.CreateDocument(model.Document{Name: "Resume", Kind: model.DocumentResume, SHA256: strings.Repeat("a", 64), UserID: application.UserID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = pgRepo.LinkDocument(document.ID.String(), application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	interview, err := pgRepo.CreateInterview(model.Interview{Type: model.InterviewOnsite, ScheduledAt: time.Now(), ApplicationID: application.ID, UserID: application.UserID})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.DeleteDocument(document.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.DeleteInterview(interview.ID.String(), application.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Numbers in the trash aren't reused
+	newer, err := pgRepo.CreateDocument(model.Document{Name: "Resume", Kind: model.DocumentResume, SHA256: strings.Repeat("b", 64), UserID: application.UserID})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, newer.Version, 2)
+
+	next, err := pgRepo.CreateInterview(model.Interview{Type: model.InterviewOnsite, ScheduledAt: time.Now(), ApplicationID: application.ID, UserID: application.UserID})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, next.Round, 2)
+
+	items, err := pgRepo.Trash(userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, len(*items), 2)
+	assert.Equal(t, (*items)[0].Kind, model.TrashInterview)
+	assert.Equal(t, (*items)[0].Title, "Round 1 at GoCardless")
+	assert.Equal(t, *(*items)[0].ApplicationID, application.ID)
+	assert.Equal(t, (*items)[1].Kind, model.TrashDocument)
+	assert.Equal(t, (*items)[1].Title, "Resume v1")
+	assert.Equal(t, (*items)[1].ApplicationID, nil)
+
+	_, err = pgRepo.RestoreInterview(interview.ID.String(), application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+
+	// Links to documents in the trash come back with them
+	documents, err := pgRepo.ApplicationDocuments(application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*documents), 0)
+
+	_, err = pgRepo.RestoreDocument(document.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	documents, err = pgRepo.ApplicationDocuments(application.ID.String(), userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(*documents), 1)
+
+	// Purging a document returns its content once no other document has it
+	_, err = pgRepo.DeleteDocument(document.ID.String(), userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	orphans := []string{}
+	purged, err := pgRepo.PurgeTrash(time.Now().Add(time.Minute), recordRemoved(&orphans))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, purged, 1)
+	assert.Equal(t, orphans, []string{strings.Repeat("a", 64)})
+
+	_, err = pgRepo.RestoreDocument(document.ID.String(), userID)
+	assert.Equal(t, err, storage.ErrDocumentNotFound)
+}
@@ -1,9 +1,10 @@
 package postgres
 
 import (
-	"errors"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 	"github.com/jinzhu/gorm"
 )
 
@@ -38,8 +39,35 @@ func (repo *Repository) AllUsers() (*[]model.User, error) {
 	return &users, nil
 }
 
-// GetUser ...
-func (repo *Repository) GetUser(id string) (*model.User, error) {
+// GetUser -> users can only get themselves
+func (repo *Repository) GetUser(id, userID string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	if id != userID {
+		logger.Infof("User can't reach another user in Postgres")
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	user := model.User{}
+
+	err := db.Model(&model.User{}).Where("id = ?", id).Take(&user).Error
+	if gorm.IsRecordNotFoundError(err) {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, storage.ErrUserNotFound
+	}
+
+	if err != nil {
+		logger.Infof("Failed to get the user from Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+// FindUser -> gets any user, for lookups of the server itself and of staff
+func (repo *Repository) FindUser(id string) (*model.User, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
@@ -48,7 +76,7 @@ func (repo *Repository) GetUser(id string) (*model.User, error) {
 	err := db.Model(&model.User{}).Where("id = ?", id).Take(&user).Error
 	if gorm.IsRecordNotFoundError(err) {
 		logger.Infof("User not found in Postgres")
-		return &model.User{}, errors.New("User not found")
+		return &model.User{}, storage.ErrUserNotFound
 	}
 
 	if err != nil {
@@ -69,7 +97,7 @@ func (repo *Repository) GetUserByEmail(email string) (*model.User, error) {
 	err := db.Model(&model.User{}).Where("email = ?", email).Take(&user).Error
 	if gorm.IsRecordNotFoundError(err) {
 		logger.Infof("User not found in Postgres")
-		return &model.User{}, errors.New("User not found")
+		return &model.User{}, storage.ErrUserNotFound
 	}
 
 	if err != nil {
@@ -80,42 +108,206 @@ func (repo *Repository) GetUserByEmail(email string) (*model.User, error) {
 	return &user, nil
 }
 
-// UpdateUser ...
-func (repo *Repository) UpdateUser(user model.User, id string) (*model.User, error) {
+// UpdateUser -> updates the given profile fields, the password is changed by ChangeUserPassword.
+// A new email has to be verified again, so tokens sent to the old one are used up.
+func (repo *Repository) UpdateUser(user model.User, id, userID string) (*model.User, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 
-	err := user.BeforeSave()
-	if err != nil {
+	if id != userID {
+		logger.Infof("User can't reach another user in Postgres")
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current := model.User{}
+		err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", id).Take(&current).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return storage.ErrUserNotFound
+		}
+
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if user.FirstName != "" {
+			updates["first_name"] = user.FirstName
+		}
+
+		if user.LastName != "" {
+			updates["last_name"] = user.LastName
+		}
+
+		if user.Email != "" && user.Email != current.Email {
+			taken := 0
+			err = tx.Unscoped().Model(&model.User{}).Where("email = ? AND id <> ?", user.Email, id).Count(&taken).Error
+			if err != nil {
+				return err
+			}
+
+			if taken > 0 {
+				return storage.ErrEmailExists
+			}
+
+			updates["email"] = user.Email
+			updates["is_verified"] = false
+
+			err = tx.Model(&model.UserToken{}).
+				Where("user_id = ? AND purpose IN (?) AND used_at IS NULL", id, []model.TokenPurpose{model.TokenVerifyEmail, model.TokenResetPassword}).
+				UpdateColumn("used_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+
+		return tx.Model(&model.User{}).Where("id = ?", id).Updates(updates).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err == storage.ErrEmailExists {
+		logger.Infof("Email used by another user in Postgres")
 		return &model.User{}, err
 	}
 
-	err = db.Model(model.User{}).Updates(&user).Error
 	if err != nil {
 		logger.Infof("Failed to update the user in Postgres")
 		return &model.User{}, err
 	}
 
-	return repo.GetUser(id)
+	return repo.FindUser(id)
 }
 
-// DeleteUser ...
-func (repo *Repository) DeleteUser(id string) (int64, error) {
+// ChangeUserPassword -> sets the hashed password, every session and password reset of the user ends
+func (repo *Repository) ChangeUserPassword(id, password, userID string) (*model.User, error) {
 
 	db := repo.postgres.DB
 	logger := repo.postgres.logger
 
-	db = db.Unscoped().Model(&model.User{}).Where("id = ?", id).Take(&model.User{}).Delete(&model.User{})
-	if gorm.IsRecordNotFoundError(db.Error) {
-		logger.Infof("Failed to get the user from Postgres")
-		return 0, errors.New("User not found")
+	if id != userID {
+		logger.Infof("User can't reach another user in Postgres")
+		return &model.User{}, storage.ErrForbidden
+	}
+
+	user := model.User{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// UpdateColumns skips BeforeSave, which would hash the password again
+		result := tx.Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"password":            password,
+			"sessions_revoked_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		err := tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&model.UserToken{}).Where("user_id = ? AND purpose = ? AND used_at IS NULL", id, model.TokenResetPassword).UpdateColumn("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", id).Take(&user).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to change the password in Postgres")
+		return &model.User{}, err
+	}
+
+	return &user, nil
+}
+
+// RehashUserPassword -> replaces the hash of an unchanged password by one with the current parameters, sessions go on.
+// Nothing happens when the password changed since hash was read.
+func (repo *Repository) RehashUserPassword(id, hash, rehash string) error {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	// UpdateColumn skips BeforeSave, which would hash the password again
+	err := db.Model(&model.User{}).Where("id = ? AND password = ?", id, hash).UpdateColumn("password", rehash).Error
+	if err != nil {
+		logger.Infof("Failed to rehash the password in Postgres")
+		return err
+	}
+
+	return nil
+}
+
+// DisableUser -> disables the user and revokes all of their sessions
+func (repo *Repository) DisableUser(id string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&model.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+			"disabled_at":         gorm.Expr("COALESCE(disabled_at, ?)", now),
+			"sessions_revoked_at": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return storage.ErrUserNotFound
+		}
+
+		return tx.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", id).UpdateColumn("revoked_at", now).Error
+	})
+	if err == storage.ErrUserNotFound {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, err
+	}
+
+	if err != nil {
+		logger.Infof("Failed to disable the user in Postgres")
+		return &model.User{}, err
 	}
 
-	if db.Error != nil {
-		logger.Infof("Failed to delete the user from Postgres")
-		return 0, db.Error
+	return repo.FindUser(id)
+}
+
+// EnableUser -> lets a disabled user sign in again
+func (repo *Repository) EnableUser(id string) (*model.User, error) {
+
+	db := repo.postgres.DB
+	logger := repo.postgres.logger
+
+	result := db.Model(&model.User{}).Where("id = ?", id).UpdateColumn("disabled_at", nil)
+	if result.Error != nil {
+		logger.Infof("Failed to enable the user in Postgres")
+		return &model.User{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		logger.Infof("User not found in Postgres")
+		return &model.User{}, storage.ErrUserNotFound
 	}
 
-	return db.RowsAffected, nil
+	return repo.FindUser(id)
 }
@@ -5,8 +5,10 @@ package postgres
 import (
 	"log"
 	"testing"
+	"time"
 
 	"github.com/amaraliou/trackr-core/internal/model"
+	"github.com/amaraliou/trackr-core/internal/storage"
 	uuid "github.com/satori/go.uuid"
 	"gopkg.in/go-playground/assert.v1"
 )
@@ -43,7 +45,7 @@ func TestGetUser(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	retrievedUser, err := pgRepo.GetUser(user.ID.String())
+	retrievedUser, err := pgRepo.GetUser(user.ID.String(), user.ID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -61,13 +63,13 @@ func TestNonExistentUser(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	_, err = pgRepo.GetUser(randomUUID.String())
+	_, err = pgRepo.FindUser(randomUUID.String())
 	assert.Equal(t, err.Error(), "User not found")
 
 	_, err = pgRepo.GetUserByEmail(randomEmail)
 	assert.Equal(t, err.Error(), "User not found")
 
-	_, err = pgRepo.DeleteUser(randomUUID.String())
+	err = pgRepo.DeleteUser(randomUUID.String(), time.Now())
 	assert.Equal(t, err.Error(), "User not found")
 }
 
@@ -113,6 +115,39 @@ func TestCreateUser(t *testing.T) {
 	assert.Equal(t, newUser.Email, createdUser.Email)
 }
 
+func TestForeignUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users, err := seedUsers()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	userID := (*users)[0].ID.String()
+	otherUserID := (*users)[1].ID.String()
+
+	_, err = pgRepo.GetUser(userID, otherUserID)
+	assert.Equal(t, err, storage.ErrForbidden)
+
+	_, err = pgRepo.UpdateUser(model.User{FirstName: "Mario"}, userID, otherUserID)
+	assert.Equal(t, err, storage.ErrForbidden)
+
+	_, err = pgRepo.ChangeUserPassword(userID, "hash", otherUserID)
+	assert.Equal(t, err, storage.ErrForbidden)
+
+	_, err = pgRepo.ScheduleUserDeletion(userID, time.Now(), otherUserID)
+	assert.Equal(t, err, storage.ErrForbidden)
+
+	user, err := pgRepo.FindUser(userID)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, user.FirstName, (*users)[0].FirstName)
+	assert.Equal(t, user.DeletionScheduled(), false)
+}
+
 func TestUpdateUser(t *testing.T) {
 
 	err := refreshEverything()
@@ -129,7 +164,7 @@ func TestUpdateUser(t *testing.T) {
 		FirstName: "Mario",
 	}
 
-	updatedUser, err := pgRepo.UpdateUser(userUpdate, user.ID.String())
+	updatedUser, err := pgRepo.UpdateUser(userUpdate, user.ID.String(), user.ID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -137,7 +172,53 @@ func TestUpdateUser(t *testing.T) {
 	assert.Equal(t, updatedUser.FirstName, "Mario")
 }
 
-func TestDeleteUser(t *testing.T) {
+func TestUpdateUser_Email(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users, err := seedUsers()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user := (*users)[0]
+	other := (*users)[1]
+	password := user.Password
+
+	err = pgRepo.postgres.DB.Model(&user).UpdateColumn("is_verified", true).Error
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	token, err := pgRepo.CreateUserToken(model.UserToken{
+		Purpose:   model.TokenVerifyEmail,
+		Hash:      "hash",
+		ExpiresAt: time.Now().Add(time.Hour),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = pgRepo.UpdateUser(model.User{Email: other.Email}, user.ID.String(), user.ID.String())
+	assert.Equal(t, err, storage.ErrEmailExists)
+
+	// The password isn't touched by profile updates
+	updatedUser, err := pgRepo.UpdateUser(model.User{Email: "mario@gmail.com"}, user.ID.String(), user.ID.String())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, updatedUser.Email, "mario@gmail.com")
+	assert.Equal(t, updatedUser.IsVerified, false)
+	assert.Equal(t, updatedUser.Password, password)
+
+	// Tokens sent to the old email can't verify the new one
+	_, err = pgRepo.VerifyUserEmail(token.Hash)
+	assert.Equal(t, err, storage.ErrTokenInvalid)
+}
+
+func TestChangeUserPassword(t *testing.T) {
 
 	err := refreshEverything()
 	if err != nil {
@@ -149,10 +230,81 @@ func TestDeleteUser(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	isDeleted, err := pgRepo.DeleteUser(user.ID.String())
+	hashedPassword, err := model.Hash("newpassword")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	randomID := uuid.NewV4().String()
+	_, err = pgRepo.ChangeUserPassword(randomID, string(hashedPassword), randomID)
+	assert.Equal(t, err, storage.ErrUserNotFound)
+
+	changedUser, err := pgRepo.ChangeUserPassword(user.ID.String(), string(hashedPassword), user.ID.String())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, model.VerifyPassword(changedUser.Password, "newpassword"), nil)
+	assert.NotEqual(t, changedUser.SessionsRevokedAt, nil)
+}
+
+func TestRehashUserPassword(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rehash, err := model.Hash("johndoe")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// A stale hash means the password changed meanwhile, so nothing is replaced
+	err = pgRepo.RehashUserPassword(user.ID.String(), "stale", string(rehash))
+	assert.Equal(t, err, nil)
+
+	unchangedUser, err := pgRepo.FindUser(user.ID.String())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, unchangedUser.Password, user.Password)
+
+	err = pgRepo.RehashUserPassword(user.ID.String(), user.Password, string(rehash))
+	assert.Equal(t, err, nil)
+
+	rehashedUser, err := pgRepo.FindUser(user.ID.String())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rehashedUser.Password, string(rehash))
+	assert.Equal(t, rehashedUser.SessionsRevokedAt, (*time.Time)(nil))
+}
+
+func TestDisableUser(t *testing.T) {
+
+	err := refreshEverything()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	user, err := seedOneUser()
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, user.Role, model.RoleUser)
+
+	disabledUser, err := pgRepo.DisableUser(user.ID.String())
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, disabledUser.Disabled(), true)
+	assert.Equal(t, disabledUser.SessionValid(time.Now().Add(-time.Minute)), false)
+
+	enabledUser, err := pgRepo.EnableUser(user.ID.String())
 	if err != nil {
 		log.Fatal(err)
 	}
+	assert.Equal(t, enabledUser.Disabled(), false)
 
-	assert.Equal(t, isDeleted, int64(1))
+	_, err = pgRepo.DisableUser(uuid.NewV4().String())
+	assert.Equal(t, err, storage.ErrUserNotFound)
 }
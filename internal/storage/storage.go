@@ -1,19 +1,171 @@
 package storage
 
-import "github.com/amaraliou/trackr-core/internal/model"
+import (
+	"errors"
+	"time"
+
+	"github.com/amaraliou/trackr-core/internal/model"
+)
+
+var (
+	// ErrUserNotFound ...
+	ErrUserNotFound = errors.New("User not found")
+	// ErrForbidden is returned when a user reaches for the account of another user
+	ErrForbidden = errors.New("Forbidden")
+	// ErrApplicationNotFound is also returned for applications owned by someone else
+	ErrApplicationNotFound = errors.New("Application not found")
+	// ErrCompanyNotFound is also returned for companies owned by someone else
+	ErrCompanyNotFound = errors.New("Company not found")
+	// ErrCompanyExists is returned when the user has a company with the same name, ignoring case
+	ErrCompanyExists = errors.New("Company already exists")
+	// ErrInterviewNotFound is also returned for interviews of another application
+	ErrInterviewNotFound = errors.New("Interview not found")
+	// ErrDocumentNotFound is also returned for documents owned by someone else
+	ErrDocumentNotFound = errors.New("Document not found")
+	// ErrDocumentExists is returned with the latest version when it has the same content
+	ErrDocumentExists = errors.New("Document already exists")
+	// ErrContactNotFound is also returned for contacts owned by someone else
+	ErrContactNotFound = errors.New("Contact not found")
+	// ErrReminderNotFound is also returned for reminders of another application
+	ErrReminderNotFound = errors.New("Reminder not found")
+	// ErrTokenInvalid is returned for unknown, used and expired user tokens alike
+	ErrTokenInvalid = errors.New("Invalid or expired token")
+	// ErrAPIKeyNotFound is also returned for API keys owned by someone else or revoked
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrIdentityConflict is returned when an identity's email belongs to another user and the provider didn't verify it
+	ErrIdentityConflict = errors.New("Email already used by another account")
+	// ErrEmailExists is returned when a user changes their email to the one of another user
+	ErrEmailExists = errors.New("Email already in use")
+	// ErrTokenReused is returned when a refresh token is used after its rotation, its whole family is revoked
+	ErrTokenReused = errors.New("Refresh token reused")
+)
 
 // PostgresInterface ...
 type PostgresInterface interface {
+	// User methods taking the user ID as last argument fail with ErrForbidden for any other user, users only reach themselves.
+	// FindUser gets any user, for the server's own lookups and for staff.
 	CreateUser(model.User) (*model.User, error)
-	GetUser(string) (*model.User, error)
+	GetUser(string, string) (*model.User, error)
+	FindUser(string) (*model.User, error)
 	GetUserByEmail(string) (*model.User, error)
-	UpdateUser(model.User, string) (*model.User, error)
-	DeleteUser(string) (int64, error)
+	UpdateUser(model.User, string, string) (*model.User, error)
+	ChangeUserPassword(string, string, string) (*model.User, error)
+	RehashUserPassword(string, string, string) error
 	AllUsers() (*[]model.User, error)
+	CreateUserToken(model.UserToken) (*model.UserToken, error)
+	CountUserTokens(string, model.TokenPurpose, time.Time) (int, error)
+	GetTokenUser(string, model.TokenPurpose) (*model.User, error)
+	VerifyUserEmail(string) (*model.User, error)
+	ResetUserPassword(string, string) (*model.User, error)
+	DisableUser(string) (*model.User, error)
+	EnableUser(string) (*model.User, error)
+	CreateAuditEvent(model.AuditEvent) (*model.AuditEvent, error)
+
+	// Deletion methods handle accounts users asked to delete, DeleteUser moves them to the trash until PurgeTrash
+	ScheduleUserDeletion(string, time.Time, string) (*model.User, error)
+	CancelUserDeletion(string) (*model.User, error)
+	DueUserDeletions(time.Time) ([]string, error)
+	DeleteUser(string, time.Time) error
+	DeletedUsers() (*[]model.TrashItem, error)
+	RestoreUser(string) (*model.User, error)
+	ExportUser(string, string) (*model.UserExport, error)
+
+	// Login throttle methods take keys like account:<email> or ip:<address>
+	LoginLockedUntil([]string) (time.Time, error)
+	RecordLoginFailure(string, model.LockoutPolicy) (*model.LoginThrottle, error)
+	ResetLoginFailures(string) error
+
+	// Session methods handle refresh tokens and revoked access tokens
+	CreateRefreshToken(model.RefreshToken) (*model.RefreshToken, error)
+	RotateRefreshToken(string, model.RefreshToken) (*model.RefreshToken, error)
+	RevokeRefreshToken(string, string) error
+	RevokeUserSessions(string) error
+	DenyToken(string, time.Time) error
+	TokenDenied(string) (bool, error)
+
+	// MFA methods handle two-factor enrolment and the challenges of two-step logins
+	SetTOTPSecret(string, string) (*model.User, error)
+	EnableTOTP(string, int64, []string) (*model.User, error)
+	DisableTOTP(string) (*model.User, error)
+	ReplaceRecoveryCodes(string, []string) error
+	UseTOTPStep(string, int64) error
+	UseRecoveryCode(string, string) error
+	GetMFAChallenge(string) (*model.User, error)
+	FailMFAChallenge(string) error
+	CompleteMFAChallenge(string) (*model.User, error)
+
+	// Identity methods handle sign in with external identity providers
+	CreateOIDCState(model.OIDCState) (*model.OIDCState, error)
+	ConsumeOIDCState(string, string) (*model.OIDCState, error)
+	SignInWithIdentity(model.Identity, model.User) (*model.User, error)
+
+	// API key methods are scoped to the user ID given as last argument
+	CreateAPIKey(model.APIKey) (*model.APIKey, error)
+	AllAPIKeys(string) (*[]model.APIKey, error)
+	RevokeAPIKey(string, string) error
+	AuthenticateAPIKey(string, string) (*model.APIKey, error)
 
+	// Application methods are scoped to the user ID given as last argument
 	CreateApplication(model.Application) (*model.Application, error)
-	GetApplication(string) (*model.Application, error)
-	UpdateApplication(model.Application, string) (*model.Application, error)
-	DeleteApplication(string) (int64, error)
-	AllApplications() (*[]model.Application, error)
+	GetApplication(string, string) (*model.Application, error)
+	UpdateApplication(model.Application, string, string) (*model.Application, error)
+	ReplaceApplication(model.Application, string, string) (*model.Application, error)
+	DeleteApplication(string, string) (int64, error)
+	RestoreApplication(string, string) (*model.Application, error)
+	AllApplications(string) (*[]model.Application, error)
+	GetApplicationTimeline(string, string) (*[]model.ApplicationEvent, error)
+
+	CreateCompany(model.Company) (*model.Company, error)
+	GetCompany(string, string) (*model.Company, error)
+	UpdateCompany(model.Company, string, string) (*model.Company, error)
+	DeleteCompany(string, string) (int64, error)
+	RestoreCompany(string, string) (*model.Company, error)
+	AllCompanies(string) (*[]model.Company, error)
+
+	// Interview methods take the application ID and the user ID as last arguments
+	CreateInterview(model.Interview) (*model.Interview, error)
+	GetInterview(string, string, string) (*model.Interview, error)
+	UpdateInterview(model.Interview, string, string, string) (*model.Interview, error)
+	DeleteInterview(string, string, string) (int64, error)
+	RestoreInterview(string, string, string) (*model.Interview, error)
+	AllInterviews(string, string) (*[]model.Interview, error)
+
+	CreateDocument(model.Document) (*model.Document, error)
+	GetDocument(string, string) (*model.Document, error)
+	DeleteDocument(string, string) (int64, error)
+	RestoreDocument(string, string) (*model.Document, error)
+	AllDocuments(string) (*[]model.Document, error)
+	DocumentVersions(string, string) (*[]model.Document, error)
+	LinkDocument(string, string, string) error
+	UnlinkDocument(string, string, string) error
+	ApplicationDocuments(string, string) (*[]model.Document, error)
+
+	CreateContact(model.Contact) (*model.Contact, error)
+	GetContact(string, string) (*model.Contact, error)
+	UpdateContact(model.Contact, string, string) (*model.Contact, error)
+	DeleteContact(string, string) (int64, error)
+	RestoreContact(string, string) (*model.Contact, error)
+	AllContacts(string) (*[]model.Contact, error)
+	LinkContact(model.ApplicationContact, string) (*model.ApplicationContact, error)
+	UnlinkContact(string, string, string) error
+	ApplicationContacts(string, string) (*[]model.ApplicationContact, error)
+	CreateContactInteraction(model.ContactInteraction) (*model.ContactInteraction, error)
+	AllContactInteractions(string, string) (*[]model.ContactInteraction, error)
+
+	// Reminder methods take the application ID and the user ID as last arguments
+	CreateReminder(model.Reminder) (*model.Reminder, error)
+	GetReminder(string, string, string) (*model.Reminder, error)
+	AllReminders(string, string) (*[]model.Reminder, error)
+	ActiveReminders(string) (*[]model.Reminder, error)
+	SnoozeReminder(string, string, string, time.Time) (*model.Reminder, error)
+	DismissReminder(string, string, string) (*model.Reminder, error)
+	FireDueReminders(time.Time, func(model.Reminder) error) (int, error)
+
+	// Trash methods handle soft deleted applications, contacts, companies, documents and interviews, PurgeTrash
+	// deletes those and the users deleted before the given time, and removes the content nothing references anymore
+	Trash(string) (*[]model.TrashItem, error)
+	PurgeTrash(time.Time, func(string) error) (int, error)
+
+	EnqueueEmail(model.Email) (*model.Email, error)
+	DeliverQueuedEmails(time.Time, func(model.Email) error) (int, error)
 }